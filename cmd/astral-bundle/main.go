@@ -0,0 +1,124 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/lib/astrald"
+	dircli "github.com/cryptopunkscc/astrald/mod/dir/client"
+	objectscli "github.com/cryptopunkscc/astrald/mod/objects/client"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	switch os.Args[1] {
+	case "export":
+		export(os.Args[2:])
+	case "import":
+		importBundle(os.Args[2:])
+	default:
+		usage()
+	}
+}
+
+// export writes a bundle of the listed objects to a file or stdout
+func export(args []string) {
+	var target, out string
+	var recursive bool
+
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	flags.StringVar(&target, "target", "localnode", "target node")
+	flags.StringVar(&out, "o", "", "output file (default stdout)")
+	flags.BoolVar(&recursive, "r", false, "include referenced objects")
+	flags.Parse(args)
+
+	var ids []*astral.ObjectID
+	for _, arg := range flags.Args() {
+		id, err := astral.ParseID(arg)
+		if err != nil {
+			fatal("invalid object id %s: %v", arg, err)
+		}
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 {
+		fatal("no objects to export")
+	}
+
+	ctx := astrald.NewContext()
+	client := newClient(ctx, target)
+
+	var w io.Writer = os.Stdout
+	if len(out) > 0 {
+		f, err := os.Create(out)
+		if err != nil {
+			fatal("create %s: %v", out, err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	err := client.Export(ctx, ids, recursive, w)
+	if err != nil {
+		if len(out) > 0 {
+			os.Remove(out)
+		}
+		fatal("export: %v", err)
+	}
+}
+
+// importBundle stores the objects of a bundle read from a file or stdin and prints their IDs
+func importBundle(args []string) {
+	var target, repo string
+
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	flags.StringVar(&target, "target", "localnode", "target node")
+	flags.StringVar(&repo, "repo", "", "target repository")
+	flags.Parse(args)
+
+	var r io.Reader = os.Stdin
+	if flags.NArg() > 0 {
+		f, err := os.Open(flags.Arg(0))
+		if err != nil {
+			fatal("open: %v", err)
+		}
+		defer f.Close()
+		r = f
+	}
+
+	ctx := astrald.NewContext()
+	client := newClient(ctx, target)
+
+	ids, err := client.Import(ctx, repo, r)
+	for _, id := range ids {
+		fmt.Println(id)
+	}
+	if err != nil {
+		fatal("import: %v", err)
+	}
+}
+
+func newClient(ctx *astral.Context, target string) *objectscli.Client {
+	targetID, err := dircli.ResolveIdentity(ctx, target)
+	if err != nil {
+		fatal("resolve target: %v", err)
+	}
+
+	return objectscli.New(targetID, nil)
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: astral-bundle export [-target node] [-r] [-o file] <objectID>...")
+	fmt.Fprintln(os.Stderr, "       astral-bundle import [-target node] [-repo repo] [file]")
+	os.Exit(2)
+}
+
+func fatal(f string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "error: "+f+"\n", args...)
+	os.Exit(1)
+}
@@ -0,0 +1,212 @@
+package apphost
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"time"
+
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/mod/objects"
+	"golang.org/x/net/webdav"
+)
+
+var _ webdav.File = &davDir{}
+var _ webdav.File = &davObject{}
+var _ webdav.File = &davUpload{}
+
+// davInfo describes a folder or an object. Objects report their sniffed content type and
+// their ID as the ETag, so that listings don't need to read them.
+type davInfo struct {
+	fs       *davFS
+	name     string
+	size     int64
+	dir      bool
+	objectID *astral.ObjectID
+}
+
+func (fs *davFS) dirInfo(name string) *davInfo {
+	return &davInfo{fs: fs, name: name, dir: true}
+}
+
+func (fs *davFS) objectInfo(name string, objectID *astral.ObjectID) *davInfo {
+	return &davInfo{fs: fs, name: name, size: int64(objectID.Size), objectID: objectID}
+}
+
+func (info *davInfo) Name() string { return info.name }
+
+func (info *davInfo) Size() int64 { return info.size }
+
+func (info *davInfo) Mode() os.FileMode {
+	if info.dir {
+		return os.ModeDir | 0555
+	}
+	return 0444
+}
+
+func (info *davInfo) ModTime() time.Time { return time.Unix(0, 0) }
+
+func (info *davInfo) IsDir() bool { return info.dir }
+
+func (info *davInfo) Sys() any { return nil }
+
+func (info *davInfo) ContentType(ctx context.Context) (string, error) {
+	if info.objectID == nil {
+		return "", webdav.ErrNotImplemented
+	}
+	return info.fs.mod.Objects.ContentType(info.fs.context(ctx), info.objectID)
+}
+
+func (info *davInfo) ETag(context.Context) (string, error) {
+	if info.objectID == nil {
+		return "", webdav.ErrNotImplemented
+	}
+	return `"` + info.objectID.String() + `"`, nil
+}
+
+// davDir is an open folder. Its entries are listed on the first Readdir.
+type davDir struct {
+	info    *davInfo
+	list    func() ([]os.FileInfo, error)
+	entries []os.FileInfo
+	listed  bool
+}
+
+func (fs *davFS) dir(name string, list func() ([]os.FileInfo, error)) *davDir {
+	return &davDir{info: fs.dirInfo(name), list: list}
+}
+
+func (d *davDir) Readdir(count int) ([]os.FileInfo, error) {
+	if !d.listed {
+		entries, err := d.list()
+		if err != nil {
+			return nil, err
+		}
+		d.entries, d.listed = entries, true
+	}
+
+	if count <= 0 {
+		entries := d.entries
+		d.entries = nil
+		return entries, nil
+	}
+
+	if len(d.entries) == 0 {
+		return nil, io.EOF
+	}
+
+	n := min(count, len(d.entries))
+	entries := d.entries[:n]
+	d.entries = d.entries[n:]
+	return entries, nil
+}
+
+func (d *davDir) Stat() (os.FileInfo, error) { return d.info, nil }
+
+func (d *davDir) Read([]byte) (int, error) { return 0, os.ErrInvalid }
+
+func (d *davDir) Seek(int64, int) (int64, error) { return 0, os.ErrInvalid }
+
+func (d *davDir) Write([]byte) (int, error) { return 0, os.ErrPermission }
+
+func (d *davDir) Close() error { return nil }
+
+// davObject is an object opened for reading. The object is read only when its data is
+// requested, since WebDAV clients open files just to list their properties.
+type davObject struct {
+	info *davInfo
+	ctx  *astral.Context
+	repo objects.Repository
+	r    *objects.ReadSeeker
+}
+
+func (fs *davFS) object(ctx *astral.Context, name string, objectID *astral.ObjectID, repo objects.Repository) *davObject {
+	return &davObject{info: fs.objectInfo(name, objectID), ctx: ctx, repo: repo}
+}
+
+func (f *davObject) reader() *objects.ReadSeeker {
+	if f.r == nil {
+		f.r = objects.NewReadSeeker(f.ctx, f.info.objectID, f.repo, nil)
+	}
+	return f.r
+}
+
+func (f *davObject) Read(p []byte) (int, error) { return f.reader().Read(p) }
+
+func (f *davObject) Seek(offset int64, whence int) (int64, error) {
+	return f.reader().Seek(offset, whence)
+}
+
+func (f *davObject) Readdir(int) ([]os.FileInfo, error) { return nil, os.ErrInvalid }
+
+func (f *davObject) Stat() (os.FileInfo, error) { return f.info, nil }
+
+func (f *davObject) Write([]byte) (int, error) { return 0, os.ErrPermission }
+
+func (f *davObject) Close() error {
+	if f.r != nil {
+		_ = f.r.Close()
+	}
+	return nil
+}
+
+// errUploadIncomplete is returned when an upload is closed before all of its data was written.
+var errUploadIncomplete = errors.New("upload incomplete")
+
+// davUpload writes a new object. The object is committed on Close and passed to done, unless
+// a write failed or the request body ended early, in which case it is discarded.
+type davUpload struct {
+	name string
+	size int64
+	w    objects.Writer
+	body *davBody // nil if the upload does not come from a request
+	err  error
+	done func(*astral.ObjectID) error
+}
+
+func (fs *davFS) upload(ctx *astral.Context, repo objects.Repository, name string, done func(*astral.ObjectID) error) (*davUpload, error) {
+	w, err := repo.Create(ctx, &objects.CreateOpts{})
+	if err != nil {
+		return nil, err
+	}
+
+	body, _ := ctx.Value(davBodyKey{}).(*davBody)
+
+	return &davUpload{name: name, w: w, body: body, done: done}, nil
+}
+
+func (f *davUpload) Write(p []byte) (n int, err error) {
+	n, err = f.w.Write(p)
+	f.size += int64(n)
+	if err != nil && f.err == nil {
+		f.err = err
+	}
+	return
+}
+
+func (f *davUpload) Close() error {
+	if f.err == nil && f.body != nil && !f.body.complete {
+		f.err = errUploadIncomplete
+	}
+	if f.err != nil {
+		_ = f.w.Discard()
+		return f.err
+	}
+
+	objectID, err := f.w.Commit()
+	if err != nil || f.done == nil {
+		return err
+	}
+	return f.done(objectID)
+}
+
+func (f *davUpload) Stat() (os.FileInfo, error) {
+	return &davInfo{name: f.name, size: f.size}, nil
+}
+
+func (f *davUpload) Read([]byte) (int, error) { return 0, os.ErrInvalid }
+
+func (f *davUpload) Seek(int64, int) (int64, error) { return 0, os.ErrInvalid }
+
+func (f *davUpload) Readdir(int) ([]os.FileInfo, error) { return nil, os.ErrInvalid }
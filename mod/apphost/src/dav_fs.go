@@ -0,0 +1,301 @@
+package apphost
+
+import (
+	"context"
+	"maps"
+	"os"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/mod/auth"
+	"github.com/cryptopunkscc/astrald/mod/objects"
+	"github.com/cryptopunkscc/astrald/mod/tree"
+	"golang.org/x/net/webdav"
+)
+
+const (
+	davRepos = "repos"
+	davTree  = "tree"
+
+	// DAVTreeRoot is the part of the tree shared over WebDAV. Guests cannot see or write nodes outside of it.
+	DAVTreeRoot = "/mod/apphost/dav"
+)
+
+var _ webdav.FileSystem = &davFS{}
+
+// davFS presents repositories and the DAVTreeRoot subtree as a WebDAV file system of a guest.
+// Repositories are folders of objects named by their IDs. Tree nodes holding an object ID are
+// files, nodes holding no value are folders and other nodes are hidden. Objects the guest cannot
+// read are hidden; creating objects requires objects.CreateObjectAction. Objects are immutable,
+// so nothing can be removed or renamed.
+type davFS struct {
+	mod      *Module
+	identity *astral.Identity
+}
+
+func (fs *davFS) Mkdir(ctx context.Context, name string, _ os.FileMode) error {
+	actx := fs.context(ctx)
+
+	seg := davSplit(name)
+	if len(seg) < 2 || seg[0] != davTree || fs.mod.Tree == nil {
+		return os.ErrPermission
+	}
+
+	if !fs.canCreate(actx) {
+		return os.ErrPermission
+	}
+
+	treePath := davTreePath(seg[1:])
+
+	parent, err := tree.Query(actx, fs.mod.Tree.Root(), path.Dir(treePath), len(seg) == 2)
+	if err != nil {
+		return os.ErrNotExist
+	}
+	if objectID, visible := treeEntry(actx, parent); objectID != nil || !visible {
+		return os.ErrNotExist
+	}
+	if _, err := tree.Query(actx, fs.mod.Tree.Root(), treePath, false); err == nil {
+		return os.ErrExist
+	}
+
+	_, err = tree.Query(actx, fs.mod.Tree.Root(), treePath, true)
+	return err
+}
+
+func (fs *davFS) OpenFile(ctx context.Context, name string, flag int, _ os.FileMode) (webdav.File, error) {
+	actx := fs.context(ctx)
+
+	if flag&(os.O_WRONLY|os.O_RDWR) != 0 {
+		return fs.create(actx, name)
+	}
+
+	seg := davSplit(name)
+	switch {
+	case len(seg) == 0:
+		return fs.dir("/", func() ([]os.FileInfo, error) {
+			return []os.FileInfo{fs.dirInfo(davRepos), fs.dirInfo(davTree)}, nil
+		}), nil
+
+	case seg[0] == davRepos:
+		return fs.openRepo(actx, seg[1:])
+
+	case seg[0] == davTree:
+		return fs.openTree(actx, seg[1:])
+	}
+
+	return nil, os.ErrNotExist
+}
+
+func (fs *davFS) RemoveAll(context.Context, string) error {
+	return os.ErrPermission
+}
+
+func (fs *davFS) Rename(context.Context, string, string) error {
+	return os.ErrPermission
+}
+
+func (fs *davFS) Stat(ctx context.Context, name string) (os.FileInfo, error) {
+	f, err := fs.OpenFile(ctx, name, os.O_RDONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return f.Stat()
+}
+
+// openRepo opens the list of repositories, a repository or an object in a repository.
+func (fs *davFS) openRepo(ctx *astral.Context, seg []string) (webdav.File, error) {
+	if len(seg) == 0 {
+		return fs.dir(davRepos, func() (list []os.FileInfo, err error) {
+			repos := fs.mod.Objects.Repositories()
+			for _, name := range slices.Sorted(maps.Keys(repos)) {
+				list = append(list, fs.dirInfo(name))
+			}
+			return
+		}), nil
+	}
+
+	repo := fs.mod.Objects.GetRepository(seg[0])
+	if repo == nil {
+		return nil, os.ErrNotExist
+	}
+
+	switch len(seg) {
+	case 1:
+		return fs.dir(seg[0], func() (list []os.FileInfo, err error) {
+			scan, err := repo.Scan(ctx, false)
+			if err != nil {
+				return nil, err
+			}
+
+			for objectID := range scan {
+				if fs.canRead(ctx, objectID) {
+					list = append(list, fs.objectInfo(objectID.String(), objectID))
+				}
+			}
+			return
+		}), nil
+
+	case 2:
+		objectID, err := astral.ParseID(seg[1])
+		if err != nil || !fs.canRead(ctx, objectID) {
+			return nil, os.ErrNotExist
+		}
+
+		if has, _ := repo.Contains(ctx, objectID); !has {
+			return nil, os.ErrNotExist
+		}
+
+		return fs.object(ctx, seg[1], objectID, repo), nil
+	}
+
+	return nil, os.ErrNotExist
+}
+
+// openTree opens a tree node as a file if it holds an object ID or as a folder otherwise.
+func (fs *davFS) openTree(ctx *astral.Context, seg []string) (webdav.File, error) {
+	if fs.mod.Tree == nil {
+		return nil, os.ErrNotExist
+	}
+
+	// the shared subtree is created on first use
+	node, err := tree.Query(ctx, fs.mod.Tree.Root(), davTreePath(seg), len(seg) == 0)
+	if err != nil {
+		return nil, os.ErrNotExist
+	}
+
+	name := davTree
+	if len(seg) > 0 {
+		name = seg[len(seg)-1]
+	}
+
+	objectID, visible := treeEntry(ctx, node)
+	switch {
+	case !visible:
+		return nil, os.ErrNotExist
+
+	case objectID != nil:
+		if !fs.canRead(ctx, objectID) {
+			return nil, os.ErrNotExist
+		}
+		return fs.object(ctx, name, objectID, fs.mod.Objects.ReadDefault()), nil
+	}
+
+	return fs.dir(name, func() (list []os.FileInfo, err error) {
+		subs, err := node.Sub(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, name := range slices.Sorted(maps.Keys(subs)) {
+			objectID, visible := treeEntry(ctx, subs[name])
+			switch {
+			case !visible:
+			case objectID == nil:
+				list = append(list, fs.dirInfo(name))
+			case fs.canRead(ctx, objectID):
+				list = append(list, fs.objectInfo(name, objectID))
+			}
+		}
+		return
+	}), nil
+}
+
+// create opens an upload to a repository or to a node of the shared subtree. Uploads to the tree
+// are stored in the default repository and their IDs are set as the node's value. Only nodes that
+// don't exist can be written, so a guest cannot replace the objects of existing nodes.
+func (fs *davFS) create(ctx *astral.Context, name string) (webdav.File, error) {
+	if !fs.canCreate(ctx) {
+		return nil, os.ErrPermission
+	}
+
+	seg := davSplit(name)
+	switch {
+	case len(seg) == 3 && seg[0] == davRepos:
+		repo := fs.mod.Objects.GetRepository(seg[1])
+		if repo == nil {
+			return nil, os.ErrNotExist
+		}
+
+		return fs.upload(ctx, repo, seg[2], nil)
+
+	case len(seg) >= 2 && seg[0] == davTree && fs.mod.Tree != nil:
+		treePath := davTreePath(seg[1:])
+		root := fs.mod.Tree.Root()
+
+		parent, err := tree.Query(ctx, root, path.Dir(treePath), len(seg) == 2)
+		if err != nil {
+			return nil, os.ErrNotExist
+		}
+		if objectID, visible := treeEntry(ctx, parent); objectID != nil || !visible {
+			return nil, os.ErrNotExist
+		}
+
+		if _, err := tree.Query(ctx, root, treePath, false); err == nil {
+			return nil, os.ErrExist
+		}
+
+		return fs.upload(ctx, fs.mod.Objects.WriteDefault(), seg[len(seg)-1], func(objectID *astral.ObjectID) error {
+			// the node could have been created during the upload
+			if _, err := tree.Query(ctx, root, treePath, false); err == nil {
+				return os.ErrExist
+			}
+			return fs.mod.Tree.Set(ctx, treePath, objectID)
+		})
+	}
+
+	return nil, os.ErrPermission
+}
+
+func (fs *davFS) canRead(ctx *astral.Context, objectID *astral.ObjectID) bool {
+	return fs.mod.Auth.Authorize(ctx, &objects.ReadObjectAction{
+		Action:   auth.NewAction(fs.identity),
+		ObjectID: objectID,
+	})
+}
+
+func (fs *davFS) canCreate(ctx *astral.Context) bool {
+	return fs.mod.Auth.Authorize(ctx, &objects.CreateObjectAction{
+		Action: auth.NewAction(fs.identity),
+	})
+}
+
+// context returns the context of a request of the guest. Requests don't reach the network.
+func (fs *davFS) context(ctx context.Context) *astral.Context {
+	return astral.NewContext(ctx).WithIdentity(fs.identity).WithZone(astral.ZoneDevice | astral.ZoneVirtual)
+}
+
+// davSplit splits a slash separated path into its segments.
+func davSplit(name string) []string {
+	name = strings.Trim(path.Clean("/"+name), "/")
+	if len(name) == 0 {
+		return nil
+	}
+	return strings.Split(name, "/")
+}
+
+// davTreePath returns the path of a node of the shared subtree.
+func davTreePath(seg []string) string {
+	return path.Join(append([]string{DAVTreeRoot}, seg...)...)
+}
+
+// treeEntry returns the object ID held by a tree node and whether the node is shown to guests.
+// Nodes holding no value are shown as folders, nodes holding anything but an object ID are hidden.
+func treeEntry(ctx *astral.Context, node tree.Node) (objectID *astral.ObjectID, visible bool) {
+	values, err := node.Get(ctx, false)
+	if err != nil {
+		return nil, false
+	}
+
+	switch value := (<-values).(type) {
+	case *astral.ObjectID:
+		return value, true
+	case *astral.Nil, nil:
+		return nil, true
+	}
+
+	return nil, false
+}
@@ -0,0 +1,73 @@
+package apphost
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+
+	"golang.org/x/net/webdav"
+)
+
+// DAVPrefix is the path under which HTTPServer serves repositories and the tree over WebDAV.
+const DAVPrefix = "/.dav"
+
+// isDAV checks if a request targets the WebDAV tree.
+func isDAV(request *http.Request) bool {
+	return request.URL.Path == DAVPrefix || strings.HasPrefix(request.URL.Path, DAVPrefix+"/")
+}
+
+// handleDAV serves a WebDAV request. File managers only send credentials after a challenge,
+// so unauthenticated requests are answered with one. The access token can be passed as the
+// basic auth password.
+func (srv *HTTPServer) handleDAV(writer http.ResponseWriter, request *http.Request) {
+	// why: browsers resend cached basic credentials on their own, so they are accepted only here
+	// and not by the query handlers, which would otherwise be open to cross-site requests
+	token := srv.getAuthToken(request)
+	if _, password, ok := request.BasicAuth(); ok {
+		token = password
+	}
+
+	clientID, err := srv.AuthenticateToken(token)
+	if err != nil {
+		writer.Header().Set("WWW-Authenticate", `Basic realm="astral"`)
+		writer.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	handler := &webdav.Handler{
+		Prefix:     DAVPrefix,
+		FileSystem: &davFS{mod: srv.Module, identity: clientID},
+		LockSystem: srv.davLocks,
+		Logger: func(request *http.Request, err error) {
+			if err != nil {
+				srv.log.Logv(2, "dav: %v %v: %v", request.Method, request.URL.Path, err)
+			}
+		},
+	}
+
+	if request.Method == http.MethodPut {
+		body := &davBody{ReadCloser: request.Body}
+		request = request.WithContext(context.WithValue(request.Context(), davBodyKey{}, body))
+		request.Body = body
+	}
+
+	handler.ServeHTTP(writer, request)
+}
+
+type davBodyKey struct{}
+
+// davBody is the body of an upload. The WebDAV handler closes uploaded files even if the body
+// could not be read in full, so uploads check it before committing their objects.
+type davBody struct {
+	io.ReadCloser
+	complete bool
+}
+
+func (b *davBody) Read(p []byte) (n int, err error) {
+	n, err = b.ReadCloser.Read(p)
+	if err == io.EOF {
+		b.complete = true
+	}
+	return
+}
@@ -0,0 +1,276 @@
+package apphost
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/astral/log"
+	"github.com/cryptopunkscc/astrald/mod/auth"
+	"github.com/cryptopunkscc/astrald/mod/objects"
+	"github.com/cryptopunkscc/astrald/mod/objects/mem"
+	"github.com/cryptopunkscc/astrald/mod/tree"
+	"golang.org/x/net/webdav"
+)
+
+type testDAVNode struct {
+	astral.Router
+	identity *astral.Identity
+}
+
+func (n *testDAVNode) Identity() *astral.Identity { return n.identity }
+
+type testDAVObjects struct {
+	objects.Module
+	repo objects.Repository
+}
+
+func (o *testDAVObjects) Repositories() map[string]objects.Repository {
+	return map[string]objects.Repository{"mem": o.repo}
+}
+
+func (o *testDAVObjects) GetRepository(name string) objects.Repository {
+	if name == "mem" {
+		return o.repo
+	}
+	return nil
+}
+
+func (o *testDAVObjects) ReadDefault() objects.Repository  { return o.repo }
+func (o *testDAVObjects) WriteDefault() objects.Repository { return o.repo }
+
+func (o *testDAVObjects) ContentType(*astral.Context, *astral.ObjectID) (string, error) {
+	return "text/plain", nil
+}
+
+// testDAVAuth allows creating objects and reading all objects except the hidden one.
+type testDAVAuth struct {
+	auth.Module
+	hidden *astral.ObjectID
+}
+
+func (a *testDAVAuth) Authorize(_ *astral.Context, action auth.ActionObject) bool {
+	if read, ok := action.(*objects.ReadObjectAction); ok {
+		return a.hidden == nil || !read.ObjectID.IsEqual(a.hidden)
+	}
+	return true
+}
+
+// testTreeNode is an in-memory tree node.
+type testTreeNode struct {
+	value astral.Object
+	subs  map[string]tree.Node
+}
+
+func (n *testTreeNode) Get(*astral.Context, bool) (<-chan astral.Object, error) {
+	ch := make(chan astral.Object, 1)
+	ch <- cmp.Or[astral.Object](n.value, &astral.Nil{})
+	close(ch)
+	return ch, nil
+}
+
+func (n *testTreeNode) Set(_ *astral.Context, object astral.Object) error {
+	n.value = object
+	return nil
+}
+
+func (n *testTreeNode) Delete(*astral.Context) error { return errors.New("not supported") }
+
+func (n *testTreeNode) Sub(*astral.Context) (map[string]tree.Node, error) { return n.subs, nil }
+
+func (n *testTreeNode) Create(_ *astral.Context, name string) (tree.Node, error) {
+	sub := &testTreeNode{subs: map[string]tree.Node{}}
+	n.subs[name] = sub
+	return sub, nil
+}
+
+type testDAVTree struct {
+	tree.Module
+	root *testTreeNode
+}
+
+func (t *testDAVTree) Root() tree.Node { return t.root }
+
+func (t *testDAVTree) Set(ctx *astral.Context, path string, object astral.Object) error {
+	node, err := tree.Query(ctx, t.root, path, true)
+	if err != nil {
+		return err
+	}
+	return node.Set(ctx, object)
+}
+
+func davRequest(t *testing.T, ts *httptest.Server, method, path string, body string, header http.Header) (*http.Response, string) {
+	t.Helper()
+
+	req, err := http.NewRequest(method, ts.URL+DAVPrefix+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	data, _ := io.ReadAll(res.Body)
+	return res, string(data)
+}
+
+// Uploaded objects are listed under their IDs and can be read in ranges; unreadable ones are hidden.
+func TestDAV_RepoPutGet(t *testing.T) {
+	repo := mem.New("", 0)
+	authz := &testDAVAuth{}
+	mod := &Module{
+		Deps: Deps{Auth: authz, Objects: &testDAVObjects{repo: repo}},
+		node: &testDAVNode{identity: astral.GenerateIdentity()},
+		log:  log.New(nil),
+	}
+
+	ts := httptest.NewServer(&webdav.Handler{
+		Prefix:     DAVPrefix,
+		FileSystem: &davFS{mod: mod, identity: astral.GenerateIdentity()},
+		LockSystem: webdav.NewMemLS(),
+	})
+	defer ts.Close()
+
+	res, _ := davRequest(t, ts, http.MethodPut, "/repos/mem/hello.txt", "hello world", nil)
+	if res.StatusCode != http.StatusCreated {
+		t.Fatalf("put: got status %v", res.StatusCode)
+	}
+
+	objectID, err := astral.Resolve(strings.NewReader("hello world"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, body := davRequest(t, ts, "PROPFIND", "/repos/mem/", "", http.Header{"Depth": {"1"}})
+	if res.StatusCode != http.StatusMultiStatus || !strings.Contains(body, objectID.String()) {
+		t.Fatalf("propfind: got status %v, body %s", res.StatusCode, body)
+	}
+
+	res, body = davRequest(t, ts, http.MethodGet, "/repos/mem/"+objectID.String(), "", http.Header{"Range": {"bytes=6-"}})
+	if res.StatusCode != http.StatusPartialContent || body != "world" {
+		t.Fatalf("get: got status %v, body %q", res.StatusCode, body)
+	}
+
+	authz.hidden = objectID
+
+	res, _ = davRequest(t, ts, http.MethodGet, "/repos/mem/"+objectID.String(), "", nil)
+	if res.StatusCode != http.StatusNotFound {
+		t.Fatalf("get hidden: got status %v", res.StatusCode)
+	}
+
+	res, body = davRequest(t, ts, "PROPFIND", "/repos/mem/", "", http.Header{"Depth": {"1"}})
+	if strings.Contains(body, objectID.String()) {
+		t.Fatalf("propfind lists a hidden object: %s", body)
+	}
+}
+
+// An upload whose request body ended early is discarded instead of being stored truncated.
+func TestDAV_IncompleteUpload(t *testing.T) {
+	repo := mem.New("", 0)
+	mod := &Module{
+		Deps: Deps{Auth: &testDAVAuth{}, Objects: &testDAVObjects{repo: repo}},
+		node: &testDAVNode{identity: astral.GenerateIdentity()},
+		log:  log.New(nil),
+	}
+	fs := &davFS{mod: mod, identity: astral.GenerateIdentity()}
+
+	body := &davBody{ReadCloser: io.NopCloser(iotest.ErrReader(io.ErrUnexpectedEOF))}
+	ctx := context.WithValue(context.Background(), davBodyKey{}, body)
+
+	f, err := fs.OpenFile(ctx, "/repos/mem/hello.txt", os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.Copy(f, io.MultiReader(strings.NewReader("hello"), body)); err == nil {
+		t.Fatal("copy succeeded")
+	}
+	if err := f.Close(); !errors.Is(err, errUploadIncomplete) {
+		t.Fatalf("close: got %v, want %v", err, errUploadIncomplete)
+	}
+
+	scan, err := repo.Scan(astral.NewContext(nil), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for objectID := range scan {
+		t.Fatalf("truncated object %v stored", objectID)
+	}
+}
+
+// Only the shared subtree is visible, nodes holding values other than objects are hidden and
+// uploads land inside the subtree.
+func TestDAV_TreeSubtree(t *testing.T) {
+	ctx := astral.NewContext(nil)
+	repo := mem.New("", 0)
+	tr := &testDAVTree{root: &testTreeNode{subs: map[string]tree.Node{}}}
+	mod := &Module{
+		Deps:         Deps{Auth: &testDAVAuth{}, Objects: &testDAVObjects{repo: repo}},
+		OptionalDeps: OptionalDeps{Tree: tr},
+		node:         &testDAVNode{identity: astral.GenerateIdentity()},
+		log:          log.New(nil),
+	}
+
+	w, err := repo.Create(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte("readme"))
+	readmeID, err := w.Commit()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tr.Set(ctx, "/mod/apphost/config", astral.NewString8("secret"))
+	tr.Set(ctx, DAVTreeRoot+"/docs/readme", readmeID)
+	tr.Set(ctx, DAVTreeRoot+"/setting", astral.NewString8("secret"))
+
+	ts := httptest.NewServer(&webdav.Handler{
+		Prefix:     DAVPrefix,
+		FileSystem: &davFS{mod: mod, identity: astral.GenerateIdentity()},
+		LockSystem: webdav.NewMemLS(),
+	})
+	defer ts.Close()
+
+	res, body := davRequest(t, ts, "PROPFIND", "/tree/", "", http.Header{"Depth": {"1"}})
+	if res.StatusCode != http.StatusMultiStatus || !strings.Contains(body, "docs") {
+		t.Fatalf("propfind: got status %v, body %s", res.StatusCode, body)
+	}
+	if strings.Contains(body, "setting") || strings.Contains(body, "apphost") {
+		t.Fatalf("propfind lists a node outside of the shared subtree or a value: %s", body)
+	}
+
+	res, _ = davRequest(t, ts, http.MethodGet, "/tree/setting", "", nil)
+	if res.StatusCode != http.StatusNotFound {
+		t.Fatalf("get value node: got status %v", res.StatusCode)
+	}
+
+	res, body = davRequest(t, ts, http.MethodGet, "/tree/docs/readme", "", nil)
+	if res.StatusCode != http.StatusOK || body != "readme" {
+		t.Fatalf("get object node: got status %v, body %q", res.StatusCode, body)
+	}
+
+	res, _ = davRequest(t, ts, http.MethodPut, "/tree/notes.txt", "notes", nil)
+	if res.StatusCode != http.StatusCreated {
+		t.Fatalf("put: got status %v", res.StatusCode)
+	}
+	if _, err := tree.Query(ctx, tr.root, DAVTreeRoot+"/notes.txt", false); err != nil {
+		t.Fatalf("upload not stored in the shared subtree: %v", err)
+	}
+	if _, found := tr.root.subs["notes.txt"]; found {
+		t.Fatal("upload stored at the root of the tree")
+	}
+}
@@ -49,6 +49,12 @@ func (srv *HTTPObjectHandler) ServeHTTP(writer http.ResponseWriter, request *htt
 		return
 	}
 
+	// why: the file server can only sniff seekable content itself, so set the type up front
+	if mime, err := srv.Objects.ContentType(ctx, objectID); err == nil {
+		writer.Header().Set("Content-Type", mime)
+		writer.Header().Set("X-Content-Type-Options", "nosniff")
+	}
+
 	// pass the request to the file server
 	writer.Header().Set("Content-Disposition", "inline; filename="+objectID.String())
 	srv.fileServer.ServeHTTP(writer, request)
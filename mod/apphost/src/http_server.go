@@ -10,6 +10,7 @@ import (
 	"github.com/cryptopunkscc/astrald/astral"
 	"github.com/cryptopunkscc/astrald/lib/ipc"
 	"github.com/cryptopunkscc/astrald/mod/objects/fs"
+	"golang.org/x/net/webdav"
 )
 
 // HTTPAuthTokenHeader is the request header carrying the bearer token for HTTP clients.
@@ -19,17 +20,19 @@ const HTTPAuthTokenHeader = "X-Astral-Auth-Token"
 const HTTPTargetHeader = "X-Astral-Target"
 
 // HTTPServer is the HTTP gateway for apphost, handling REST queries, object downloads,
-// and WebSocket guest connections on a single listener.
+// WebDAV and WebSocket guest connections on a single listener.
 type HTTPServer struct {
 	*Module
 	fileSystem *fs.FS
 	fileServer http.Handler
+	davLocks   webdav.LockSystem
 	ctx        *astral.Context
 }
 
 func NewHTTPServer(mod *Module) *HTTPServer {
 	srv := &HTTPServer{
-		Module: mod,
+		Module:   mod,
+		davLocks: webdav.NewMemLS(),
 	}
 
 	srv.fileSystem = fs.NewFS(srv.Objects.ReadDefault())
@@ -92,6 +95,12 @@ func (srv *HTTPServer) ServeHTTP(writer http.ResponseWriter, request *http.Reque
 		return
 	}
 
+	// WebDAV uses OPTIONS for discovery and answers auth failures with a challenge
+	if isDAV(request) {
+		srv.handleDAV(writer, request)
+		return
+	}
+
 	// add CORS headers
 	writer.Header().Set("Access-Control-Allow-Origin", "*")
 	writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
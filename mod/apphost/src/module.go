@@ -13,6 +13,7 @@ import (
 	"github.com/cryptopunkscc/astrald/mod/crypto"
 	"github.com/cryptopunkscc/astrald/mod/dir"
 	"github.com/cryptopunkscc/astrald/mod/objects"
+	"github.com/cryptopunkscc/astrald/mod/tree"
 	"github.com/cryptopunkscc/astrald/mod/user"
 	"github.com/cryptopunkscc/astrald/sig"
 )
@@ -27,6 +28,7 @@ type Deps struct {
 }
 
 type OptionalDeps struct {
+	Tree tree.Module
 	User user.Module
 }
 
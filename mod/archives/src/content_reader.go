@@ -1,21 +1,21 @@
 package archives
 
 import (
-	"archive/zip"
 	"github.com/cryptopunkscc/astrald/astral"
 	"github.com/cryptopunkscc/astrald/streams"
 	"io"
-	"io/fs"
 )
 
 var _ io.ReadSeeker = &contentReader{}
 
+// contentReader reads a file stored in an archive. openAt opens the file at a position; if
+// direct is false, it is only called for position 0 and seeking ahead skips the data.
 type contentReader struct {
-	zip      *zip.Reader
-	path     string
 	objectID *astral.ObjectID
+	openAt   func(pos int64) (io.ReadCloser, error)
+	direct   bool
 
-	file fs.File
+	file io.ReadCloser
 	pos  int64
 }
 
@@ -41,12 +41,17 @@ func (r *contentReader) Seek(offset int64, whence int) (int64, error) {
 		return r.pos, nil
 	}
 
+	if r.direct {
+		err := r.open(target)
+		return r.pos, err
+	}
+
 	if target > r.pos {
 		err := streams.Skip(r, uint64(target-r.pos))
 		return r.pos, err
 	}
 
-	var err = r.open()
+	var err = r.open(0)
 	if err != nil {
 		return 0, err
 	}
@@ -63,14 +68,17 @@ func (r *contentReader) Close() (err error) {
 	return
 }
 
-func (r *contentReader) open() (err error) {
+func (r *contentReader) open(pos int64) (err error) {
 	if r.file != nil {
 		r.file.Close()
 		r.file = nil
 		r.pos = 0
 	}
 
-	r.file, err = r.zip.Open(r.path)
+	r.file, err = r.openAt(pos)
+	if err == nil {
+		r.pos = pos
+	}
 
 	return
 }
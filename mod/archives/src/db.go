@@ -24,6 +24,7 @@ type dbEntry struct {
 	ObjectID *astral.ObjectID `gorm:"index"`
 	Comment  string
 	Modified time.Time
+	Offset   int64 // offset of the data in the uncompressed stream of a tar archive
 }
 
 func (dbEntry) TableName() string { return archives.DBPrefix + "entries" }
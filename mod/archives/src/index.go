@@ -11,7 +11,8 @@ import (
 type entryFunc func(*archives.Entry)
 
 // Index scans and persists the archive for objectID, returning a cached result
-// if one already exists. Emits EventArchiveIndexed on success.
+// if one already exists. Zip, tar, tar.gz and tar.bz2 archives are supported.
+// Emits EventArchiveIndexed on success.
 // note: serialized under mod.mu to prevent concurrent scans of the same object.
 func (mod *Module) Index(ctx context.Context, objectID *astral.ObjectID) (archive *archives.Archive, err error) {
 	mod.mu.Lock()
@@ -21,22 +22,35 @@ func (mod *Module) Index(ctx context.Context, objectID *astral.ObjectID) (archiv
 		return cached, nil
 	}
 
-	mod.log.Logv(1, "indexing zip %v", objectID)
-	archive, err = mod.scan(ctx, objectID, func(entry *archives.Entry) {
+	format, err := mod.detectFormat(objectID)
+	if err != nil {
+		return nil, err
+	}
+
+	postScan := func(entry *archives.Entry) {
 		mod.log.Infov(1, "scanned %v (%v)", entry.ObjectID, entry.Path)
-	})
+	}
+
+	var offsets map[string]int64
+
+	mod.log.Logv(1, "indexing %v %v", format, objectID)
+	if format == formatZip {
+		archive, err = mod.scanZip(ctx, objectID, postScan)
+	} else {
+		archive, offsets, err = mod.scanTar(ctx, objectID, format, postScan)
+	}
 	if err != nil {
 		return
 	}
 
-	err = mod.setCache(objectID, archive)
+	err = mod.setCache(objectID, archive, offsets)
 
 	mod.Objects.Receive(&archives.EventArchiveIndexed{ObjectID: objectID, Archive: archive}, nil)
 
 	return
 }
 
-func (mod *Module) scan(ctx context.Context, objectID *astral.ObjectID, postScan entryFunc) (archive *archives.Archive, err error) {
+func (mod *Module) scanZip(ctx context.Context, objectID *astral.ObjectID, postScan entryFunc) (archive *archives.Archive, err error) {
 	reader, err := mod.openZip(objectID)
 	if err != nil {
 		return nil, fmt.Errorf("error reading zip file: %w", err)
@@ -44,7 +58,7 @@ func (mod *Module) scan(ctx context.Context, objectID *astral.ObjectID, postScan
 
 	archive = &archives.Archive{
 		Comment: reader.Comment,
-		Format:  "zip",
+		Format:  formatZip,
 	}
 
 	for _, file := range reader.File {
@@ -145,7 +159,9 @@ func (mod *Module) clearCache(objectID *astral.ObjectID) (err error) {
 		Error
 }
 
-func (mod *Module) setCache(objectID *astral.ObjectID, archive *archives.Archive) error {
+// setCache stores an archive in the index. Offsets are the positions of the entries of a tar
+// archive in its uncompressed stream, by path.
+func (mod *Module) setCache(objectID *astral.ObjectID, archive *archives.Archive, offsets map[string]int64) error {
 	mod.clearCache(objectID)
 
 	row := dbArchive{
@@ -160,6 +176,7 @@ func (mod *Module) setCache(objectID *astral.ObjectID, archive *archives.Archive
 			Path:     entry.Path,
 			Comment:  entry.Comment,
 			Modified: entry.Modified,
+			Offset:   offsets[entry.Path],
 		})
 	}
 
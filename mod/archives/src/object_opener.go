@@ -14,7 +14,7 @@ func (mod *Module) OpenObject(ctx *astral.Context, objectID *astral.ObjectID) (i
 		return nil, astral.ErrZoneExcluded
 	}
 
-	if objects.IsOffsetLimitValid(objectID, 0, 0) {
+	if !objects.IsOffsetLimitValid(objectID, 0, 0) {
 		return nil, objects.ErrOutOfBounds
 	}
 
@@ -29,7 +29,10 @@ func (mod *Module) OpenObject(ctx *astral.Context, objectID *astral.ObjectID) (i
 	}
 
 	for _, row := range rows {
-		r, err := mod.open(row.Parent.ObjectID, row.Path, row.ObjectID)
+		if row.Parent == nil {
+			continue
+		}
+		r, err := mod.open(row.Parent, &row)
 		if err == nil {
 			mod.log.Logv(2, "opened %v from %v/%v", objectID, row.Parent.ObjectID, row.Path)
 			return r, nil
@@ -39,19 +42,30 @@ func (mod *Module) OpenObject(ctx *astral.Context, objectID *astral.ObjectID) (i
 	return nil, objects.ErrNotFound
 }
 
-func (mod *Module) open(zipID *astral.ObjectID, path string, fileID *astral.ObjectID) (io.ReadCloser, error) {
-	zipFile, err := mod.openZip(zipID)
-	if err != nil {
-		return nil, objects.ErrNotFound
-	}
+// open opens an entry of an archive for reading. Files in zip and compressed tar archives are
+// read from the start of the file; files in plain tar archives are read by offset.
+func (mod *Module) open(archive *dbArchive, entry *dbEntry) (io.ReadCloser, error) {
+	var r = &contentReader{objectID: entry.ObjectID}
+
+	switch archive.Format {
+	case formatZip, "":
+		zipFile, err := mod.openZip(archive.ObjectID)
+		if err != nil {
+			return nil, objects.ErrNotFound
+		}
+
+		r.openAt = func(int64) (io.ReadCloser, error) {
+			return zipFile.Open(entry.Path)
+		}
 
-	var r = &contentReader{
-		zip:      zipFile,
-		path:     path,
-		objectID: fileID,
+	default:
+		r.direct = archive.Format == formatTar
+		r.openAt = func(pos int64) (io.ReadCloser, error) {
+			return mod.openTarEntry(archive.ObjectID, archive.Format, entry.Offset, entry.ObjectID, pos)
+		}
 	}
 
-	err = r.open()
+	err := r.open(0)
 
 	return r, err
 }
@@ -27,3 +27,10 @@ func (r *readerAt) ReadAt(p []byte, off int64) (n int, err error) {
 
 	return f.Read(p)
 }
+
+// readObject opens an object for sequential reading from offset.
+func (mod *Module) readObject(objectID *astral.ObjectID, offset int64) (objects.Reader, error) {
+	ctx := astral.NewContext(nil).WithIdentity(mod.node.Identity())
+
+	return mod.Objects.ReadDefault().Read(ctx, objectID, offset, 0)
+}
@@ -0,0 +1,27 @@
+package archives
+
+import (
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/mod/objects"
+)
+
+var _ objects.ReferenceExtractor = &Module{}
+
+// ExtractReferences returns the entries of an indexed archive. The references of archives that
+// aren't indexed yet are unknown; other objects reference nothing.
+func (mod *Module) ExtractReferences(ctx *astral.Context, objectID *astral.ObjectID) ([]*astral.ObjectID, error) {
+	archive := mod.getCache(objectID)
+	if archive == nil {
+		if _, err := mod.detectFormat(objectID); err == nil {
+			return nil, objects.ErrReferencesUnknown
+		}
+		return nil, nil
+	}
+
+	var refs []*astral.ObjectID
+	for _, e := range archive.Entries {
+		refs = append(refs, e.ObjectID)
+	}
+
+	return refs, nil
+}
@@ -0,0 +1,209 @@
+package archives
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/mod/archives"
+	"github.com/cryptopunkscc/astrald/mod/objects"
+	"github.com/cryptopunkscc/astrald/streams"
+)
+
+// archive formats
+const (
+	formatZip    = "zip"
+	formatTar    = "tar"
+	formatTarGz  = "tar.gz"
+	formatTarBz2 = "tar.bz2"
+)
+
+const tarMagicOffset = 257 // offset of the ustar magic in a tar header
+
+var errUnknownFormat = errors.New("unknown archive format")
+
+// detectFormat returns the format of an archive from the magic bytes of its data. Compressed
+// streams count as tar archives only if they decompress to one.
+func (mod *Module) detectFormat(objectID *astral.ObjectID) (string, error) {
+	r, err := mod.readObject(objectID, 0)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+
+	head := bufio.NewReader(r)
+	magic, _ := head.Peek(tarMagicOffset + 5)
+
+	var compressed io.Reader
+	var format string
+
+	switch {
+	case bytes.HasPrefix(magic, []byte("PK")):
+		return formatZip, nil
+
+	case isTar(magic):
+		return formatTar, nil
+
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		format = formatTarGz
+		if compressed, err = gzip.NewReader(head); err != nil {
+			return "", errUnknownFormat
+		}
+
+	case bytes.HasPrefix(magic, []byte("BZh")):
+		format = formatTarBz2
+		compressed = bzip2.NewReader(head)
+
+	default:
+		// zip archives can be prefixed with other data, like self-extracting archives
+		return formatZip, nil
+	}
+
+	magic, _ = bufio.NewReader(compressed).Peek(tarMagicOffset + 5)
+	if !isTar(magic) {
+		return "", errUnknownFormat
+	}
+
+	return format, nil
+}
+
+// isTar checks if a header block starts with a ustar (POSIX or GNU) header.
+func isTar(block []byte) bool {
+	return len(block) >= tarMagicOffset+5 && string(block[tarMagicOffset:tarMagicOffset+5]) == "ustar"
+}
+
+// openTar returns the uncompressed tar stream of an archive, starting at offset.
+func (mod *Module) openTar(objectID *astral.ObjectID, format string, offset int64) (io.ReadCloser, error) {
+	if format == formatTar {
+		return mod.readObject(objectID, offset)
+	}
+
+	r, err := mod.readObject(objectID, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var tr io.Reader
+	switch format {
+	case formatTarGz:
+		tr, err = gzip.NewReader(r)
+	case formatTarBz2:
+		tr = bzip2.NewReader(r)
+	default:
+		err = errUnknownFormat
+	}
+	if err != nil {
+		r.Close()
+		return nil, err
+	}
+
+	// compressed streams can't seek, so the data before offset is decompressed and dropped
+	if err = streams.Skip(tr, uint64(offset)); err != nil {
+		r.Close()
+		return nil, err
+	}
+
+	return &readCloser{Reader: tr, Closer: r}, nil
+}
+
+// scanTar indexes the regular files of a tar archive. The returned offsets are the positions
+// of the files in the uncompressed stream. Files stored more than once are indexed at their
+// last copy, like tar extracts them.
+func (mod *Module) scanTar(ctx context.Context, objectID *astral.ObjectID, format string, postScan entryFunc) (archive *archives.Archive, offsets map[string]int64, err error) {
+	r, err := mod.openTar(objectID, format, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer r.Close()
+
+	archive = &archives.Archive{Format: format}
+	offsets = map[string]int64{}
+	index := map[string]int{}
+
+	counter := streams.NewReadCounter(r)
+	reader := tar.NewReader(counter)
+
+	for {
+		hdr, err := reader.Next()
+		switch {
+		case errors.Is(err, io.EOF):
+			return archive, offsets, nil
+		case err != nil:
+			return archive, offsets, err
+		}
+
+		// sparse files are not stored as a single run of bytes, so they can't be read by offset
+		if !hdr.FileInfo().Mode().IsRegular() || isSparse(hdr) {
+			continue
+		}
+
+		offset := counter.Total()
+
+		fileID, err := astral.Resolve(reader)
+		if err != nil {
+			return archive, offsets, err
+		}
+
+		entry := &archives.Entry{
+			ObjectID: fileID,
+			Path:     hdr.Name,
+			Modified: hdr.ModTime,
+		}
+
+		if i, found := index[entry.Path]; found {
+			archive.Entries[i] = entry
+		} else {
+			index[entry.Path] = len(archive.Entries)
+			archive.Entries = append(archive.Entries, entry)
+		}
+		offsets[entry.Path] = offset
+
+		if postScan != nil {
+			postScan(entry)
+		}
+
+		select {
+		case <-ctx.Done():
+			return archive, offsets, ctx.Err()
+		default:
+		}
+	}
+}
+
+func isSparse(hdr *tar.Header) bool {
+	if hdr.Typeflag == tar.TypeGNUSparse {
+		return true
+	}
+	for key := range hdr.PAXRecords {
+		if strings.HasPrefix(key, "GNU.sparse.") {
+			return true
+		}
+	}
+	return false
+}
+
+// openTarEntry opens a file stored in a tar archive at offset, starting at pos within the file.
+func (mod *Module) openTarEntry(tarID *astral.ObjectID, format string, offset int64, fileID *astral.ObjectID, pos int64) (io.ReadCloser, error) {
+	if pos >= int64(fileID.Size) {
+		return io.NopCloser(bytes.NewReader(nil)), nil
+	}
+
+	r, err := mod.openTar(tarID, format, offset+pos)
+	if err != nil {
+		return nil, objects.ErrNotFound
+	}
+
+	return &streams.LimitedReader{ReadCloser: r, Limit: fileID.Size - uint64(pos)}, nil
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,140 @@
+package archives
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/astral/log"
+	"github.com/cryptopunkscc/astrald/mod/objects"
+	"github.com/cryptopunkscc/astrald/mod/objects/mem"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+type testNode struct {
+	astral.Router
+	identity *astral.Identity
+}
+
+func (n *testNode) Identity() *astral.Identity { return n.identity }
+
+type testObjects struct {
+	objects.Module
+	repo objects.Repository
+}
+
+func (o *testObjects) ReadDefault() objects.Repository { return o.repo }
+
+func (o *testObjects) Receive(astral.Object, *astral.Identity) error { return nil }
+
+func testTar(t *testing.T, files map[string]string, modTime time.Time) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w := tar.NewWriter(&buf)
+	w.WriteHeader(&tar.Header{Typeflag: tar.TypeDir, Name: "dir/", Mode: 0755, ModTime: modTime})
+	for name, data := range files {
+		err := w.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(data)), ModTime: modTime})
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(data))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// Files of tar and tar.gz archives are indexed with their times and read back by ObjectID,
+// including after seeking back and forth.
+func TestIndex_Tar(t *testing.T) {
+	gdb, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repo := mem.New("", 0)
+	mod := &Module{
+		Deps: Deps{Objects: &testObjects{repo: repo}},
+		node: &testNode{identity: astral.GenerateIdentity()},
+		log:  log.New(nil),
+		db:   gdb,
+	}
+	if err := gdb.AutoMigrate(&dbArchive{}, &dbEntry{}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := astral.NewContext(nil).WithZone(astral.ZoneVirtual)
+	modTime := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+
+	store := func(data []byte) *astral.ObjectID {
+		w, _ := repo.Create(ctx, nil)
+		w.Write(data)
+		id, err := w.Commit()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return id
+	}
+
+	files := map[string]string{
+		"dir/a.txt": strings.Repeat("0123456789", 1000),
+		"b.txt":     "small file",
+	}
+	plain := testTar(t, files, modTime)
+
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write(testTar(t, map[string]string{"c.txt": files["dir/a.txt"] + "c"}, modTime))
+	zw.Close()
+	files["c.txt"] = files["dir/a.txt"] + "c"
+
+	for format, data := range map[string][]byte{formatTar: plain, formatTarGz: gz.Bytes()} {
+		archive, err := mod.Index(ctx, store(data))
+		if err != nil {
+			t.Fatalf("%v: %v", format, err)
+		}
+		if archive.Format != format {
+			t.Fatalf("got format %v, want %v", archive.Format, format)
+		}
+
+		for _, entry := range archive.Entries {
+			want, found := files[entry.Path]
+			if !found || !entry.Modified.Equal(modTime) {
+				t.Fatalf("%v: unexpected entry %v (%v)", format, entry.Path, entry.Modified)
+			}
+
+			r, err := mod.OpenObject(ctx, entry.ObjectID)
+			if err != nil {
+				t.Fatalf("%v: open %v: %v", format, entry.Path, err)
+			}
+
+			got, _ := io.ReadAll(r)
+			if string(got) != want {
+				t.Fatalf("%v: %v: data differs", format, entry.Path)
+			}
+
+			seeker := r.(io.ReadSeeker)
+			for _, pos := range []int64{5, 2, 9} {
+				if int(pos) >= len(want) {
+					continue
+				}
+				if _, err := seeker.Seek(pos, io.SeekStart); err != nil {
+					t.Fatal(err)
+				}
+				b := make([]byte, 1)
+				if _, err := io.ReadFull(seeker, b); err != nil || b[0] != want[pos] {
+					t.Fatalf("%v: %v: read at %v: %q, %v", format, entry.Path, pos, b, err)
+				}
+			}
+			r.Close()
+		}
+	}
+}
@@ -4,3 +4,4 @@ import "errors"
 
 var ErrNotAbsolute = errors.New("path not absolute")
 var ErrInvalidPath = errors.New("invalid path")
+var ErrFileExists = errors.New("file exists")
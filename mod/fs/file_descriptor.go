@@ -0,0 +1,46 @@
+package fs
+
+import (
+	"io"
+
+	"github.com/cryptopunkscc/astrald/astral"
+)
+
+var _ astral.Object = &FileDescriptor{}
+
+// FileDescriptor describes a file holding an object in a watched directory. Ext is the
+// lowercase extension without the dot.
+type FileDescriptor struct {
+	Path    astral.String16
+	Name    astral.String16
+	Ext     astral.String8
+	ModTime astral.Time
+}
+
+func (FileDescriptor) ObjectType() string {
+	return "mod.fs.file_descriptor"
+}
+
+func (d FileDescriptor) WriteTo(w io.Writer) (n int64, err error) {
+	return astral.Objectify(&d).WriteTo(w)
+}
+
+func (d *FileDescriptor) ReadFrom(r io.Reader) (n int64, err error) {
+	return astral.Objectify(d).ReadFrom(r)
+}
+
+func (d FileDescriptor) MarshalJSON() ([]byte, error) {
+	return astral.Objectify(&d).MarshalJSON()
+}
+
+func (d *FileDescriptor) UnmarshalJSON(bytes []byte) error {
+	return astral.Objectify(d).UnmarshalJSON(bytes)
+}
+
+func (d FileDescriptor) String() string {
+	return string(d.Path)
+}
+
+func init() {
+	_ = astral.Add(&FileDescriptor{})
+}
@@ -4,6 +4,9 @@ const (
 	ModuleName = "fs"
 	// DBPrefix is the key namespace prefix used when storing fs module data in the database.
 	DBPrefix = "fs__"
+
+	MethodExport       = "fs.export"
+	MethodSyncManifest = "fs.sync_manifest"
 )
 
 type Module interface {
@@ -10,6 +10,13 @@ type RepoConfig struct {
 	Label    string
 	Path     string
 	Writable bool
+
+	// Sync is the name of the folder the directory is kept in sync with. Nodes sync the
+	// directories configured with the same folder name.
+	Sync string
+
+	// Peers are the nodes to sync the folder with. Defaults to the other nodes of the swarm.
+	Peers []string
 }
 
 var defaultConfig = Config{}
@@ -232,13 +232,17 @@ func (db *DB) EachInvalidatedPath(fn func(string) error) error {
 	}
 }
 
-func (db *DB) SearchByPath(query string) (rows []*dbLocalFile, err error) {
-	err = db.
-		Where("LOWER(path) like ?", "%"+query+"%").
+// SearchFiles returns the active rows matching all conditions.
+func (db *DB) SearchFiles(conds []searchCond) (rows []*dbLocalFile, err error) {
+	tx := db.
 		Where("updated_at != 0").
-		Where("deleted_at IS NULL").
-		Find(&rows).
-		Error
+		Where("deleted_at IS NULL")
+
+	for _, cond := range conds {
+		tx = tx.Where(cond.sql, cond.args...)
+	}
+
+	err = tx.Find(&rows).Error
 
 	return
 }
@@ -1,6 +1,8 @@
 package fs
 
 import (
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/cryptopunkscc/astrald/astral"
@@ -17,3 +19,18 @@ type dbLocalFile struct {
 }
 
 func (dbLocalFile) TableName() string { return fs.DBPrefix + "local_files" }
+
+// describe returns the file descriptor of the row.
+func (row *dbLocalFile) describe() *fs.FileDescriptor {
+	return &fs.FileDescriptor{
+		Path:    astral.String16(row.Path),
+		Name:    astral.String16(filepath.Base(row.Path)),
+		Ext:     astral.String8(fileExt(row.Path)),
+		ModTime: astral.Time(time.Unix(0, row.ModTime)),
+	}
+}
+
+// fileExt returns the lowercase extension of a path without the dot.
+func fileExt(path string) string {
+	return strings.ToLower(strings.TrimPrefix(filepath.Ext(path), "."))
+}
@@ -0,0 +1,83 @@
+package fs
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/mod/fs"
+	"gorm.io/gorm/clause"
+)
+
+// dbSyncEntry is the manifest entry of a file in a synced folder. DiskID is the content last
+// seen on disk at the path (nil if none). Pending entries are not materialized on disk yet.
+type dbSyncEntry struct {
+	Folder   string           `gorm:"primaryKey"`
+	Path     string           `gorm:"primaryKey"`
+	ObjectID *astral.ObjectID `gorm:"index"`
+	Removed  bool
+	Version  string // json encoded fs.SyncVersion
+	Origin   *astral.Identity
+	ModTime  time.Time
+	DiskID   *astral.ObjectID
+	Pending  bool `gorm:"index"`
+}
+
+func (dbSyncEntry) TableName() string { return fs.DBPrefix + "sync_entries" }
+
+// FindSyncEntry returns the entry of a path or nil if the folder has none.
+func (db *DB) FindSyncEntry(folder string, path string) (row *dbSyncEntry, err error) {
+	var rows []*dbSyncEntry
+	err = db.
+		Where("folder = ? AND path = ?", folder, path).
+		Limit(1).
+		Find(&rows).
+		Error
+	if len(rows) > 0 {
+		row = rows[0]
+	}
+	return
+}
+
+// SyncEntries returns all entries of a folder.
+func (db *DB) SyncEntries(folder string) (rows []*dbSyncEntry, err error) {
+	err = db.Where("folder = ?", folder).Order("path").Find(&rows).Error
+	return
+}
+
+// PendingSyncEntries returns the entries of a folder that are not materialized on disk.
+func (db *DB) PendingSyncEntries(folder string) (rows []*dbSyncEntry, err error) {
+	err = db.Where("folder = ? AND pending = ?", folder, true).Order("path").Find(&rows).Error
+	return
+}
+
+func (db *DB) SaveSyncEntry(row *dbSyncEntry) error {
+	return db.Clauses(clause.OnConflict{UpdateAll: true}).Create(row).Error
+}
+
+func (row *dbSyncEntry) version() (v fs.SyncVersion) {
+	_ = json.Unmarshal([]byte(row.Version), &v)
+	return
+}
+
+func (row *dbSyncEntry) setEntry(entry *fs.SyncEntry) {
+	version, _ := json.Marshal(entry.Version)
+
+	row.ObjectID = entry.ObjectID
+	row.Removed = bool(entry.Removed)
+	row.Version = string(version)
+	row.Origin = entry.Origin
+	row.ModTime = entry.ModTime.Time()
+}
+
+func (row *dbSyncEntry) entry() *fs.SyncEntry {
+	return &fs.SyncEntry{
+		Folder:   astral.String8(row.Folder),
+		Path:     astral.String16(row.Path),
+		ObjectID: row.ObjectID,
+		Removed:  astral.Bool(row.Removed),
+		Version:  row.version(),
+		Origin:   row.Origin,
+		ModTime:  astral.Time(row.ModTime),
+	}
+}
@@ -3,10 +3,12 @@ package fs
 import (
 	"github.com/cryptopunkscc/astrald/astral"
 	"github.com/cryptopunkscc/astrald/core"
+	"github.com/cryptopunkscc/astrald/mod/archives"
 	"github.com/cryptopunkscc/astrald/mod/auth"
 	"github.com/cryptopunkscc/astrald/mod/dir"
 	"github.com/cryptopunkscc/astrald/mod/objects"
 	"github.com/cryptopunkscc/astrald/mod/shell"
+	"github.com/cryptopunkscc/astrald/mod/user"
 )
 
 type Deps struct {
@@ -16,12 +18,21 @@ type Deps struct {
 	Shell   shell.Module
 }
 
+type OptionalDeps struct {
+	Archives archives.Module
+	User     user.Module
+}
+
 func (mod *Module) LoadDependencies(*astral.Context) (err error) {
 	err = core.Inject(mod.node, &mod.Deps)
 	if err != nil {
 		return
 	}
 
+	// optional — archives can be exported only if the archives module is loaded, and folders
+	// are synced with the swarm only if the user module is loaded
+	core.Inject(mod.node, &mod.OptionalDeps)
+
 	// add the default repo
 	mod.addDefaultRepo()
 
@@ -29,9 +40,12 @@ func (mod *Module) LoadDependencies(*astral.Context) (err error) {
 	for name, cfg := range mod.config.Repos {
 		var repo objects.Repository
 
-		if cfg.Writable {
+		switch {
+		case cfg.Sync != "":
+			repo, err = mod.addSyncRepo(cfg)
+		case cfg.Writable:
 			repo = NewRepository(mod, cfg.Label, cfg.Path)
-		} else {
+		default:
 			repo, err = NewWatchRepository(mod, cfg.Path, cfg.Label)
 		}
 		if err != nil {
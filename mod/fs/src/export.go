@@ -0,0 +1,169 @@
+package fs
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/mod/fs"
+)
+
+// exportTempPrefix names the partial file of an export. The name depends only on the object,
+// so an interrupted export resumes where it stopped.
+const exportTempPrefix = tempFilePrefix + "export."
+
+// exportItem is a file to export.
+type exportItem struct {
+	Path     string // slash separated, relative to the target directory
+	ObjectID *astral.ObjectID
+	ModTime  time.Time // zero to keep the time of writing
+}
+
+// archiveItems returns the entries of an archive indexed by the archives module.
+func (mod *Module) archiveItems(ctx *astral.Context, objectID *astral.ObjectID) ([]exportItem, error) {
+	if mod.Archives == nil {
+		return nil, errors.New("archives module not loaded")
+	}
+
+	archive, err := mod.Archives.Index(ctx, objectID)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []exportItem
+	for _, entry := range archive.Entries {
+		items = append(items, exportItem{
+			Path:     entry.Path,
+			ObjectID: entry.ObjectID,
+			ModTime:  entry.Modified,
+		})
+	}
+	return items, nil
+}
+
+// folderItems returns the files in the manifest of a synced folder.
+func (mod *Module) folderItems(folder string) ([]exportItem, error) {
+	rows, err := mod.db.SyncEntries(folder)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("folder %v not found", folder)
+	}
+
+	var items []exportItem
+	for _, row := range rows {
+		if row.Removed {
+			continue
+		}
+		items = append(items, exportItem{
+			Path:     row.Path,
+			ObjectID: row.ObjectID,
+			ModTime:  row.ModTime,
+		})
+	}
+	return items, nil
+}
+
+// export writes an item into dir and returns the path of the file. A file already holding the
+// object is kept; a file with other content is replaced only if overwrite is set.
+func (mod *Module) export(ctx *astral.Context, dir string, item exportItem, overwrite bool) (string, error) {
+	rel := filepath.Clean(filepath.FromSlash(strings.TrimPrefix(item.Path, "/")))
+	if !filepath.IsLocal(rel) || strings.HasPrefix(filepath.Base(rel), tempFilePrefix) {
+		return "", fmt.Errorf("%v: %w", item.Path, fs.ErrInvalidPath)
+	}
+	path := filepath.Join(dir, rel)
+
+	if stat, err := os.Stat(path); err == nil {
+		if !stat.Mode().IsRegular() {
+			return "", fmt.Errorf("%v: %w", path, fs.ErrFileExists)
+		}
+
+		fileID, err := resolveFileID(path)
+		if err != nil {
+			return "", err
+		}
+		if fileID.IsEqual(item.ObjectID) {
+			return path, setModTime(path, item.ModTime)
+		}
+		if !overwrite {
+			return "", fmt.Errorf("%v: %w", path, fs.ErrFileExists)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+
+	tempPath := filepath.Join(filepath.Dir(path), exportTempPrefix+item.ObjectID.String())
+
+	err := mod.exportObject(ctx, tempPath, item.ObjectID)
+	if err != nil {
+		return "", err
+	}
+
+	if err = os.Rename(tempPath, path); err != nil {
+		return "", err
+	}
+
+	return path, setModTime(path, item.ModTime)
+}
+
+// exportObject writes an object to path, continuing a partial file left by a previous export.
+// The data is verified against the object ID; a file that doesn't match is removed.
+func (mod *Module) exportObject(ctx *astral.Context, path string, objectID *astral.ObjectID) error {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	resolver := astral.NewWriteResolver(nil)
+
+	// hash the data written so far
+	done, err := io.Copy(resolver, io.LimitReader(f, int64(objectID.Size)))
+	if err != nil {
+		return err
+	}
+	if err = f.Truncate(done); err != nil {
+		return err
+	}
+
+	if done < int64(objectID.Size) {
+		if done > 0 {
+			mod.log.Logv(1, "export: resuming %v at %v", objectID, done)
+		}
+
+		r, err := mod.Objects.ReadDefault().Read(ctx, objectID, done, 0)
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+
+		_, err = io.Copy(io.MultiWriter(f, resolver), io.LimitReader(r, int64(objectID.Size)-done))
+		if err != nil {
+			return err
+		}
+	}
+
+	if resolved := resolver.Resolve(); !resolved.IsEqual(objectID) {
+		f.Close()
+		os.Remove(path)
+		return fmt.Errorf("exported data resolves to %v", resolved)
+	}
+
+	return f.Sync()
+}
+
+// setModTime sets the modification time of a file unless t is zero.
+func setModTime(path string, t time.Time) error {
+	if t.IsZero() {
+		return nil
+	}
+	return os.Chtimes(path, t, t)
+}
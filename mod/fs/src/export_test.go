@@ -0,0 +1,85 @@
+package fs
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/astral/log"
+	"github.com/cryptopunkscc/astrald/mod/fs"
+	"github.com/cryptopunkscc/astrald/mod/objects"
+	"github.com/cryptopunkscc/astrald/mod/objects/mem"
+)
+
+type testObjects struct {
+	objects.Module
+	repo objects.Repository
+}
+
+func (o *testObjects) ReadDefault() objects.Repository { return o.repo }
+
+// A partial file is resumed and verified; an exported file is kept on the next export.
+func TestExport_Resume(t *testing.T) {
+	ctx := astral.NewContext(nil)
+	repo := mem.New("", 0)
+	mod := &Module{
+		Deps: Deps{Objects: &testObjects{repo: repo}},
+		log:  log.New(nil),
+	}
+
+	data := strings.Repeat("exported data ", 100)
+	w, _ := repo.Create(ctx, nil)
+	w.Write([]byte(data))
+	objectID, err := w.Commit()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "docs"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	partial := filepath.Join(dir, "docs", exportTempPrefix+objectID.String())
+	if err := os.WriteFile(partial, []byte(data[:500]), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	modTime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	item := exportItem{Path: "docs/a.txt", ObjectID: objectID, ModTime: modTime}
+
+	path, err := mod.export(ctx, dir, item, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, _ := os.ReadFile(path)
+	if string(got) != data {
+		t.Fatalf("exported data differs")
+	}
+	if stat, _ := os.Stat(path); !stat.ModTime().Equal(modTime) {
+		t.Fatalf("got mod time %v, want %v", stat.ModTime(), modTime)
+	}
+	if fileExists(partial) {
+		t.Fatal("partial file left behind")
+	}
+
+	if _, err = mod.export(ctx, dir, item, false); err != nil {
+		t.Fatalf("export of an exported file: %v", err)
+	}
+
+	if err := os.WriteFile(path, []byte("changed"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = mod.export(ctx, dir, item, false); !errors.Is(err, fs.ErrFileExists) {
+		t.Fatalf("got %v, want ErrFileExists", err)
+	}
+
+	item.Path = "../escape.txt"
+	if _, err = mod.export(ctx, dir, item, true); !errors.Is(err, fs.ErrInvalidPath) {
+		t.Fatalf("got %v, want ErrInvalidPath", err)
+	}
+}
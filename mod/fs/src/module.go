@@ -8,12 +8,14 @@ import (
 	"github.com/cryptopunkscc/astrald/core/assets"
 	"github.com/cryptopunkscc/astrald/lib/routing"
 	"github.com/cryptopunkscc/astrald/mod/fs"
+	"github.com/cryptopunkscc/astrald/sig"
 )
 
 var _ fs.Module = &Module{}
 
 type Module struct {
 	Deps
+	OptionalDeps
 	config  Config
 	node    astral.Node
 	assets  assets.Assets
@@ -22,6 +24,7 @@ type Module struct {
 	ctx     *astral.Context
 	indexer *Indexer
 	router  routing.OpRouter
+	syncs   sig.Map[string, *SyncRepository] // by folder
 }
 
 func (mod *Module) Run(ctx *astral.Context) error {
@@ -35,6 +38,10 @@ func (mod *Module) Run(ctx *astral.Context) error {
 		}
 	}()
 
+	for _, repo := range mod.syncs.Values() {
+		go repo.run(ctx)
+	}
+
 	<-ctx.Done()
 	return nil
 }
@@ -8,7 +8,8 @@ import (
 
 var _ objects.Describer = &Module{}
 
-// DescribeObject returns file-location descriptors for objectID; restricted to ZoneDevice callers.
+// DescribeObject returns a file-location and a file descriptor of every indexed file holding
+// objectID; restricted to ZoneDevice callers.
 func (mod *Module) DescribeObject(ctx *astral.Context, objectID *astral.ObjectID) (<-chan *objects.Descriptor, error) {
 	if !ctx.Zone().Is(astral.ZoneDevice) {
 		return nil, astral.ErrZoneExcluded
@@ -19,7 +20,7 @@ func (mod *Module) DescribeObject(ctx *astral.Context, objectID *astral.ObjectID
 		return nil, err
 	}
 
-	var results = make(chan *objects.Descriptor, len(rows))
+	var results = make(chan *objects.Descriptor, 2*len(rows))
 	defer close(results)
 
 	for _, row := range rows {
@@ -31,6 +32,11 @@ func (mod *Module) DescribeObject(ctx *astral.Context, objectID *astral.ObjectID
 				Path:   astral.String16(row.Path),
 			},
 		}
+		results <- &objects.Descriptor{
+			SourceID: mod.node.Identity(),
+			ObjectID: objectID,
+			Data:     row.describe(),
+		}
 	}
 
 	return results, nil
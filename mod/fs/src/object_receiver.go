@@ -0,0 +1,29 @@
+package fs
+
+import (
+	"github.com/cryptopunkscc/astrald/mod/fs"
+	"github.com/cryptopunkscc/astrald/mod/objects"
+)
+
+var _ objects.Receiver = &Module{}
+
+// ReceiveObject merges sync entries pushed by the peers of a folder. Entries are kept in the
+// manifest, so they are accepted without saving.
+func (mod *Module) ReceiveObject(drop objects.Drop) error {
+	switch object := drop.Object().(type) {
+	case *fs.SyncEntry:
+		repo, found := mod.syncs.Get(string(object.Folder))
+		if !found || !repo.isPeer(drop.SenderID()) {
+			return nil
+		}
+
+		err := repo.apply(object)
+		if err != nil {
+			return err
+		}
+
+		return drop.Accept(false)
+	}
+
+	return nil
+}
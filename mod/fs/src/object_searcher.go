@@ -1,19 +1,53 @@
 package fs
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/cryptopunkscc/astrald/astral"
 	"github.com/cryptopunkscc/astrald/mod/objects"
 )
 
-// SearchObject performs a case-insensitive path substring search; requires the "path" tag and ZoneDevice zone.
+// searchTags are the tags supported by the searcher
+var searchTags = []string{"path", "name", "ext", "modified"}
+
+// searchCond is a single condition on the index of local files.
+type searchCond struct {
+	sql  string
+	args []any
+}
+
+// fileSearch is a search query translated to conditions on the index. Names are checked
+// on the rows found, since the index has no column for them.
+type fileSearch struct {
+	conds        []searchCond
+	names        []string
+	excludeNames []string
+}
+
+// SearchObject searches indexed files by path; requires ZoneDevice. The query text matches
+// a substring of the path. Tags narrow the search:
+//
+//	path:~/documents   files under a directory (absolute or ~ paths) or with a path substring
+//	name:report        files with a name substring
+//	ext:pdf            files with an extension
+//	modified:>=2026-10-01, modified:<2026-10-01T12:00, modified:2026-10-01 (that day),
+//	modified:7d        files modified in the last 7 days (h, d and w units)
 func (mod *Module) SearchObject(ctx *astral.Context, query objects.SearchQuery) (<-chan *objects.SearchResult, error) {
 	if !ctx.Zone().Is(astral.ZoneDevice) {
 		return nil, astral.ErrZoneExcluded
 	}
 
-	err := query.RequiredTagsIn("path")
+	err := query.RequiredTagsIn(searchTags...)
+	if err != nil {
+		return nil, err
+	}
+
+	search, err := parseFileSearch(query, time.Now())
 	if err != nil {
 		return nil, err
 	}
@@ -23,20 +57,232 @@ func (mod *Module) SearchObject(ctx *astral.Context, query objects.SearchQuery)
 	go func() {
 		defer close(results)
 
-		rows, err := mod.db.SearchByPath(strings.ToLower(string(query.Query)))
+		if search == nil {
+			return
+		}
+
+		rows, err := mod.db.SearchFiles(search.conds)
 		if err != nil {
 			mod.log.Error("search: db: %v", err)
 			return
 		}
-		mod.log.Log("search fs: %v", len(rows))
+
 		for _, row := range rows {
+			if !search.matchName(filepath.Base(row.Path)) {
+				continue
+			}
 
-			results <- &objects.SearchResult{
+			select {
+			case results <- &objects.SearchResult{
 				SourceID: mod.node.Identity(),
 				ObjectID: row.DataID,
+			}:
+			case <-ctx.Done():
+				return
 			}
 		}
 	}()
 
 	return results, nil
 }
+
+// parseFileSearch translates the query text and the required and excluded tags of a query
+// into conditions. Optional tags are ignored. An empty query returns nil, so it matches
+// nothing rather than the whole index.
+func parseFileSearch(query objects.SearchQuery, now time.Time) (*fileSearch, error) {
+	s := &fileSearch{}
+
+	text := strings.ToLower(strings.TrimSpace(string(query.Query)))
+	if len(text) > 0 {
+		s.conds = append(s.conds, searchCond{
+			sql:  `LOWER(path) LIKE ? ESCAPE '\'`,
+			args: []any{"%" + likeEscape(text) + "%"},
+		})
+	}
+
+	for _, tag := range query.Tags {
+		if tag.Mod != objects.TagModRequire && tag.Mod != objects.TagModExclude {
+			continue
+		}
+
+		name := strings.ToLower(string(tag.Name))
+		value := strings.ToLower(strings.TrimSpace(string(tag.Value)))
+		exclude := tag.Mod == objects.TagModExclude
+
+		if len(value) == 0 {
+			return nil, fmt.Errorf("empty value of %s", name)
+		}
+
+		if name == "name" {
+			if exclude {
+				s.excludeNames = append(s.excludeNames, value)
+			} else {
+				s.names = append(s.names, value)
+				// narrow the rows to check
+				s.conds = append(s.conds, searchCond{
+					sql:  `LOWER(path) LIKE ? ESCAPE '\'`,
+					args: []any{"%" + likeEscape(value) + "%"},
+				})
+			}
+			continue
+		}
+
+		cond, err := fileTagCond(name, value, now)
+		if err != nil {
+			return nil, err
+		}
+		if exclude {
+			cond.sql = "NOT (" + cond.sql + ")"
+		}
+		s.conds = append(s.conds, cond)
+	}
+
+	if len(s.conds) == 0 {
+		return nil, nil
+	}
+
+	return s, nil
+}
+
+// fileTagCond returns the condition of a path, ext or modified tag.
+func fileTagCond(name, value string, now time.Time) (searchCond, error) {
+	switch name {
+	case "path":
+		if dir, ok := expandDir(value); ok {
+			return searchCond{
+				sql:  `(LOWER(path) = ? OR LOWER(path) LIKE ? ESCAPE '\')`,
+				args: []any{dir, likeEscape(strings.TrimSuffix(dir, string(filepath.Separator))) + string(filepath.Separator) + "%"},
+			}, nil
+		}
+		return searchCond{
+			sql:  `LOWER(path) LIKE ? ESCAPE '\'`,
+			args: []any{"%" + likeEscape(value) + "%"},
+		}, nil
+
+	case "ext":
+		return searchCond{
+			sql:  `LOWER(path) LIKE ? ESCAPE '\'`,
+			args: []any{"%." + likeEscape(strings.TrimPrefix(value, "."))},
+		}, nil
+
+	case "modified":
+		return modifiedCond(value, now)
+	}
+
+	return searchCond{}, objects.NewErrTagUnsupported(name)
+}
+
+// modifiedCond returns the condition of a modified tag. The value is a point in time, a date,
+// a date and time or an age, optionally prefixed with a comparison operator (>, >=, <, <=, =).
+// A date without an operator matches that day, an age without an operator matches files
+// modified since then.
+func modifiedCond(value string, now time.Time) (searchCond, error) {
+	op, arg := "", value
+	for _, o := range []string{">=", "<=", ">", "<", "="} {
+		if strings.HasPrefix(value, o) {
+			op, arg = o, strings.TrimSpace(value[len(o):])
+			break
+		}
+	}
+
+	if day, err := time.ParseInLocation(time.DateOnly, arg, now.Location()); err == nil {
+		next := day.AddDate(0, 0, 1)
+		switch op {
+		case "", "=":
+			return searchCond{
+				sql:  "(mod_time >= ? AND mod_time < ?)",
+				args: []any{day.UnixNano(), next.UnixNano()},
+			}, nil
+		case ">":
+			// after the whole day
+			return searchCond{sql: "mod_time >= ?", args: []any{next.UnixNano()}}, nil
+		case "<=":
+			return searchCond{sql: "mod_time < ?", args: []any{next.UnixNano()}}, nil
+		}
+		return searchCond{sql: "mod_time " + op + " ?", args: []any{day.UnixNano()}}, nil
+	}
+
+	point, err := parseTimePoint(arg, now)
+	if err != nil {
+		return searchCond{}, fmt.Errorf("invalid value of modified: %s", value)
+	}
+
+	if op == "" || op == "=" {
+		op = ">="
+	}
+
+	return searchCond{sql: "mod_time " + op + " ?", args: []any{point.UnixNano()}}, nil
+}
+
+// parseTimePoint parses a date and time or an age relative to now.
+func parseTimePoint(s string, now time.Time) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02T15:04"} {
+		t, err := time.ParseInLocation(layout, strings.ToUpper(s), now.Location())
+		if err == nil {
+			return t, nil
+		}
+	}
+
+	if len(s) < 2 {
+		return time.Time{}, strconv.ErrSyntax
+	}
+
+	var unit time.Duration
+	switch s[len(s)-1] {
+	case 'h':
+		unit = time.Hour
+	case 'd':
+		unit = 24 * time.Hour
+	case 'w':
+		unit = 7 * 24 * time.Hour
+	default:
+		return time.Time{}, strconv.ErrSyntax
+	}
+
+	n, err := strconv.ParseUint(s[:len(s)-1], 10, 32)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return now.Add(-time.Duration(n) * unit), nil
+}
+
+// expandDir returns the cleaned directory of a path tag if it is absolute or relative to
+// the home directory.
+func expandDir(value string) (string, bool) {
+	if value == "~" || strings.HasPrefix(value, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", false
+		}
+		value = strings.ToLower(home) + value[1:]
+	}
+
+	if !filepath.IsAbs(value) {
+		return "", false
+	}
+
+	return filepath.Clean(value), true
+}
+
+// matchName checks if a file name matches the name tags of the search.
+func (s *fileSearch) matchName(name string) bool {
+	name = strings.ToLower(name)
+
+	for _, n := range s.names {
+		if !strings.Contains(name, n) {
+			return false
+		}
+	}
+	for _, n := range s.excludeNames {
+		if strings.Contains(name, n) {
+			return false
+		}
+	}
+	return true
+}
+
+// likeEscape escapes the wildcards of a LIKE pattern.
+func likeEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,90 @@
+package fs
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/astral/log"
+	"github.com/cryptopunkscc/astrald/mod/objects"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestSearchObject(t *testing.T) {
+	gdb, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mod := &Module{
+		db:   &DB{gdb},
+		log:  log.New(nil),
+		node: &testNode{identity: astral.GenerateIdentity()},
+	}
+	if err := mod.db.AutoMigrate(&dbLocalFile{}); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	files := map[string]time.Time{
+		"/home/u/Documents/Report.pdf":    now.Add(-time.Hour),
+		"/home/u/Documents/old/notes.pdf": now.AddDate(0, -1, 0),
+		"/home/u/Documents/report.txt":    now.Add(-time.Hour),
+		"/home/u/Documents-old/a.pdf":     now.Add(-time.Hour),
+		"/home/u/Music/100%_report.mp3":   now.Add(-time.Hour),
+	}
+
+	for path, modTime := range files {
+		id, _ := astral.Resolve(strings.NewReader(path))
+		if err := mod.db.IndexPath(path, id, modTime.UnixNano()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	search := func(text string) (found []string) {
+		var query objects.SearchQuery
+		if err := query.UnmarshalText([]byte(text)); err != nil {
+			t.Fatal(err)
+		}
+
+		ctx := astral.NewContext(nil).WithZone(astral.ZoneDevice)
+		results, err := mod.SearchObject(ctx, query)
+		if err != nil {
+			t.Fatalf("%q: %v", text, err)
+		}
+
+		for result := range results {
+			for path := range files {
+				if id, _ := astral.Resolve(strings.NewReader(path)); id.IsEqual(result.ObjectID) {
+					found = append(found, path)
+				}
+			}
+		}
+		slices.Sort(found)
+		return
+	}
+
+	tests := map[string][]string{
+		"ext:pdf path:/home/u/documents modified:7d": {"/home/u/Documents/Report.pdf"},
+		"ext:pdf path:/home/u/documents":             {"/home/u/Documents/Report.pdf", "/home/u/Documents/old/notes.pdf"},
+		"name:report -ext:mp3":                       {"/home/u/Documents/Report.pdf", "/home/u/Documents/report.txt"},
+		"name:% ":                                    {"/home/u/Music/100%_report.mp3"},
+		"documents -name:report modified:<3d":        {"/home/u/Documents/old/notes.pdf"},
+		"":                                           nil,
+	}
+
+	for text, want := range tests {
+		if got := search(text); !slices.Equal(got, want) {
+			t.Errorf("%q: got %v, want %v", text, got, want)
+		}
+	}
+
+	var query objects.SearchQuery
+	_ = query.UnmarshalText([]byte("modified:yesterday"))
+	if _, err := mod.SearchObject(astral.NewContext(nil).WithZone(astral.ZoneDevice), query); err == nil {
+		t.Error("invalid modified value accepted")
+	}
+}
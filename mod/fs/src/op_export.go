@@ -0,0 +1,82 @@
+package fs
+
+import (
+	"path/filepath"
+
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/astral/channel"
+	"github.com/cryptopunkscc/astrald/lib/routing"
+	"github.com/cryptopunkscc/astrald/mod/fs"
+)
+
+type opExportArgs struct {
+	Dir       string
+	ID        *astral.ObjectID `query:"optional"`
+	Name      string           `query:"optional"`
+	Extract   bool             `query:"optional"`
+	Folder    string           `query:"optional"`
+	Overwrite bool             `query:"optional"`
+	Zone      astral.Zone      `query:"optional"`
+	Out       string           `query:"optional"`
+}
+
+// OpExport writes objects into a directory as regular files. It exports a single object (named
+// Name, or its ID), every entry of an archive (Extract), or every file of a synced folder
+// (Folder). Archive and folder entries keep their paths and modification times. Files already
+// holding their objects are skipped and partial files are resumed, so an interrupted export
+// can be repeated. Sends a FileLocation of every exported file, followed by an EOS.
+func (mod *Module) OpExport(ctx *astral.Context, q *routing.IncomingQuery, args opExportArgs) (err error) {
+	if !q.Caller().IsEqual(mod.node.Identity()) {
+		return q.Reject()
+	}
+
+	ch := channel.New(q.AcceptRaw(), channel.WithOutputFormat(args.Out))
+	defer ch.Close()
+
+	if !filepath.IsAbs(args.Dir) {
+		return ch.Send(astral.Err(fs.ErrNotAbsolute))
+	}
+
+	ctx = ctx.WithIdentity(q.Caller()).IncludeZone(args.Zone)
+
+	var items []exportItem
+	switch {
+	case len(args.Folder) > 0:
+		items, err = mod.folderItems(args.Folder)
+
+	case args.ID == nil || args.ID.IsZero():
+		return ch.Send(astral.NewError("id or folder is required"))
+
+	case args.Extract:
+		items, err = mod.archiveItems(ctx, args.ID)
+
+	default:
+		name := args.Name
+		if len(name) == 0 {
+			name = args.ID.String()
+		}
+		items = []exportItem{{Path: name, ObjectID: args.ID}}
+	}
+	if err != nil {
+		return ch.Send(astral.Err(err))
+	}
+
+	for _, item := range items {
+		path, err := mod.export(ctx, args.Dir, item, args.Overwrite)
+		if err != nil {
+			return ch.Send(astral.Err(err))
+		}
+
+		mod.log.Logv(1, "exported %v to %v", item.ObjectID, path)
+
+		err = ch.Send(&fs.FileLocation{
+			NodeID: mod.node.Identity(),
+			Path:   astral.String16(path),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return ch.Send(&astral.EOS{})
+}
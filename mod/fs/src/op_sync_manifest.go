@@ -0,0 +1,38 @@
+package fs
+
+import (
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/astral/channel"
+	"github.com/cryptopunkscc/astrald/lib/routing"
+)
+
+type opSyncManifestArgs struct {
+	Folder string
+	Out    string `query:"optional"`
+}
+
+// OpSyncManifest streams the manifest of a synced folder followed by an EOS. Only the peers
+// of the folder can read it.
+func (mod *Module) OpSyncManifest(ctx *astral.Context, q *routing.IncomingQuery, args opSyncManifestArgs) (err error) {
+	repo, found := mod.syncs.Get(args.Folder)
+	if !found || !(q.Caller().IsEqual(mod.node.Identity()) || repo.isPeer(q.Caller())) {
+		return q.RejectWithCode(2)
+	}
+
+	ch := channel.New(q.AcceptRaw(), channel.WithOutputFormat(args.Out))
+	defer ch.Close()
+
+	rows, err := mod.db.SyncEntries(args.Folder)
+	if err != nil {
+		return ch.Send(astral.Err(err))
+	}
+
+	for _, row := range rows {
+		err = ch.Send(row.entry())
+		if err != nil {
+			return
+		}
+	}
+
+	return ch.Send(&astral.EOS{})
+}
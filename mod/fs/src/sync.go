@@ -0,0 +1,548 @@
+package fs
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/astral/channel"
+	"github.com/cryptopunkscc/astrald/lib/astrald"
+	"github.com/cryptopunkscc/astrald/lib/paths"
+	"github.com/cryptopunkscc/astrald/lib/query"
+	"github.com/cryptopunkscc/astrald/mod/fs"
+	objectscli "github.com/cryptopunkscc/astrald/mod/objects/client"
+)
+
+const (
+	syncPullInterval  = 5 * time.Minute
+	syncRetryInterval = time.Minute
+	syncSettleDelay   = 2 * time.Second // files modified more recently are synced later
+	syncQueryTimeout  = time.Minute
+	syncConflictTag   = ".sync-conflict-"
+)
+
+var errSyncMismatch = errors.New("data does not match the object id")
+
+// run syncs the folder until ctx is canceled. The manifests of the peers are pulled on start
+// and periodically, local changes are recorded as they are reported by the watcher.
+func (repo *SyncRepository) run(ctx *astral.Context) {
+	pull := time.NewTicker(syncPullInterval)
+	defer pull.Stop()
+	retry := time.NewTicker(syncRetryInterval)
+	defer retry.Stop()
+
+	repo.pullAll(ctx)
+
+	for {
+		repo.reconcile(ctx)
+		repo.materialize(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-repo.changed:
+			// let bursts of events settle
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(syncSettleDelay):
+			}
+		case <-retry.C:
+		case <-pull.C:
+			repo.pullAll(ctx)
+		}
+	}
+}
+
+// reconcile compares the directory with the manifest and records every file added, changed
+// or removed on disk as a new version. The new entries are pushed to the peers.
+func (repo *SyncRepository) reconcile(ctx *astral.Context) {
+	var changed []*fs.SyncEntry
+
+	repo.mu.Lock()
+
+	rows, err := repo.mod.db.SyncEntries(repo.folder)
+	if err != nil {
+		repo.mu.Unlock()
+		repo.mod.log.Error("sync %v: db error: %v", repo.folder, err)
+		return
+	}
+
+	entries := make(map[string]*dbSyncEntry, len(rows))
+	for _, row := range rows {
+		entries[row.Path] = row
+	}
+
+	seen := map[string]bool{}
+	err = paths.WalkDir(ctx, repo.root, func(diskPath string, info os.FileInfo) error {
+		rel, err := filepath.Rel(repo.root, diskPath)
+		if err != nil {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		if !syncablePath(rel) {
+			return nil
+		}
+		seen[rel] = true
+
+		row := entries[rel]
+		if row != nil && row.Pending {
+			return nil
+		}
+		if time.Since(info.ModTime()) < syncSettleDelay {
+			repo.trigger()
+			return nil
+		}
+
+		objectID, err := repo.fileID(diskPath, info)
+		if err != nil {
+			repo.mod.log.Errorv(1, "sync %v: %v: %v", repo.folder, rel, err)
+			return nil
+		}
+
+		if row != nil && !row.Removed && row.ObjectID.IsEqual(objectID) {
+			return nil
+		}
+
+		entry, err := repo.record(row, rel, objectID, false)
+		if err != nil {
+			return err
+		}
+		changed = append(changed, entry)
+		return nil
+	})
+
+	for rel, row := range entries {
+		if err != nil {
+			break
+		}
+		if seen[rel] || row.Removed || row.Pending {
+			continue
+		}
+
+		var entry *fs.SyncEntry
+		entry, err = repo.record(row, rel, row.ObjectID, true)
+		changed = append(changed, entry)
+	}
+
+	repo.mu.Unlock()
+
+	if err != nil && ctx.Err() == nil {
+		repo.mod.log.Error("sync %v: reconcile: %v", repo.folder, err)
+	}
+
+	for _, entry := range changed {
+		repo.mod.log.Logv(1, "sync: local change %v", entry)
+		repo.push(ctx, entry)
+	}
+}
+
+// record stores a local change of a file as a version following the current one.
+func (repo *SyncRepository) record(row *dbSyncEntry, rel string, objectID *astral.ObjectID, removed bool) (*fs.SyncEntry, error) {
+	if row == nil {
+		row = &dbSyncEntry{Folder: repo.folder, Path: rel}
+	}
+
+	self := repo.mod.node.Identity()
+	entry := &fs.SyncEntry{
+		Folder:   astral.String8(repo.folder),
+		Path:     astral.String16(rel),
+		ObjectID: objectID,
+		Removed:  astral.Bool(removed),
+		Version:  row.version().Bump(self),
+		Origin:   self,
+		ModTime:  astral.Now(),
+	}
+
+	row.setEntry(entry)
+	row.DiskID = objectID
+	if removed {
+		row.DiskID = nil
+	}
+
+	return entry, repo.mod.db.SaveSyncEntry(row)
+}
+
+// fileID returns the object ID of a file, hashing it only if the index is out of date.
+func (repo *SyncRepository) fileID(diskPath string, info os.FileInfo) (*astral.ObjectID, error) {
+	modTime := info.ModTime().UnixNano()
+
+	row, err := repo.mod.db.FindByPath(diskPath)
+	if err == nil && row.ModTime == modTime && row.UpdatedAt != 0 && row.DataID != nil {
+		return row.DataID, nil
+	}
+
+	objectID, err := resolveFileID(diskPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return objectID, repo.mod.db.IndexPath(diskPath, objectID, modTime)
+}
+
+// apply merges an entry received from a peer into the manifest. Entries that descend from the
+// local one replace it. Concurrent entries are resolved by resolveConflict.
+func (repo *SyncRepository) apply(remote *fs.SyncEntry) error {
+	rel := string(remote.Path)
+	if !syncablePath(rel) || remote.ObjectID == nil || remote.Origin.IsZero() {
+		return fs.ErrInvalidPath
+	}
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	row, err := repo.mod.db.FindSyncEntry(repo.folder, rel)
+	if err != nil {
+		return err
+	}
+
+	if row == nil {
+		row = &dbSyncEntry{Folder: repo.folder, Path: rel}
+		row.setEntry(remote)
+		row.Pending = !row.Removed
+		return repo.save(row)
+	}
+
+	local := row.entry()
+
+	switch remote.Version.Compare(local.Version) {
+	case fs.SyncBefore, fs.SyncEqual:
+		return nil
+
+	case fs.SyncAfter:
+		row.setEntry(remote)
+		row.Pending = true
+		return repo.save(row)
+	}
+
+	return repo.resolveConflict(row, local, remote)
+}
+
+// resolveConflict merges two concurrent versions of a file. The later change is kept at the
+// path, the other one is saved as a conflict copy. Removals lose to changes. Every node picks
+// the same winner and the same conflict copy, so the manifests converge.
+func (repo *SyncRepository) resolveConflict(row *dbSyncEntry, local, remote *fs.SyncEntry) error {
+	merged := local.Version.Merge(remote.Version)
+
+	if local.Removed == remote.Removed && local.ObjectID.IsEqual(remote.ObjectID) {
+		local.Version = merged
+		row.setEntry(local)
+		return repo.save(row)
+	}
+
+	winner, loser := local, remote
+	if syncWins(remote, local) {
+		winner, loser = remote, local
+	}
+
+	kept := *winner
+	kept.Version = merged
+	row.setEntry(&kept)
+	row.Pending = winner == remote
+
+	repo.mod.log.Info("sync %v: conflict at %v, keeping %v", repo.folder, row.Path, winner.Origin)
+
+	if !loser.Removed {
+		copyPath := conflictPath(string(loser.Path), loser.Origin, loser.ModTime.Time())
+
+		existing, err := repo.mod.db.FindSyncEntry(repo.folder, copyPath)
+		if err != nil {
+			return err
+		}
+		if existing == nil {
+			conflict := &dbSyncEntry{Folder: repo.folder, Path: copyPath}
+			conflict.setEntry(&fs.SyncEntry{
+				Folder:   loser.Folder,
+				Path:     astral.String16(copyPath),
+				ObjectID: loser.ObjectID,
+				Version:  fs.SyncVersion{{Node: loser.Origin, Counter: 1}},
+				Origin:   loser.Origin,
+				ModTime:  loser.ModTime,
+			})
+			conflict.Pending = true
+
+			// why: the local version is still on disk, keep it instead of fetching it again
+			src := repo.diskPath(row.Path)
+			if loser == local && row.DiskID.IsEqual(loser.ObjectID) && fileExists(src) {
+				dst := repo.diskPath(copyPath)
+				if err := os.Rename(src, dst); err == nil {
+					conflict.Pending = false
+					conflict.DiskID = loser.ObjectID
+					row.DiskID = nil
+					row.Pending = true
+				}
+			}
+
+			if err := repo.mod.db.SaveSyncEntry(conflict); err != nil {
+				return err
+			}
+		}
+	}
+
+	return repo.save(row)
+}
+
+// save stores an entry and schedules a sync if it needs to be materialized.
+func (repo *SyncRepository) save(row *dbSyncEntry) error {
+	err := repo.mod.db.SaveSyncEntry(row)
+	if err == nil && row.Pending {
+		repo.trigger()
+	}
+	return err
+}
+
+// syncWins checks if a change wins over a concurrent one.
+func syncWins(a, b *fs.SyncEntry) bool {
+	switch {
+	case a.Removed != b.Removed:
+		return !bool(a.Removed)
+	case !a.ModTime.Time().Equal(b.ModTime.Time()):
+		return a.ModTime.Time().After(b.ModTime.Time())
+	}
+	return a.Origin.String() > b.Origin.String()
+}
+
+// conflictPath returns the path of the conflict copy of a change made by a node.
+func conflictPath(p string, origin *astral.Identity, modTime time.Time) string {
+	ext := path.Ext(p)
+	node := origin.String()
+	if len(node) > 8 {
+		node = node[:8]
+	}
+
+	return fmt.Sprintf("%s%s%s-%s%s", strings.TrimSuffix(p, ext), syncConflictTag, modTime.UTC().Format("20060102-150405"), node, ext)
+}
+
+// materialize writes the pending entries of the manifest to disk.
+func (repo *SyncRepository) materialize(ctx *astral.Context) {
+	rows, err := repo.mod.db.PendingSyncEntries(repo.folder)
+	if err != nil {
+		repo.mod.log.Error("sync %v: db error: %v", repo.folder, err)
+		return
+	}
+
+	for _, row := range rows {
+		if ctx.Err() != nil {
+			return
+		}
+
+		err := repo.materializeEntry(ctx, row)
+		if err != nil {
+			repo.mod.log.Errorv(1, "sync %v: %v: %v", repo.folder, row.Path, err)
+		}
+	}
+}
+
+// materializeEntry writes a file to disk or removes it. A file changed on disk since it was
+// last synced is never overwritten or removed; it is moved to a conflict copy or kept.
+func (repo *SyncRepository) materializeEntry(ctx *astral.Context, row *dbSyncEntry) error {
+	diskPath := repo.diskPath(row.Path)
+
+	var tempPath string
+	if !row.Removed && !repo.onDisk(diskPath, row.ObjectID) {
+		var err error
+		tempPath, err = repo.fetch(ctx, row)
+		if err != nil {
+			return err
+		}
+		defer os.Remove(tempPath)
+	}
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	// the entry could have changed while the file was fetched
+	current, err := repo.mod.db.FindSyncEntry(repo.folder, row.Path)
+	if err != nil || current == nil || current.Version != row.Version || !current.Pending {
+		return err
+	}
+
+	if fileExists(diskPath) && !repo.onDisk(diskPath, current.DiskID) && !repo.onDisk(diskPath, current.ObjectID) {
+		if current.Removed {
+			// an unsynced local change wins over the removal, reconcile will record it
+			current.Pending = false
+			current.DiskID = nil
+			return repo.mod.db.SaveSyncEntry(current)
+		}
+
+		copyPath := conflictPath(current.Path, repo.mod.node.Identity(), time.Now())
+		if err := os.Rename(diskPath, repo.diskPath(copyPath)); err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case current.Removed:
+		if err := os.Remove(diskPath); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		current.DiskID = nil
+
+	case len(tempPath) > 0:
+		if err := os.Rename(tempPath, diskPath); err != nil {
+			return err
+		}
+		if info, err := os.Stat(diskPath); err == nil {
+			_ = repo.mod.db.IndexPath(diskPath, current.ObjectID, info.ModTime().UnixNano())
+		}
+		current.DiskID = current.ObjectID
+
+	default:
+		current.DiskID = current.ObjectID
+	}
+
+	current.Pending = false
+
+	repo.mod.log.Logv(1, "sync: applied %v", current.entry())
+
+	return repo.mod.db.SaveSyncEntry(current)
+}
+
+// onDisk checks if the file at diskPath holds the object.
+func (repo *SyncRepository) onDisk(diskPath string, objectID *astral.ObjectID) bool {
+	if objectID == nil {
+		return false
+	}
+
+	info, err := os.Stat(diskPath)
+	if err != nil || !info.Mode().IsRegular() {
+		return false
+	}
+
+	fileID, err := repo.fileID(diskPath, info)
+	return err == nil && fileID.IsEqual(objectID)
+}
+
+// fetch reads the object of an entry into a temporary file next to its path. The object is
+// read from the node's repositories if possible, otherwise from the peers, starting with the
+// node that made the change.
+func (repo *SyncRepository) fetch(ctx *astral.Context, row *dbSyncEntry) (string, error) {
+	dir := filepath.Dir(repo.diskPath(row.Path))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	var rbytes = make([]byte, 8)
+	rand.Read(rbytes)
+	tempPath := filepath.Join(dir, tempFilePrefix+hex.EncodeToString(rbytes))
+
+	sources := []func() (io.ReadCloser, error){
+		func() (io.ReadCloser, error) {
+			return repo.mod.Objects.ReadDefault().Read(ctx.WithZone(astral.ZoneDevice|astral.ZoneVirtual), row.ObjectID, 0, 0)
+		},
+	}
+
+	peers := repo.Peers()
+	for i, peer := range peers {
+		if peer.IsEqual(row.Origin) {
+			peers[0], peers[i] = peers[i], peers[0]
+		}
+	}
+	for _, peer := range peers {
+		sources = append(sources, func() (io.ReadCloser, error) {
+			return objectscli.New(peer, astrald.Default()).Read(ctx, row.ObjectID, 0, 0)
+		})
+	}
+
+	err := objectsNotFound
+	for _, source := range sources {
+		r, serr := source()
+		if serr != nil {
+			continue
+		}
+
+		err = writeVerified(tempPath, r, row.ObjectID)
+		r.Close()
+		if err == nil {
+			return tempPath, nil
+		}
+		os.Remove(tempPath)
+	}
+
+	return "", err
+}
+
+var objectsNotFound = errors.New("object not found on any peer")
+
+// writeVerified copies an object into a file and checks that the data matches its ID.
+func writeVerified(path string, r io.Reader, objectID *astral.ObjectID) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	resolver := astral.NewWriteResolver(nil)
+
+	_, err = io.Copy(io.MultiWriter(f, resolver), io.LimitReader(r, int64(objectID.Size)))
+	if err != nil {
+		return err
+	}
+
+	if !resolver.Resolve().IsEqual(objectID) {
+		return errSyncMismatch
+	}
+
+	return f.Sync()
+}
+
+// push sends an entry to every peer of the folder.
+func (repo *SyncRepository) push(ctx *astral.Context, entry *fs.SyncEntry) {
+	for _, peer := range repo.Peers() {
+		err := repo.mod.Objects.Push(ctx, peer, entry)
+		if err != nil {
+			repo.mod.log.Logv(2, "sync %v: push to %v: %v", repo.folder, peer, err)
+		}
+	}
+}
+
+// pullAll merges the manifests of all peers.
+func (repo *SyncRepository) pullAll(ctx *astral.Context) {
+	for _, peer := range repo.Peers() {
+		err := repo.pull(ctx, peer)
+		if err != nil && ctx.Err() == nil {
+			repo.mod.log.Logv(2, "sync %v: pull from %v: %v", repo.folder, peer, err)
+		}
+	}
+}
+
+// pull fetches the manifest of the folder from a peer and merges it.
+func (repo *SyncRepository) pull(ctx *astral.Context, peer *astral.Identity) error {
+	ctx, cancel := ctx.WithTimeout(syncQueryTimeout)
+	defer cancel()
+
+	q := query.New(repo.mod.node.Identity(), peer, fs.MethodSyncManifest, query.Args{
+		"folder": repo.folder,
+	})
+
+	ch, err := query.Route(ctx, repo.mod.node, q)
+	if err != nil {
+		return err
+	}
+	defer ch.Close()
+
+	var entries []*fs.SyncEntry
+	err = ch.Switch(channel.Collect(&entries), channel.BreakOnEOS, channel.PassErrors, channel.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if string(entry.Folder) != repo.folder {
+			continue
+		}
+		if err := repo.apply(entry); err != nil {
+			repo.mod.log.Errorv(1, "sync %v: entry %v from %v: %v", repo.folder, entry.Path, peer, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,148 @@
+package fs
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/mod/objects"
+)
+
+var _ objects.Repository = &SyncRepository{}
+
+// SyncRepository is a watched repository whose directory is kept in two-way sync with the
+// manifest of a folder shared by several nodes. Local changes are recorded in the manifest
+// and pushed to the peers; changes received from the peers are written to the directory.
+// Files changed concurrently on two nodes are resolved the same way on every node: the later
+// change is kept at the path and the other one is saved as a conflict copy next to it.
+type SyncRepository struct {
+	*WatchRepository
+	folder string
+	peers  []string
+
+	mu      sync.Mutex
+	changed chan struct{}
+}
+
+// NewSyncRepository creates a SyncRepository that keeps root in sync with a folder.
+func NewSyncRepository(mod *Module, root string, label string, folder string, peers []string) (*SyncRepository, error) {
+	watch, err := NewWatchRepository(mod, root, label)
+	if err != nil {
+		return nil, err
+	}
+
+	repo := &SyncRepository{
+		WatchRepository: watch,
+		folder:          folder,
+		peers:           peers,
+		changed:         make(chan struct{}, 1),
+	}
+
+	watch.watcher.OnWriteDone = repo.onChange
+	watch.watcher.OnRenamed = repo.onChange
+	watch.watcher.OnFileCreated = repo.onChange
+	watch.watcher.OnRemoved = repo.onRemove
+
+	return repo, nil
+}
+
+func (repo *SyncRepository) onChange(path string) {
+	repo.WatchRepository.onChange(path)
+	repo.trigger()
+}
+
+func (repo *SyncRepository) onRemove(path string) {
+	repo.WatchRepository.onRemove(path)
+	repo.trigger()
+}
+
+// addSyncRepo creates a SyncRepository for a configured repository. Every folder can be
+// synced with one directory only.
+func (mod *Module) addSyncRepo(cfg RepoConfig) (*SyncRepository, error) {
+	if _, found := mod.syncs.Get(cfg.Sync); found {
+		return nil, fmt.Errorf("folder %v is already synced", cfg.Sync)
+	}
+
+	repo, err := NewSyncRepository(mod, cfg.Path, cfg.Label, cfg.Sync, cfg.Peers)
+	if err != nil {
+		return nil, err
+	}
+
+	mod.syncs.Set(cfg.Sync, repo)
+
+	return repo, nil
+}
+
+// trigger schedules a sync of the folder.
+func (repo *SyncRepository) trigger() {
+	select {
+	case repo.changed <- struct{}{}:
+	default:
+	}
+}
+
+// Peers returns the nodes the folder is synced with.
+func (repo *SyncRepository) Peers() (peers []*astral.Identity) {
+	self := repo.mod.node.Identity()
+
+	var candidates []*astral.Identity
+	switch {
+	case len(repo.peers) > 0:
+		for _, name := range repo.peers {
+			id, err := repo.mod.Dir.ResolveIdentity(name)
+			if err != nil {
+				repo.mod.log.Errorv(1, "sync %v: invalid peer %v: %v", repo.folder, name, err)
+				continue
+			}
+			candidates = append(candidates, id)
+		}
+	case repo.mod.User != nil:
+		candidates = repo.mod.User.LocalSwarm()
+	}
+
+	for _, id := range candidates {
+		if !id.IsEqual(self) {
+			peers = append(peers, id)
+		}
+	}
+	return
+}
+
+// isPeer checks if a node is a peer of the folder.
+func (repo *SyncRepository) isPeer(id *astral.Identity) bool {
+	for _, peer := range repo.Peers() {
+		if peer.IsEqual(id) {
+			return true
+		}
+	}
+	return false
+}
+
+// diskPath returns the path of a file of the folder in the directory.
+func (repo *SyncRepository) diskPath(path string) string {
+	return filepath.Join(repo.root, filepath.FromSlash(path))
+}
+
+// syncablePath checks if a slash separated path can be synced. Paths leaving the folder and
+// temporary files are rejected.
+func syncablePath(path string) bool {
+	if !filepath.IsLocal(filepath.FromSlash(path)) || strings.Contains(path, `\`) {
+		return false
+	}
+
+	for _, seg := range strings.Split(path, "/") {
+		if len(seg) == 0 || strings.HasPrefix(seg, tempFilePrefix) {
+			return false
+		}
+	}
+	return true
+}
+
+// fileExists checks if a regular file exists at path.
+func fileExists(path string) bool {
+	stat, err := os.Stat(path)
+	return err == nil && stat.Mode().IsRegular()
+}
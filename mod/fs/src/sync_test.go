@@ -0,0 +1,151 @@
+package fs
+
+import (
+	"bytes"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/astral/log"
+	"github.com/cryptopunkscc/astrald/mod/fs"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+type testNode struct {
+	astral.Router
+	identity *astral.Identity
+}
+
+func (n *testNode) Identity() *astral.Identity { return n.identity }
+
+func testSyncRepository(t *testing.T) *SyncRepository {
+	t.Helper()
+
+	gdb, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mod := &Module{
+		db:   &DB{gdb},
+		log:  log.New(nil),
+		node: &testNode{identity: astral.GenerateIdentity()},
+	}
+	if err := mod.db.AutoMigrate(&dbLocalFile{}, &dbSyncEntry{}); err != nil {
+		t.Fatal(err)
+	}
+
+	return &SyncRepository{
+		WatchRepository: &WatchRepository{mod: mod, root: t.TempDir()},
+		folder:          "test",
+		changed:         make(chan struct{}, 1),
+	}
+}
+
+func TestSyncVersion_Compare(t *testing.T) {
+	a, b := astral.GenerateIdentity(), astral.GenerateIdentity()
+
+	v1 := fs.SyncVersion{}.Bump(a)
+	v2 := v1.Bump(b)
+	v3 := v1.Bump(a)
+
+	tests := []struct {
+		v, other fs.SyncVersion
+		want     fs.SyncOrder
+	}{
+		{v1, v1, fs.SyncEqual},
+		{v1, v2, fs.SyncBefore},
+		{v2, v1, fs.SyncAfter},
+		{v2, v3, fs.SyncConcurrent},
+		{v2.Merge(v3), v3, fs.SyncAfter},
+		{nil, v1, fs.SyncBefore},
+	}
+
+	for i, test := range tests {
+		if got := test.v.Compare(test.other); got != test.want {
+			t.Errorf("case %d: got %v, want %v", i, got, test.want)
+		}
+	}
+}
+
+// A later concurrent change replaces the local file; the local version is kept as a conflict copy.
+func TestSyncRepository_Conflict(t *testing.T) {
+	repo := testSyncRepository(t)
+	ctx := astral.NewContext(nil)
+
+	diskPath := repo.diskPath("a.txt")
+	if err := os.WriteFile(diskPath, []byte("local"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-time.Minute)
+	if err := os.Chtimes(diskPath, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	repo.reconcile(ctx)
+
+	row, err := repo.mod.db.FindSyncEntry("test", "a.txt")
+	if err != nil || row == nil {
+		t.Fatalf("local change not recorded: %v", err)
+	}
+	local := row.entry()
+
+	remoteID, _ := astral.Resolve(bytes.NewReader([]byte("remote")))
+	other := astral.GenerateIdentity()
+	remote := &fs.SyncEntry{
+		Folder:   "test",
+		Path:     "a.txt",
+		ObjectID: remoteID,
+		Version:  fs.SyncVersion{}.Bump(other),
+		Origin:   other,
+		ModTime:  astral.Time(local.ModTime.Time().Add(time.Hour)),
+	}
+
+	if err := repo.apply(remote); err != nil {
+		t.Fatal(err)
+	}
+
+	row, _ = repo.mod.db.FindSyncEntry("test", "a.txt")
+	if !row.ObjectID.IsEqual(remoteID) || !row.Pending {
+		t.Fatalf("remote change not adopted: %+v", row)
+	}
+	if row.version().Compare(local.Version) != fs.SyncAfter || row.version().Compare(remote.Version) != fs.SyncAfter {
+		t.Fatalf("versions not merged: %v", row.Version)
+	}
+
+	copyPath := conflictPath("a.txt", local.Origin, local.ModTime.Time())
+	data, err := os.ReadFile(repo.diskPath(copyPath))
+	if err != nil || string(data) != "local" {
+		t.Fatalf("conflict copy: %q, %v", data, err)
+	}
+	if fileExists(diskPath) {
+		t.Fatal("local file left at the conflicting path")
+	}
+
+	// an older version of the entry is ignored
+	if err := repo.apply(local); err != nil {
+		t.Fatal(err)
+	}
+	row, _ = repo.mod.db.FindSyncEntry("test", "a.txt")
+	if !row.ObjectID.IsEqual(remoteID) {
+		t.Fatal("older version replaced the entry")
+	}
+}
+
+func TestSyncablePath(t *testing.T) {
+	for path, want := range map[string]bool{
+		"a.txt":         true,
+		"dir/a.txt":     true,
+		"../a.txt":      false,
+		"/a.txt":        false,
+		"dir//a.txt":    false,
+		"dir/.tmp.1234": false,
+		`dir\..\..\a`:   false,
+	} {
+		if got := syncablePath(path); got != want {
+			t.Errorf("%q: got %v, want %v", path, got, want)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package fs
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/cryptopunkscc/astrald/astral"
+)
+
+var _ astral.Object = &SyncEntry{}
+
+// SyncEntry is the state of a file in a synced folder. Nodes exchange entries to keep the
+// manifests of a folder in sync. The version tells later changes from concurrent ones.
+type SyncEntry struct {
+	Folder   astral.String8
+	Path     astral.String16 // slash separated, relative to the folder
+	ObjectID *astral.ObjectID
+	Removed  astral.Bool
+	Version  SyncVersion
+	Origin   *astral.Identity // node that made the change
+	ModTime  astral.Time
+}
+
+func (SyncEntry) ObjectType() string { return "mod.fs.sync_entry" }
+
+func (e SyncEntry) WriteTo(w io.Writer) (n int64, err error) {
+	return astral.Objectify(&e).WriteTo(w)
+}
+
+func (e *SyncEntry) ReadFrom(r io.Reader) (n int64, err error) {
+	return astral.Objectify(e).ReadFrom(r)
+}
+
+func (e SyncEntry) MarshalJSON() ([]byte, error) {
+	type alias SyncEntry
+	return json.Marshal(alias(e))
+}
+
+func (e *SyncEntry) UnmarshalJSON(bytes []byte) error {
+	type alias SyncEntry
+	return json.Unmarshal(bytes, (*alias)(e))
+}
+
+func (e SyncEntry) String() string {
+	if e.Removed {
+		return fmt.Sprintf("%s/%s removed", e.Folder, e.Path)
+	}
+	return fmt.Sprintf("%s/%s (%s)", e.Folder, e.Path, e.ObjectID)
+}
+
+// SyncCounter counts the changes a node made to a file.
+type SyncCounter struct {
+	Node    *astral.Identity
+	Counter astral.Uint64
+}
+
+// SyncVersion is a version vector of a file in a synced folder.
+type SyncVersion []SyncCounter
+
+// SyncOrder is the result of comparing two versions.
+type SyncOrder int
+
+const (
+	SyncEqual      SyncOrder = iota
+	SyncBefore               // the version is an ancestor of the other
+	SyncAfter                // the version descends from the other
+	SyncConcurrent           // the versions diverged
+)
+
+// Get returns the counter of a node.
+func (v SyncVersion) Get(node *astral.Identity) uint64 {
+	for _, c := range v {
+		if c.Node.IsEqual(node) {
+			return uint64(c.Counter)
+		}
+	}
+	return 0
+}
+
+// Bump returns a copy of the version with the counter of a node incremented.
+func (v SyncVersion) Bump(node *astral.Identity) SyncVersion {
+	next := make(SyncVersion, 0, len(v)+1)
+	found := false
+	for _, c := range v {
+		if c.Node.IsEqual(node) {
+			c.Counter++
+			found = true
+		}
+		next = append(next, c)
+	}
+	if !found {
+		next = append(next, SyncCounter{Node: node, Counter: 1})
+	}
+	return next
+}
+
+// Merge returns a version that descends from both versions.
+func (v SyncVersion) Merge(other SyncVersion) SyncVersion {
+	merged := append(SyncVersion{}, v...)
+	for _, c := range other {
+		found := false
+		for i := range merged {
+			if merged[i].Node.IsEqual(c.Node) {
+				merged[i].Counter = max(merged[i].Counter, c.Counter)
+				found = true
+			}
+		}
+		if !found {
+			merged = append(merged, c)
+		}
+	}
+	return merged
+}
+
+// Compare returns the order of the version relative to the other.
+func (v SyncVersion) Compare(other SyncVersion) SyncOrder {
+	var before, after bool
+
+	for _, c := range v {
+		switch o := other.Get(c.Node); {
+		case uint64(c.Counter) > o:
+			after = true
+		case uint64(c.Counter) < o:
+			before = true
+		}
+	}
+	for _, c := range other {
+		if v.Get(c.Node) < uint64(c.Counter) {
+			before = true
+		}
+	}
+
+	switch {
+	case before && after:
+		return SyncConcurrent
+	case before:
+		return SyncBefore
+	case after:
+		return SyncAfter
+	}
+	return SyncEqual
+}
+
+func init() {
+	_ = astral.Add(&SyncEntry{})
+}
@@ -0,0 +1,43 @@
+package media
+
+import (
+	"io"
+
+	"github.com/cryptopunkscc/astrald/astral"
+)
+
+var _ astral.Object = &AudioDescriptor{}
+
+// AudioDescriptor describes an audio file decoded from its tags (ID3 or Vorbis comments).
+// Duration is 0 if it can't be determined without decoding the audio.
+type AudioDescriptor struct {
+	Format   astral.String8
+	Title    astral.String16
+	Artist   astral.String16
+	Album    astral.String16
+	Duration astral.Duration
+}
+
+func (AudioDescriptor) ObjectType() string {
+	return "mod.media.audio_descriptor"
+}
+
+func (d AudioDescriptor) WriteTo(w io.Writer) (n int64, err error) {
+	return astral.Objectify(&d).WriteTo(w)
+}
+
+func (d *AudioDescriptor) ReadFrom(r io.Reader) (n int64, err error) {
+	return astral.Objectify(d).ReadFrom(r)
+}
+
+func (d AudioDescriptor) MarshalJSON() ([]byte, error) {
+	return astral.Objectify(&d).MarshalJSON()
+}
+
+func (d *AudioDescriptor) UnmarshalJSON(bytes []byte) error {
+	return astral.Objectify(d).UnmarshalJSON(bytes)
+}
+
+func init() {
+	_ = astral.Add(&AudioDescriptor{})
+}
@@ -0,0 +1,37 @@
+package media
+
+import (
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/astral/channel"
+	"github.com/cryptopunkscc/astrald/lib/astrald"
+)
+
+type Client struct {
+	targetID *astral.Identity
+	astral   *astrald.Client
+}
+
+var defaultClient *Client
+
+func New(targetID *astral.Identity, client *astrald.Client) *Client {
+	if client == nil {
+		client = astrald.Default()
+	}
+
+	return &Client{
+		astral:   client,
+		targetID: targetID,
+	}
+}
+
+func Default() *Client {
+	if defaultClient == nil {
+		defaultClient = New(nil, astrald.Default())
+	}
+
+	return defaultClient
+}
+
+func (client *Client) queryCh(ctx *astral.Context, method string, args any) (*channel.Channel, error) {
+	return client.astral.WithTarget(client.targetID).QueryChannel(ctx, method, args)
+}
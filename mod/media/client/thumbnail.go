@@ -0,0 +1,32 @@
+package media
+
+import (
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/astral/channel"
+	"github.com/cryptopunkscc/astrald/lib/query"
+	"github.com/cryptopunkscc/astrald/mod/media"
+)
+
+// Thumbnail returns the ID of a preview of an image that fits in a size x size square. A zero
+// size requests the default size.
+func (client *Client) Thumbnail(ctx *astral.Context, objectID *astral.ObjectID, size int) (*astral.ObjectID, error) {
+	args := query.Args{"id": objectID}
+	if size > 0 {
+		args["size"] = size
+	}
+
+	ch, err := client.queryCh(ctx, media.MethodThumbnail, args)
+	if err != nil {
+		return nil, err
+	}
+	defer ch.Close()
+
+	var thumbnailID *astral.ObjectID
+	err = ch.Switch(channel.Expect(&thumbnailID), channel.PassErrors, channel.WithContext(ctx))
+
+	return thumbnailID, err
+}
+
+func Thumbnail(ctx *astral.Context, objectID *astral.ObjectID, size int) (*astral.ObjectID, error) {
+	return Default().Thumbnail(ctx, objectID, size)
+}
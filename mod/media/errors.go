@@ -0,0 +1,5 @@
+package media
+
+import "errors"
+
+var ErrUnsupported = errors.New("unsupported media format")
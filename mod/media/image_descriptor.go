@@ -0,0 +1,43 @@
+package media
+
+import (
+	"io"
+
+	"github.com/cryptopunkscc/astrald/astral"
+)
+
+var _ astral.Object = &ImageDescriptor{}
+
+// ImageDescriptor describes an image decoded from its header. Orientation is the EXIF
+// orientation (1-8), 0 if unknown. TakenAt is zero if the image has no EXIF timestamp.
+type ImageDescriptor struct {
+	Format      astral.String8
+	Width       astral.Uint32
+	Height      astral.Uint32
+	Orientation astral.Uint8
+	TakenAt     astral.Time
+}
+
+func (ImageDescriptor) ObjectType() string {
+	return "mod.media.image_descriptor"
+}
+
+func (d ImageDescriptor) WriteTo(w io.Writer) (n int64, err error) {
+	return astral.Objectify(&d).WriteTo(w)
+}
+
+func (d *ImageDescriptor) ReadFrom(r io.Reader) (n int64, err error) {
+	return astral.Objectify(d).ReadFrom(r)
+}
+
+func (d ImageDescriptor) MarshalJSON() ([]byte, error) {
+	return astral.Objectify(&d).MarshalJSON()
+}
+
+func (d *ImageDescriptor) UnmarshalJSON(bytes []byte) error {
+	return astral.Objectify(d).UnmarshalJSON(bytes)
+}
+
+func init() {
+	_ = astral.Add(&ImageDescriptor{})
+}
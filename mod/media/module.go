@@ -0,0 +1,22 @@
+package media
+
+import "github.com/cryptopunkscc/astrald/astral"
+
+const ModuleName = "media"
+const DBPrefix = "media__"
+
+const (
+	MethodThumbnail = "media.thumbnail"
+)
+
+// Module extracts metadata of image and audio objects.
+type Module interface {
+	// Index decodes the metadata of an object and stores it. Returns an *ImageDescriptor,
+	// an *AudioDescriptor or ErrUnsupported if the object is not a known media format.
+	Index(ctx *astral.Context, objectID *astral.ObjectID) (astral.Object, error)
+
+	// Thumbnail returns the ID of a preview of an image that fits in a size x size square.
+	// Previews are generated on first request and kept for later calls. Images that already
+	// fit are returned as they are.
+	Thumbnail(ctx *astral.Context, objectID *astral.ObjectID, size int) (*astral.ObjectID, error)
+}
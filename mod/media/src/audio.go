@@ -0,0 +1,136 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/mod/media"
+)
+
+const maxTagText = 4 << 10 // maximum bytes of a single tag value read
+
+// parseAudio decodes the tags of an MP3, FLAC, Ogg Vorbis or Opus file.
+func parseAudio(r io.ReaderAt, size int64) (*media.AudioDescriptor, error) {
+	var magic [4]byte
+	if _, err := r.ReadAt(magic[:], 0); err != nil {
+		return nil, media.ErrUnsupported
+	}
+
+	switch {
+	case string(magic[:]) == "fLaC":
+		return parseFLAC(r, size)
+	case string(magic[:]) == "OggS":
+		return parseOgg(r, size)
+	case string(magic[:3]) == "ID3":
+		return parseMP3(r, size)
+	case magic[0] == 0xff && magic[1]&0xe0 == 0xe0:
+		if _, ok := parseMPEGHeader(magic[:]); ok {
+			return parseMP3(r, size)
+		}
+	}
+
+	return nil, media.ErrUnsupported
+}
+
+// audioTags collects tag values found in a file.
+type audioTags struct {
+	title, artist, album []string
+}
+
+func (t *audioTags) add(key, value string) {
+	value = strings.TrimSpace(strings.Trim(value, "\x00"))
+	if len(value) == 0 {
+		return
+	}
+
+	switch strings.ToUpper(key) {
+	case "TITLE":
+		t.title = append(t.title, value)
+	case "ARTIST":
+		t.artist = append(t.artist, value)
+	case "ALBUM":
+		t.album = append(t.album, value)
+	}
+}
+
+func (t *audioTags) descriptor(format string, duration time.Duration) *media.AudioDescriptor {
+	return &media.AudioDescriptor{
+		Format:   astral.String8(format),
+		Title:    astral.String16(strings.Join(t.title, ", ")),
+		Artist:   astral.String16(strings.Join(t.artist, ", ")),
+		Album:    astral.String16(strings.Join(t.album, ", ")),
+		Duration: astral.Duration(duration),
+	}
+}
+
+// parseVorbisComments reads a Vorbis comment block (also used by FLAC and Opus).
+func parseVorbisComments(data []byte, tags *audioTags) {
+	next := func() ([]byte, bool) {
+		if len(data) < 4 {
+			return nil, false
+		}
+		n := binary.LittleEndian.Uint32(data)
+		if uint64(n) > uint64(len(data)-4) {
+			return nil, false
+		}
+		v := data[4 : 4+n]
+		data = data[4+n:]
+		return v, true
+	}
+
+	// vendor string
+	if _, ok := next(); !ok || len(data) < 4 {
+		return
+	}
+
+	count := binary.LittleEndian.Uint32(data)
+	data = data[4:]
+
+	for i := uint32(0); i < count; i++ {
+		comment, ok := next()
+		if !ok {
+			return
+		}
+		if key, value, found := bytes.Cut(comment, []byte("=")); found {
+			tags.add(string(key), string(value))
+		}
+	}
+}
+
+// readAt reads exactly n bytes at off. Sizes and offsets come from untrusted headers, so
+// negative ones are rejected instead of being used.
+func readAt(r io.ReaderAt, off int64, n int) ([]byte, error) {
+	if off < 0 || n < 0 {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	buf := make([]byte, n)
+	m, err := r.ReadAt(buf, off)
+	if m == n {
+		return buf, nil
+	}
+	if err == nil {
+		err = io.ErrUnexpectedEOF
+	}
+	return nil, err
+}
+
+// sampleDuration returns the duration of a number of samples at a sample rate, saturating
+// instead of overflowing for values read from corrupt headers.
+func sampleDuration(samples, rate uint64) time.Duration {
+	if rate == 0 {
+		return 0
+	}
+
+	secs, rem := samples/rate, samples%rate
+	if secs >= uint64(math.MaxInt64/time.Second) {
+		return math.MaxInt64
+	}
+
+	return time.Duration(secs)*time.Second + time.Duration(rem*uint64(time.Second)/rate)
+}
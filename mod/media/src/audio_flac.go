@@ -0,0 +1,60 @@
+package media
+
+import (
+	"encoding/binary"
+	"io"
+	"time"
+
+	"github.com/cryptopunkscc/astrald/mod/media"
+)
+
+const (
+	flacStreamInfo    = 0
+	flacVorbisComment = 4
+	maxFLACBlocks     = 128
+	maxFLACComments   = 1 << 20
+)
+
+// parseFLAC reads the STREAMINFO and VORBIS_COMMENT metadata blocks of a FLAC file.
+func parseFLAC(r io.ReaderAt, size int64) (*media.AudioDescriptor, error) {
+	var tags audioTags
+	var duration time.Duration
+	var off int64 = 4
+
+	for i := 0; i < maxFLACBlocks && off+4 <= size; i++ {
+		hdr, err := readAt(r, off, 4)
+		if err != nil {
+			return nil, media.ErrUnsupported
+		}
+
+		last := hdr[0]&0x80 != 0
+		typ := hdr[0] & 0x7f
+		length := int(hdr[1])<<16 | int(hdr[2])<<8 | int(hdr[3])
+		off += 4
+
+		switch {
+		case typ == flacStreamInfo && length >= 18:
+			info, err := readAt(r, off, 18)
+			if err != nil {
+				return nil, media.ErrUnsupported
+			}
+			v := binary.BigEndian.Uint64(info[10:])
+			rate := v >> 44
+			samples := v & (1<<36 - 1)
+			duration = sampleDuration(samples, rate)
+
+		case typ == flacVorbisComment && length <= maxFLACComments:
+			data, err := readAt(r, off, length)
+			if err == nil {
+				parseVorbisComments(data, &tags)
+			}
+		}
+
+		if last {
+			break
+		}
+		off += int64(length)
+	}
+
+	return tags.descriptor("flac", duration), nil
+}
@@ -0,0 +1,271 @@
+package media
+
+import (
+	"encoding/binary"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"github.com/cryptopunkscc/astrald/mod/media"
+)
+
+const (
+	id3HeaderSize  = 10
+	id3v1Size      = 128
+	maxID3Frames   = 256
+	mpegSearchSize = 64 << 10 // bytes searched for the first frame after the tag
+)
+
+var (
+	mpeg1Bitrates = [16]int{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 0}
+	mpeg2Bitrates = [16]int{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160, 0}
+	mpegRates     = [4]int{44100, 48000, 32000, 0}
+)
+
+// id3 frames read, by their v2.3/v2.4 and v2.2 names
+var id3Frames = map[string]string{
+	"TIT2": "TITLE", "TT2": "TITLE",
+	"TPE1": "ARTIST", "TP1": "ARTIST",
+	"TALB": "ALBUM", "TAL": "ALBUM",
+	"TLEN": "LENGTH", "TLE": "LENGTH",
+}
+
+// parseMP3 reads the ID3v2 (or ID3v1) tag of an MP3 file. If the tag has no length, the duration
+// is taken from the Xing header of the first frame or estimated from its bitrate.
+func parseMP3(r io.ReaderAt, size int64) (*media.AudioDescriptor, error) {
+	var tags audioTags
+	var duration time.Duration
+	var audioStart int64
+
+	hdr, err := readAt(r, 0, id3HeaderSize)
+	if err == nil && string(hdr[:3]) == "ID3" {
+		audioStart = id3HeaderSize + int64(synchsafe(hdr[6:]))
+		if hdr[5]&0x10 != 0 {
+			audioStart += id3HeaderSize // footer
+		}
+
+		length := parseID3v2(r, hdr[3], audioStart, &tags)
+		if length > 0 {
+			duration = length
+		}
+	}
+
+	audioEnd := size
+	if tail, err := readAt(r, size-id3v1Size, id3v1Size); err == nil && string(tail[:3]) == "TAG" {
+		audioEnd -= id3v1Size
+		if len(tags.title)+len(tags.artist)+len(tags.album) == 0 {
+			tags.add("TITLE", latin1(tail[3:33]))
+			tags.add("ARTIST", latin1(tail[33:63]))
+			tags.add("ALBUM", latin1(tail[63:93]))
+		}
+	}
+
+	if duration == 0 {
+		duration = mpegDuration(r, audioStart, audioEnd)
+	}
+
+	return tags.descriptor("mp3", duration), nil
+}
+
+// parseID3v2 reads the text frames of an ID3v2 tag that ends at end. Returns the length of the
+// audio from the TLEN frame, if any.
+func parseID3v2(r io.ReaderAt, version byte, end int64, tags *audioTags) (length time.Duration) {
+	frameHeader, idLen := 10, 4
+	if version == 2 {
+		frameHeader, idLen = 6, 3
+	}
+
+	off := int64(id3HeaderSize)
+	for i := 0; i < maxID3Frames && off+int64(frameHeader) <= end; i++ {
+		hdr, err := readAt(r, off, frameHeader)
+		if err != nil || hdr[0] == 0 {
+			return
+		}
+
+		var size int64
+		switch version {
+		case 2:
+			size = int64(hdr[3])<<16 | int64(hdr[4])<<8 | int64(hdr[5])
+		case 3:
+			size = int64(binary.BigEndian.Uint32(hdr[4:]))
+		default:
+			size = int64(synchsafe(hdr[4:]))
+		}
+
+		off += int64(frameHeader)
+
+		key, found := id3Frames[string(hdr[:idLen])]
+		if found && size > 1 && size <= maxTagText {
+			data, err := readAt(r, off, int(size))
+			if err == nil {
+				for _, value := range id3Text(data) {
+					if key == "LENGTH" {
+						if ms, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64); err == nil {
+							length = time.Duration(ms) * time.Millisecond
+						}
+						continue
+					}
+					tags.add(key, value)
+				}
+			}
+		}
+
+		off += size
+	}
+
+	return
+}
+
+// id3Text decodes the values of an ID3 text frame.
+func id3Text(data []byte) []string {
+	enc, data := data[0], data[1:]
+
+	var text string
+	switch enc {
+	case 0:
+		text = latin1(data)
+	case 1, 2:
+		text = utf16String(data, enc == 2)
+	default:
+		text = string(data)
+	}
+
+	return strings.Split(strings.TrimRight(text, "\x00"), "\x00")
+}
+
+func latin1(data []byte) string {
+	runes := make([]rune, len(data))
+	for i, b := range data {
+		runes[i] = rune(b)
+	}
+	return strings.TrimRight(string(runes), "\x00 ")
+}
+
+// utf16String decodes UTF-16 text with a byte order mark, or big endian text if bigEndian is set.
+func utf16String(data []byte, bigEndian bool) string {
+	var order binary.ByteOrder = binary.LittleEndian
+	if bigEndian {
+		order = binary.BigEndian
+	}
+
+	var units []uint16
+	for i := 0; i+1 < len(data); i += 2 {
+		u := order.Uint16(data[i:])
+		switch u {
+		case 0xfeff:
+			continue
+		case 0xfffe:
+			// byte order mark read in the wrong order
+			if order == binary.LittleEndian {
+				order = binary.BigEndian
+			} else {
+				order = binary.LittleEndian
+			}
+			continue
+		}
+		units = append(units, u)
+	}
+
+	return string(utf16.Decode(units))
+}
+
+func synchsafe(b []byte) uint32 {
+	return uint32(b[0]&0x7f)<<21 | uint32(b[1]&0x7f)<<14 | uint32(b[2]&0x7f)<<7 | uint32(b[3]&0x7f)
+}
+
+type mpegHeader struct {
+	mpeg1   bool
+	mono    bool
+	bitrate int // kbps
+	rate    int
+}
+
+// samples returns the number of samples in a frame.
+func (h mpegHeader) samples() int {
+	if h.mpeg1 {
+		return 1152
+	}
+	return 576
+}
+
+// xingOffset returns the offset of the Xing header in the frame.
+func (h mpegHeader) xingOffset() int64 {
+	switch {
+	case h.mpeg1 && !h.mono:
+		return 36
+	case h.mpeg1 || !h.mono:
+		return 21
+	default:
+		return 13
+	}
+}
+
+// parseMPEGHeader parses the header of an MPEG audio layer III frame.
+func parseMPEGHeader(b []byte) (h mpegHeader, ok bool) {
+	if len(b) < 4 || b[0] != 0xff || b[1]&0xe0 != 0xe0 {
+		return
+	}
+
+	version := (b[1] >> 3) & 3 // 0: 2.5, 2: 2, 3: 1
+	layer := (b[1] >> 1) & 3   // 1: layer III
+	if version == 1 || layer != 1 {
+		return
+	}
+
+	h.mpeg1 = version == 3
+	h.mono = b[3]>>6 == 3
+
+	if h.mpeg1 {
+		h.bitrate = mpeg1Bitrates[b[2]>>4]
+	} else {
+		h.bitrate = mpeg2Bitrates[b[2]>>4]
+	}
+
+	h.rate = mpegRates[(b[2]>>2)&3]
+	switch version {
+	case 2:
+		h.rate /= 2
+	case 0:
+		h.rate /= 4
+	}
+
+	return h, h.bitrate > 0 && h.rate > 0
+}
+
+// mpegDuration finds the first frame of the audio and computes the duration from its Xing header,
+// or estimates it from the bitrate assuming a constant bitrate.
+func mpegDuration(r io.ReaderAt, start, end int64) time.Duration {
+	// the tag size comes from the header and can point past the end of the file
+	if end <= start {
+		return 0
+	}
+
+	buf, err := readAt(r, start, int(min(mpegSearchSize, end-start)))
+	if err != nil {
+		return 0
+	}
+
+	for i := 0; i+4 <= len(buf); i++ {
+		h, ok := parseMPEGHeader(buf[i:])
+		if !ok {
+			continue
+		}
+
+		x := int64(i) + h.xingOffset()
+		if x+12 <= int64(len(buf)) {
+			tag := string(buf[x : x+4])
+			flags := binary.BigEndian.Uint32(buf[x+4:])
+			if (tag == "Xing" || tag == "Info") && flags&1 != 0 {
+				frames := uint64(binary.BigEndian.Uint32(buf[x+8:]))
+				return sampleDuration(frames*uint64(h.samples()), uint64(h.rate))
+			}
+		}
+
+		bytes := end - start - int64(i)
+		return sampleDuration(uint64(bytes)*8, uint64(h.bitrate)*1000)
+	}
+
+	return 0
+}
@@ -0,0 +1,139 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"time"
+
+	"github.com/cryptopunkscc/astrald/mod/media"
+)
+
+const (
+	oggHeaderSize = 27
+	maxOggHeaders = 1 << 20 // maximum bytes of header packets read
+	oggTailSize   = 64 << 10
+	opusRate      = 48000
+)
+
+// parseOgg reads the identification and comment headers of the first stream of an Ogg file and
+// the granule position of its last page, which gives the duration.
+func parseOgg(r io.ReaderAt, size int64) (*media.AudioDescriptor, error) {
+	packets, serial, err := oggPackets(r, size, 2)
+	if err != nil || len(packets) < 2 {
+		return nil, media.ErrUnsupported
+	}
+
+	var tags audioTags
+	var format string
+	var rate, preSkip uint64
+
+	id, comments := packets[0], packets[1]
+	switch {
+	case bytes.HasPrefix(id, []byte("\x01vorbis")) && len(id) >= 16:
+		format = "vorbis"
+		rate = uint64(binary.LittleEndian.Uint32(id[12:]))
+		if bytes.HasPrefix(comments, []byte("\x03vorbis")) {
+			parseVorbisComments(comments[7:], &tags)
+		}
+
+	case bytes.HasPrefix(id, []byte("OpusHead")) && len(id) >= 12:
+		format = "opus"
+		rate = opusRate
+		preSkip = uint64(binary.LittleEndian.Uint16(id[10:]))
+		if bytes.HasPrefix(comments, []byte("OpusTags")) {
+			parseVorbisComments(comments[8:], &tags)
+		}
+
+	default:
+		return nil, media.ErrUnsupported
+	}
+
+	var duration time.Duration
+	if granule := oggLastGranule(r, size, serial); granule > preSkip && rate > 0 {
+		duration = sampleDuration(granule-preSkip, rate)
+	}
+
+	return tags.descriptor(format, duration), nil
+}
+
+// oggPackets returns the first n packets of the first logical stream of an Ogg file.
+func oggPackets(r io.ReaderAt, size int64, n int) (packets [][]byte, serial uint32, err error) {
+	var off int64
+	var packet []byte
+	var total int
+	var first = true
+
+	for len(packets) < n && off+oggHeaderSize <= size && total < maxOggHeaders {
+		hdr, err := readAt(r, off, oggHeaderSize)
+		if err != nil || string(hdr[:4]) != "OggS" {
+			return packets, serial, media.ErrUnsupported
+		}
+
+		pageSerial := binary.LittleEndian.Uint32(hdr[14:])
+		if first {
+			serial, first = pageSerial, false
+		}
+
+		segments, err := readAt(r, off+oggHeaderSize, int(hdr[26]))
+		if err != nil {
+			return packets, serial, err
+		}
+
+		var bodySize int
+		for _, s := range segments {
+			bodySize += int(s)
+		}
+
+		body, err := readAt(r, off+oggHeaderSize+int64(len(segments)), bodySize)
+		if err != nil {
+			return packets, serial, err
+		}
+		off += oggHeaderSize + int64(len(segments)) + int64(bodySize)
+
+		if pageSerial != serial {
+			continue
+		}
+
+		for _, s := range segments {
+			packet = append(packet, body[:s]...)
+			body = body[s:]
+			total += int(s)
+
+			// a segment shorter than 255 bytes ends a packet
+			if s < 255 {
+				packets = append(packets, packet)
+				packet = nil
+				if len(packets) == n {
+					break
+				}
+			}
+		}
+	}
+
+	return packets, serial, nil
+}
+
+// oggLastGranule returns the granule position of the last page of a stream found near the end
+// of the file, or 0 if there is none.
+func oggLastGranule(r io.ReaderAt, size int64, serial uint32) uint64 {
+	start := max(size-oggTailSize, 0)
+
+	tail, err := readAt(r, start, int(size-start))
+	if err != nil {
+		return 0
+	}
+
+	for i := bytes.LastIndex(tail, []byte("OggS")); i >= 0; i = bytes.LastIndex(tail[:i], []byte("OggS")) {
+		if i+oggHeaderSize > len(tail) || binary.LittleEndian.Uint32(tail[i+14:]) != serial {
+			continue
+		}
+
+		granule := binary.LittleEndian.Uint64(tail[i+6:])
+		if granule != ^uint64(0) {
+			return granule
+		}
+	}
+
+	return 0
+}
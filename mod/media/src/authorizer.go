@@ -0,0 +1,32 @@
+package media
+
+import (
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/mod/auth"
+	"github.com/cryptopunkscc/astrald/mod/objects"
+)
+
+// AuthorizeObjectsRead grants read access to a preview to everyone who can read an image it
+// was generated from.
+func (mod *Module) AuthorizeObjectsRead(ctx *astral.Context, action *objects.ReadObjectAction) bool {
+	sources, err := mod.db.ThumbnailSources(action.ObjectID)
+	if err != nil {
+		return false
+	}
+
+	for _, sourceID := range sources {
+		// sanity check
+		if sourceID.IsEqual(action.ObjectID) {
+			continue
+		}
+
+		if mod.Auth.Authorize(ctx, &objects.ReadObjectAction{
+			Action:   auth.NewAction(action.Actor()),
+			ObjectID: sourceID,
+		}) {
+			return true
+		}
+	}
+
+	return false
+}
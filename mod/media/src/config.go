@@ -0,0 +1,10 @@
+package media
+
+type Config struct {
+	// Repos are the repositories whose objects are indexed in the background
+	Repos []string
+}
+
+var defaultConfig = Config{
+	Repos: []string{"local"},
+}
@@ -0,0 +1,131 @@
+package media
+
+import (
+	"time"
+
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/mod/media"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type DB struct {
+	*gorm.DB
+}
+
+// dbMedia records that an object was indexed. Kind is "image", "audio" or empty if the object
+// is not a supported media file.
+type dbMedia struct {
+	ObjectID *astral.ObjectID `gorm:"primaryKey"`
+	Kind     string
+}
+
+func (dbMedia) TableName() string { return media.DBPrefix + "objects" }
+
+type dbImage struct {
+	ObjectID    *astral.ObjectID `gorm:"primaryKey"`
+	Format      string
+	Width       uint32 `gorm:"index"`
+	Height      uint32 `gorm:"index"`
+	Orientation uint8
+	TakenAt     time.Time
+}
+
+func (dbImage) TableName() string { return media.DBPrefix + "images" }
+
+type dbAudio struct {
+	ObjectID *astral.ObjectID `gorm:"primaryKey"`
+	Format   string
+	Title    string
+	Artist   string
+	Album    string
+	Duration time.Duration
+}
+
+func (dbAudio) TableName() string { return media.DBPrefix + "audio" }
+
+// FindMedia returns the stored descriptor of an object. Indexed is false if the object was not
+// indexed yet; desc is nil if it is not a media file.
+func (db *DB) FindMedia(objectID *astral.ObjectID) (desc astral.Object, indexed bool, err error) {
+	var row dbMedia
+	err = db.Where("object_id = ?", objectID).Limit(1).Find(&row).Error
+	if err != nil || row.ObjectID == nil {
+		return
+	}
+	indexed = true
+
+	switch row.Kind {
+	case kindImage:
+		var img dbImage
+		err = db.Where("object_id = ?", objectID).First(&img).Error
+		if err == nil {
+			desc = img.descriptor()
+		}
+	case kindAudio:
+		var audio dbAudio
+		err = db.Where("object_id = ?", objectID).First(&audio).Error
+		if err == nil {
+			desc = audio.descriptor()
+		}
+	}
+
+	return
+}
+
+// SaveMedia stores the descriptor of an object, or marks it as not a media file if desc is nil.
+func (db *DB) SaveMedia(objectID *astral.ObjectID, desc astral.Object) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		row := dbMedia{ObjectID: objectID}
+
+		var detail any
+		switch d := desc.(type) {
+		case *media.ImageDescriptor:
+			row.Kind = kindImage
+			detail = &dbImage{
+				ObjectID:    objectID,
+				Format:      string(d.Format),
+				Width:       uint32(d.Width),
+				Height:      uint32(d.Height),
+				Orientation: uint8(d.Orientation),
+				TakenAt:     d.TakenAt.Time(),
+			}
+		case *media.AudioDescriptor:
+			row.Kind = kindAudio
+			detail = &dbAudio{
+				ObjectID: objectID,
+				Format:   string(d.Format),
+				Title:    string(d.Title),
+				Artist:   string(d.Artist),
+				Album:    string(d.Album),
+				Duration: time.Duration(d.Duration),
+			}
+		}
+
+		err := tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(&row).Error
+		if err != nil || detail == nil {
+			return err
+		}
+
+		return tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(detail).Error
+	})
+}
+
+func (row *dbImage) descriptor() *media.ImageDescriptor {
+	return &media.ImageDescriptor{
+		Format:      astral.String8(row.Format),
+		Width:       astral.Uint32(row.Width),
+		Height:      astral.Uint32(row.Height),
+		Orientation: astral.Uint8(row.Orientation),
+		TakenAt:     astral.Time(row.TakenAt),
+	}
+}
+
+func (row *dbAudio) descriptor() *media.AudioDescriptor {
+	return &media.AudioDescriptor{
+		Format:   astral.String8(row.Format),
+		Title:    astral.String16(row.Title),
+		Artist:   astral.String16(row.Artist),
+		Album:    astral.String16(row.Album),
+		Duration: astral.Duration(row.Duration),
+	}
+}
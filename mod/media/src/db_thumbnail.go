@@ -0,0 +1,38 @@
+package media
+
+import (
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/mod/media"
+	"gorm.io/gorm/clause"
+)
+
+// dbThumbnail links an image to a preview generated from it.
+type dbThumbnail struct {
+	ObjectID    *astral.ObjectID `gorm:"primaryKey"`
+	Size        int              `gorm:"primaryKey"`
+	ThumbnailID *astral.ObjectID `gorm:"index"`
+}
+
+func (dbThumbnail) TableName() string { return media.DBPrefix + "thumbnails" }
+
+// FindThumbnail returns the ID of the preview of an image at a size or nil if there's none.
+func (db *DB) FindThumbnail(objectID *astral.ObjectID, size int) (*astral.ObjectID, error) {
+	var row dbThumbnail
+	err := db.Where("object_id = ? AND size = ?", objectID, size).Limit(1).Find(&row).Error
+	return row.ThumbnailID, err
+}
+
+// SaveThumbnail links a preview to the image it was generated from.
+func (db *DB) SaveThumbnail(objectID *astral.ObjectID, size int, thumbnailID *astral.ObjectID) error {
+	return db.Clauses(clause.OnConflict{UpdateAll: true}).Create(&dbThumbnail{
+		ObjectID:    objectID,
+		Size:        size,
+		ThumbnailID: thumbnailID,
+	}).Error
+}
+
+// ThumbnailSources returns the IDs of the images a preview was generated from.
+func (db *DB) ThumbnailSources(thumbnailID *astral.ObjectID) (ids []*astral.ObjectID, err error) {
+	err = db.Model(&dbThumbnail{}).Where("thumbnail_id = ?", thumbnailID).Pluck("object_id", &ids).Error
+	return
+}
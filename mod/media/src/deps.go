@@ -0,0 +1,21 @@
+package media
+
+import (
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/core"
+	"github.com/cryptopunkscc/astrald/mod/auth"
+	"github.com/cryptopunkscc/astrald/mod/objects"
+)
+
+type Deps struct {
+	Auth    auth.Module
+	Objects objects.Module
+}
+
+func (mod *Module) LoadDependencies(*astral.Context) (err error) {
+	if err = core.Inject(mod.node, &mod.Deps); err != nil {
+		return
+	}
+	mod.Auth.Add(auth.Func[*objects.ReadObjectAction](mod.AuthorizeObjectsRead))
+	return
+}
@@ -0,0 +1,101 @@
+package media
+
+import (
+	"encoding/binary"
+	"time"
+)
+
+const (
+	exifTagOrientation      = 0x0112
+	exifTagDateTime         = 0x0132
+	exifTagExifIFD          = 0x8769
+	exifTagDateTimeOriginal = 0x9003
+	exifTypeASCII           = 2
+	exifTypeShort           = 3
+	exifTimeLayout          = "2006:01:02 15:04:05"
+)
+
+// parseEXIF reads the orientation and the capture time from a TIFF structure of EXIF data.
+// The original capture time is preferred over the modification time. Missing or malformed
+// values are returned as zero values.
+func parseEXIF(tiff []byte) (orientation uint8, takenAt time.Time) {
+	if len(tiff) < 8 {
+		return
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return
+	}
+
+	var modified, original time.Time
+
+	var exifIFD uint32
+	walkIFD(tiff, order, order.Uint32(tiff[4:]), func(tag, typ uint16, count, value uint32, entry []byte) {
+		switch tag {
+		case exifTagOrientation:
+			if typ == exifTypeShort && count == 1 {
+				orientation = uint8(order.Uint16(entry))
+			}
+		case exifTagDateTime:
+			modified = exifTime(tiff, typ, count, value)
+		case exifTagExifIFD:
+			exifIFD = value
+		}
+	})
+
+	if exifIFD != 0 {
+		walkIFD(tiff, order, exifIFD, func(tag, typ uint16, count, value uint32, _ []byte) {
+			if tag == exifTagDateTimeOriginal {
+				original = exifTime(tiff, typ, count, value)
+			}
+		})
+	}
+
+	if orientation > 8 {
+		orientation = 0
+	}
+
+	takenAt = original
+	if takenAt.IsZero() {
+		takenAt = modified
+	}
+
+	return
+}
+
+// walkIFD calls fn for every entry of the image file directory at offset.
+func walkIFD(tiff []byte, order binary.ByteOrder, offset uint32, fn func(tag, typ uint16, count, value uint32, entry []byte)) {
+	if int(offset)+2 > len(tiff) {
+		return
+	}
+
+	n := int(order.Uint16(tiff[offset:]))
+	for i := 0; i < n; i++ {
+		p := int(offset) + 2 + i*12
+		if p+12 > len(tiff) {
+			return
+		}
+
+		e := tiff[p : p+12]
+		fn(order.Uint16(e[0:]), order.Uint16(e[2:]), order.Uint32(e[4:]), order.Uint32(e[8:]), e[8:])
+	}
+}
+
+// exifTime parses an ASCII date stored out of line at offset.
+func exifTime(tiff []byte, typ uint16, count, offset uint32) time.Time {
+	if typ != exifTypeASCII || count < 19 || int(offset)+19 > len(tiff) {
+		return time.Time{}
+	}
+
+	t, err := time.Parse(exifTimeLayout, string(tiff[offset:offset+19]))
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
@@ -0,0 +1,78 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/mod/media"
+)
+
+const maxJPEGSegments = 64 // number of JPEG segments searched for EXIF data
+
+// parseImage decodes the header of a GIF, JPEG or PNG image.
+func parseImage(r io.ReaderAt, size int64) (*media.ImageDescriptor, error) {
+	cfg, format, err := image.DecodeConfig(io.NewSectionReader(r, 0, size))
+	if err != nil {
+		return nil, media.ErrUnsupported
+	}
+
+	desc := &media.ImageDescriptor{
+		Format: astral.String8(format),
+		Width:  astral.Uint32(cfg.Width),
+		Height: astral.Uint32(cfg.Height),
+	}
+
+	if format == "jpeg" {
+		if tiff, err := jpegEXIF(r, size); err == nil {
+			orientation, takenAt := parseEXIF(tiff)
+			desc.Orientation = astral.Uint8(orientation)
+			desc.TakenAt = astral.Time(takenAt)
+		}
+	}
+
+	return desc, nil
+}
+
+// jpegEXIF returns the TIFF structure of the EXIF segment (APP1) of a JPEG image.
+func jpegEXIF(r io.ReaderAt, size int64) ([]byte, error) {
+	var off int64 = 2 // skip SOI
+	var hdr [4]byte
+
+	for i := 0; i < maxJPEGSegments && off+4 <= size; i++ {
+		if _, err := r.ReadAt(hdr[:], off); err != nil {
+			return nil, err
+		}
+		if hdr[0] != 0xff {
+			break
+		}
+
+		marker := hdr[1]
+		length := int64(binary.BigEndian.Uint16(hdr[2:]))
+
+		// the image data follows the start of scan segment
+		if marker == 0xda {
+			break
+		}
+
+		if marker == 0xe1 && length > 8 {
+			data := make([]byte, length-2)
+			if _, err := r.ReadAt(data, off+4); err != nil {
+				return nil, err
+			}
+			if bytes.HasPrefix(data, []byte("Exif\x00\x00")) {
+				return data[6:], nil
+			}
+		}
+
+		off += 2 + length
+	}
+
+	return nil, errors.New("no exif data")
+}
@@ -0,0 +1,122 @@
+package media
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/mod/media"
+	"github.com/cryptopunkscc/astrald/mod/objects"
+)
+
+const (
+	kindImage = "image"
+	kindAudio = "audio"
+
+	readTimeout = 15 * time.Second
+)
+
+// Index decodes the metadata of an object read from the default repository and stores it.
+// Objects are decoded once; later calls return the stored result.
+func (mod *Module) Index(ctx *astral.Context, objectID *astral.ObjectID) (astral.Object, error) {
+	desc, indexed, err := mod.db.FindMedia(objectID)
+	switch {
+	case err != nil:
+		return nil, err
+	case indexed && desc == nil:
+		return nil, media.ErrUnsupported
+	case indexed:
+		return desc, nil
+	}
+
+	desc, err = decode(&readerAt{ctx: ctx, repo: mod.Objects.ReadDefault(), objectID: objectID}, int64(objectID.Size))
+	switch {
+	case errors.Is(err, media.ErrUnsupported):
+		desc = nil
+	case err != nil:
+		return nil, err
+	}
+
+	err = mod.db.SaveMedia(objectID, desc)
+	if err != nil {
+		return nil, err
+	}
+
+	if desc == nil {
+		return nil, media.ErrUnsupported
+	}
+
+	mod.log.Logv(2, "indexed %v %v", desc.ObjectType(), objectID)
+
+	return desc, nil
+}
+
+// decode returns the descriptor of an image or an audio file.
+func decode(r io.ReaderAt, size int64) (astral.Object, error) {
+	if img, err := parseImage(r, size); err == nil {
+		return img, nil
+	}
+
+	audio, err := parseAudio(r, size)
+	if err != nil {
+		return nil, err
+	}
+
+	return audio, nil
+}
+
+// followRepo indexes every object of a repository and then every object added to it.
+func (mod *Module) followRepo(ctx *astral.Context, name string) error {
+	repo := mod.Objects.GetRepository(name)
+	if repo == nil {
+		return fmt.Errorf("repository %s not found", name)
+	}
+
+	scan, err := repo.Scan(ctx, true)
+	if err != nil {
+		return err
+	}
+
+	for id := range scan {
+		if id == nil {
+			continue
+		}
+
+		_, err := mod.Index(ctx, id)
+		if err != nil && !errors.Is(err, media.ErrUnsupported) && ctx.Err() == nil {
+			mod.log.Errorv(2, "index %v: %v: %v", name, id, err)
+		}
+	}
+
+	return ctx.Err()
+}
+
+// readerAt reads an object through a repository, opening a new reader for every call.
+type readerAt struct {
+	ctx      *astral.Context
+	repo     objects.Repository
+	objectID *astral.ObjectID
+}
+
+func (r *readerAt) ReadAt(p []byte, off int64) (n int, err error) {
+	if off >= int64(r.objectID.Size) {
+		return 0, io.EOF
+	}
+
+	ctx, cancel := r.ctx.WithTimeout(readTimeout)
+	defer cancel()
+
+	f, err := r.repo.Read(ctx, r.objectID, off, min(int64(len(p)), int64(r.objectID.Size)-off))
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	n, err = io.ReadFull(f, p)
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		err = io.EOF
+	}
+	return
+}
@@ -0,0 +1,39 @@
+package media
+
+import (
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/astral/log"
+	"github.com/cryptopunkscc/astrald/core"
+	"github.com/cryptopunkscc/astrald/core/assets"
+	"github.com/cryptopunkscc/astrald/mod/media"
+)
+
+type Loader struct{}
+
+func (Loader) Load(node astral.Node, assets assets.Assets, log *log.Logger) (core.Module, error) {
+	var err error
+	var mod = &Module{
+		node:   node,
+		config: defaultConfig,
+		log:    log,
+	}
+
+	_ = assets.LoadYAML(media.ModuleName, &mod.config)
+
+	mod.router.AddStructPrefix(mod, "Op")
+
+	mod.db = &DB{DB: assets.Database()}
+
+	err = mod.db.AutoMigrate(&dbMedia{}, &dbImage{}, &dbAudio{}, &dbThumbnail{})
+	if err != nil {
+		return nil, err
+	}
+
+	return mod, err
+}
+
+func init() {
+	if err := core.RegisterModule(media.ModuleName, Loader{}); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,222 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"testing"
+	"time"
+
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/mod/media"
+	"github.com/cryptopunkscc/astrald/mod/objects"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+// testJPEG encodes a w×h image with an EXIF segment holding the orientation and DateTimeOriginal.
+func testJPEG(t *testing.T, w, h int, orientation uint16, taken string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, w, h)), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// TIFF: header, IFD0 (orientation, exif pointer), exif IFD (date), date string
+	le := binary.LittleEndian
+	entry := func(tag, typ uint16, count, value uint32) []byte {
+		return le.AppendUint32(le.AppendUint32(le.AppendUint16(le.AppendUint16(nil, tag), typ), count), value)
+	}
+
+	tiff := le.AppendUint32([]byte("II*\x00"), 8)
+	tiff = le.AppendUint16(tiff, 2)
+	tiff = append(tiff, entry(exifTagOrientation, exifTypeShort, 1, uint32(orientation))...)
+	tiff = append(tiff, entry(exifTagExifIFD, 4, 1, 38)...)
+	tiff = le.AppendUint32(tiff, 0)
+	tiff = le.AppendUint16(tiff, 1)
+	tiff = append(tiff, entry(exifTagDateTimeOriginal, exifTypeASCII, 20, 56)...)
+	tiff = le.AppendUint32(tiff, 0)
+	tiff = append(tiff, taken+"\x00"...)
+
+	app1 := append([]byte("Exif\x00\x00"), tiff...)
+	segment := binary.BigEndian.AppendUint16([]byte{0xff, 0xe1}, uint16(len(app1)+2))
+
+	data := buf.Bytes()
+	return append(append(append([]byte{}, data[:2]...), append(segment, app1...)...), data[2:]...)
+}
+
+// testMP3 builds an ID3v2.3 tag followed by constant bitrate frames of one second of audio.
+func testMP3(title, artist string) []byte {
+	frame := func(id, text string) []byte {
+		body := append([]byte{3}, text...)
+		f := binary.BigEndian.AppendUint32([]byte(id), uint32(len(body)))
+		return append(append(f, 0, 0), body...)
+	}
+
+	frames := append(frame("TIT2", title), frame("TPE1", artist)...)
+	tag := []byte{'I', 'D', '3', 3, 0, 0,
+		byte(len(frames) >> 21 & 0x7f), byte(len(frames) >> 14 & 0x7f), byte(len(frames) >> 7 & 0x7f), byte(len(frames) & 0x7f)}
+	tag = append(tag, frames...)
+
+	// MPEG1 layer III, 128 kbps, 44.1 kHz: 16000 bytes of audio per second
+	audio := make([]byte, 16000)
+	copy(audio, []byte{0xff, 0xfb, 0x90, 0x00})
+
+	return append(tag, audio...)
+}
+
+func TestDecode_Image(t *testing.T) {
+	data := testJPEG(t, 640, 480, 6, "2024:05:01 12:30:00")
+
+	desc, err := decode(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	img, ok := desc.(*media.ImageDescriptor)
+	if !ok {
+		t.Fatalf("got %T, want an image descriptor", desc)
+	}
+	if img.Format != "jpeg" || img.Width != 640 || img.Height != 480 || img.Orientation != 6 {
+		t.Fatalf("got %+v", img)
+	}
+	if want := time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC); !img.TakenAt.Time().Equal(want) {
+		t.Fatalf("got taken at %v, want %v", img.TakenAt.Time(), want)
+	}
+}
+
+func TestDecode_MP3(t *testing.T) {
+	data := testMP3("Song", "Band")
+
+	desc, err := decode(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	audio, ok := desc.(*media.AudioDescriptor)
+	if !ok {
+		t.Fatalf("got %T, want an audio descriptor", desc)
+	}
+	if audio.Title != "Song" || audio.Artist != "Band" || audio.Format != "mp3" {
+		t.Fatalf("got %+v", audio)
+	}
+	if d := time.Duration(audio.Duration); d != time.Second {
+		t.Fatalf("got duration %v, want 1s", d)
+	}
+
+	if _, err := decode(bytes.NewReader([]byte("plain text")), 10); err != media.ErrUnsupported {
+		t.Fatalf("got %v, want ErrUnsupported", err)
+	}
+}
+
+// A truncated file whose ID3 header claims a tag larger than the file decodes without a panic.
+func TestDecode_TruncatedMP3(t *testing.T) {
+	data := make([]byte, 64)
+	copy(data, "ID3\x03\x00\x00\x00\x40\x00\x00") // 1 MiB tag
+
+	_, _ = decode(bytes.NewReader(data), int64(len(data)))
+
+	if d := sampleDuration(1<<36-1, 1); d <= 0 {
+		t.Fatalf("duration overflowed: %v", d)
+	}
+}
+
+func TestSearch_Tags(t *testing.T) {
+	gdb, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := &DB{DB: gdb}
+	if err := db.AutoMigrate(&dbMedia{}, &dbImage{}, &dbAudio{}, &dbThumbnail{}); err != nil {
+		t.Fatal(err)
+	}
+
+	big, small, song, preview := astral.ObjectID{Size: 1}, astral.ObjectID{Size: 2}, astral.ObjectID{Size: 3}, astral.ObjectID{Size: 4}
+	for id, desc := range map[*astral.ObjectID]astral.Object{
+		&big:     &media.ImageDescriptor{Format: "png", Width: 1920, Height: 1080},
+		&preview: &media.ImageDescriptor{Format: "jpeg", Width: 256, Height: 144},
+		&small:   &media.ImageDescriptor{Format: "jpeg", Width: 640, Height: 480},
+		&song:    &media.AudioDescriptor{Format: "mp3", Title: "Song", Artist: "The Band", Duration: astral.Duration(4 * time.Minute)},
+	} {
+		if err := db.SaveMedia(id, desc); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.SaveThumbnail(&big, 256, &preview); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		query string
+		want  []*astral.ObjectID
+	}{
+		{"type:image width:>1000", []*astral.ObjectID{&big}},
+		{"type:image -format:png", []*astral.ObjectID{&small}},
+		{"artist:band duration:>=240", []*astral.ObjectID{&song}},
+		{"band", []*astral.ObjectID{&song}},
+		{"width:>1000 artist:band", nil},
+		{"", nil},
+	}
+
+	for _, test := range tests {
+		var query objects.SearchQuery
+		if err := query.UnmarshalText([]byte(test.query)); err != nil {
+			t.Fatal(err)
+		}
+
+		s, err := parseSearch(query)
+		if err != nil {
+			t.Fatalf("%q: %v", test.query, err)
+		}
+
+		ids, err := db.Search(s, searchLimit)
+		if err != nil {
+			t.Fatalf("%q: %v", test.query, err)
+		}
+
+		if len(ids) != len(test.want) {
+			t.Fatalf("%q: got %v results, want %v", test.query, len(ids), len(test.want))
+		}
+		for i := range ids {
+			if !ids[i].IsEqual(test.want[i]) {
+				t.Fatalf("%q: got %v, want %v", test.query, ids[i], test.want[i])
+			}
+		}
+	}
+}
+
+// A rotated photo is scaled to fit the requested size and turned upright.
+func TestRenderThumbnail(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 640, 320))
+	draw.Draw(src, image.Rect(0, 0, 320, 320), image.NewUniform(color.RGBA{R: 255, A: 255}), image.Point{}, draw.Src)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, src); err != nil {
+		t.Fatal(err)
+	}
+
+	// orientation 6 is stored rotated 90° counter-clockwise, the red left half ends up on top
+	data, err := renderThumbnail(&buf, 64, 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	thumb, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if format != "png" || thumb.Bounds().Dx() != 32 || thumb.Bounds().Dy() != 64 {
+		t.Fatalf("got %v %v", format, thumb.Bounds())
+	}
+	if r, _, _, _ := thumb.At(16, 8).RGBA(); r>>8 != 255 {
+		t.Fatalf("top is not red")
+	}
+	if r, _, _, _ := thumb.At(16, 56).RGBA(); r != 0 {
+		t.Fatalf("bottom is not black")
+	}
+}
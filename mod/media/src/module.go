@@ -0,0 +1,49 @@
+package media
+
+import (
+	"sync"
+
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/astral/log"
+	"github.com/cryptopunkscc/astrald/lib/routing"
+	"github.com/cryptopunkscc/astrald/mod/media"
+)
+
+var _ media.Module = &Module{}
+
+type Module struct {
+	Deps
+	config Config
+	node   astral.Node
+	log    *log.Logger
+	db     *DB
+	router routing.OpRouter
+
+	thumbnailMu sync.Mutex
+}
+
+// Run indexes the configured repositories until ctx is canceled.
+func (mod *Module) Run(ctx *astral.Context) error {
+	ctx = ctx.WithZone(astral.ZoneDevice | astral.ZoneVirtual)
+
+	for _, name := range mod.config.Repos {
+		go func() {
+			err := mod.followRepo(ctx, name)
+			if err != nil && ctx.Err() == nil {
+				mod.log.Errorv(1, "index %v: %v", name, err)
+			}
+		}()
+	}
+
+	<-ctx.Done()
+
+	return nil
+}
+
+func (mod *Module) Router() astral.Router {
+	return &mod.router
+}
+
+func (mod *Module) String() string {
+	return media.ModuleName
+}
@@ -0,0 +1,32 @@
+package media
+
+import (
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/mod/objects"
+)
+
+var _ objects.Describer = &Module{}
+
+// DescribeObject returns an image or audio descriptor of an object held on the device, decoding
+// it first if it was not indexed yet. Only serves ZoneDevice.
+func (mod *Module) DescribeObject(ctx *astral.Context, objectID *astral.ObjectID) (<-chan *objects.Descriptor, error) {
+	if !ctx.Zone().Is(astral.ZoneDevice) {
+		return nil, astral.ErrZoneExcluded
+	}
+
+	desc, err := mod.Index(ctx.WithZone(astral.ZoneDevice|astral.ZoneVirtual), objectID)
+	if err != nil {
+		return nil, err
+	}
+
+	var results = make(chan *objects.Descriptor, 1)
+	defer close(results)
+
+	results <- &objects.Descriptor{
+		SourceID: mod.node.Identity(),
+		ObjectID: objectID,
+		Data:     desc,
+	}
+
+	return results, nil
+}
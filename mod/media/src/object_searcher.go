@@ -0,0 +1,57 @@
+package media
+
+import (
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/mod/objects"
+)
+
+var _ objects.Searcher = &Module{}
+
+// SearchObject searches the indexed images and audio files. The query text matches the title,
+// artist or album of audio files; tags narrow the results down:
+//
+//	type:image, type:audio
+//	format:png, format:mp3
+//	width:>1000, height:<=720, orientation:6
+//	artist:..., title:..., album:..., duration:>300 (seconds)
+//
+// Only serves ZoneDevice.
+func (mod *Module) SearchObject(ctx *astral.Context, query objects.SearchQuery) (<-chan *objects.SearchResult, error) {
+	if !ctx.Zone().Is(astral.ZoneDevice) {
+		return nil, astral.ErrZoneExcluded
+	}
+
+	err := query.RequiredTagsIn(searchTags...)
+	if err != nil {
+		return nil, err
+	}
+
+	q, err := parseSearch(query)
+	if err != nil {
+		return nil, err
+	}
+
+	ids, err := mod.db.Search(q, searchLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	var results = make(chan *objects.SearchResult)
+
+	go func() {
+		defer close(results)
+
+		for _, id := range ids {
+			select {
+			case results <- &objects.SearchResult{
+				SourceID: mod.node.Identity(),
+				ObjectID: id,
+			}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return results, nil
+}
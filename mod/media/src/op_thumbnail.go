@@ -0,0 +1,40 @@
+package media
+
+import (
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/astral/channel"
+	"github.com/cryptopunkscc/astrald/lib/routing"
+	"github.com/cryptopunkscc/astrald/mod/auth"
+	"github.com/cryptopunkscc/astrald/mod/objects"
+)
+
+type opThumbnailArgs struct {
+	ID   *astral.ObjectID
+	Size astral.Uint32 `query:"optional"`
+	Zone astral.Zone   `query:"optional"`
+	Out  string        `query:"optional"`
+}
+
+// OpThumbnail sends the ID of a preview of an image the caller can read. The caller can read
+// the preview as well.
+func (mod *Module) OpThumbnail(ctx *astral.Context, q *routing.IncomingQuery, args opThumbnailArgs) (err error) {
+	ctx = ctx.IncludeZone(args.Zone)
+
+	allowed := mod.Auth.Authorize(ctx, &objects.ReadObjectAction{
+		Action:   auth.NewAction(q.Caller()),
+		ObjectID: args.ID,
+	})
+	if !allowed {
+		return q.Reject()
+	}
+
+	ch := channel.New(q.AcceptRaw(), channel.WithOutputFormat(args.Out))
+	defer ch.Close()
+
+	thumbnailID, err := mod.Thumbnail(ctx, args.ID, int(args.Size))
+	if err != nil {
+		return ch.Send(astral.Err(err))
+	}
+
+	return ch.Send(thumbnailID)
+}
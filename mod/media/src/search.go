@@ -0,0 +1,164 @@
+package media
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/mod/objects"
+	"gorm.io/gorm"
+)
+
+const searchLimit = 1000 // maximum number of results of a single search
+
+// searchTags are the tags supported by the searcher
+var searchTags = []string{"type", "format", "width", "height", "orientation", "artist", "title", "album", "duration"}
+
+// columns of tags that apply to a single kind of media
+var (
+	imageColumns = map[string]bool{"width": true, "height": true, "orientation": true}
+	audioColumns = map[string]bool{"artist": true, "title": true, "album": true, "duration": true}
+)
+
+// searchCond is a single condition on a column of the image or audio table.
+type searchCond struct {
+	sql  string
+	args []any
+}
+
+// mediaSearch is a search query translated to conditions on the index.
+type mediaSearch struct {
+	images, audio          bool
+	imageConds, audioConds []searchCond
+}
+
+// parseSearch translates the query text and the required and excluded tags of a query into
+// conditions. Optional tags are ignored.
+func parseSearch(query objects.SearchQuery) (*mediaSearch, error) {
+	s := &mediaSearch{images: true, audio: true}
+
+	text := strings.ToLower(strings.TrimSpace(string(query.Query)))
+	if len(text) > 0 {
+		// images have no text to match
+		s.images = false
+		s.audioConds = append(s.audioConds, searchCond{
+			sql:  "(LOWER(title) LIKE ? OR LOWER(artist) LIKE ? OR LOWER(album) LIKE ?)",
+			args: []any{"%" + text + "%", "%" + text + "%", "%" + text + "%"},
+		})
+	}
+
+	var narrowed bool
+	for _, tag := range query.Tags {
+		if tag.Mod != objects.TagModRequire && tag.Mod != objects.TagModExclude {
+			continue
+		}
+		narrowed = true
+
+		name := strings.ToLower(string(tag.Name))
+		value := strings.ToLower(strings.TrimSpace(string(tag.Value)))
+		exclude := tag.Mod == objects.TagModExclude
+
+		if name == "type" {
+			isImage, isAudio := value == kindImage, value == kindAudio
+			if exclude {
+				s.images = s.images && !isImage
+				s.audio = s.audio && !isAudio
+			} else {
+				s.images = s.images && isImage
+				s.audio = s.audio && isAudio
+			}
+			continue
+		}
+
+		cond, err := tagCond(name, value)
+		if err != nil {
+			return nil, err
+		}
+		if exclude {
+			cond.sql = "NOT (" + cond.sql + ")"
+		}
+
+		switch {
+		case imageColumns[name]:
+			s.audio = false
+			s.imageConds = append(s.imageConds, cond)
+		case audioColumns[name]:
+			s.images = false
+			s.audioConds = append(s.audioConds, cond)
+		default:
+			s.imageConds = append(s.imageConds, cond)
+			s.audioConds = append(s.audioConds, cond)
+		}
+	}
+
+	// an empty query matches nothing rather than the whole index
+	if len(text) == 0 && !narrowed {
+		s.images, s.audio = false, false
+	}
+
+	return s, nil
+}
+
+// tagCond returns the condition of a tag. Numeric tags take a number optionally prefixed with
+// a comparison operator (>, >=, <, <=, =); text tags match a substring, format matches exactly.
+func tagCond(name, value string) (searchCond, error) {
+	switch name {
+	case "format":
+		return searchCond{sql: "format = ?", args: []any{value}}, nil
+
+	case "artist", "title", "album":
+		return searchCond{sql: "LOWER(" + name + ") LIKE ?", args: []any{"%" + value + "%"}}, nil
+	}
+
+	op, num := "=", value
+	for _, o := range []string{">=", "<=", ">", "<", "="} {
+		if strings.HasPrefix(value, o) {
+			op, num = o, strings.TrimSpace(value[len(o):])
+			break
+		}
+	}
+
+	n, err := strconv.ParseFloat(num, 64)
+	if err != nil {
+		return searchCond{}, fmt.Errorf("invalid value of %s: %s", name, value)
+	}
+
+	if name == "duration" {
+		n *= float64(time.Second)
+	}
+
+	return searchCond{sql: name + " " + op + " ?", args: []any{n}}, nil
+}
+
+// Search returns the IDs of indexed objects matching a search, images first.
+func (db *DB) Search(s *mediaSearch, limit int) (ids []*astral.ObjectID, err error) {
+	run := func(tx *gorm.DB, conds []searchCond) error {
+		var found []*astral.ObjectID
+		err := where(tx, conds).Limit(limit-len(ids)).Pluck("object_id", &found).Error
+		ids = append(ids, found...)
+		return err
+	}
+
+	if s.images {
+		// previews are not search results on their own
+		previews := db.Model(&dbThumbnail{}).Select("thumbnail_id")
+		if err = run(db.Model(&dbImage{}).Where("object_id NOT IN (?)", previews), s.imageConds); err != nil {
+			return
+		}
+	}
+
+	if s.audio && len(ids) < limit {
+		err = run(db.Model(&dbAudio{}), s.audioConds)
+	}
+
+	return
+}
+
+func where(tx *gorm.DB, conds []searchCond) *gorm.DB {
+	for _, c := range conds {
+		tx = tx.Where(c.sql, c.args...)
+	}
+	return tx
+}
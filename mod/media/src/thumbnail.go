@@ -0,0 +1,228 @@
+package media
+
+import (
+	"bytes"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"io"
+
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/mod/media"
+	"github.com/cryptopunkscc/astrald/mod/objects"
+)
+
+const (
+	defaultThumbnailSize = 256
+	minThumbnailSize     = 16
+	maxThumbnailSize     = 2048
+	maxThumbnailPixels   = 24 << 20 // larger images are not decoded; an RGBA copy takes 4 bytes per pixel
+	thumbnailQuality     = 85
+)
+
+// Thumbnail returns the ID of a preview of an image that fits in a size x size square. The
+// preview is generated from the default repository on first request, stored in the default
+// write repository and linked to the image, so later calls only look it up. A preview that
+// went missing from the repository is generated again.
+func (mod *Module) Thumbnail(ctx *astral.Context, objectID *astral.ObjectID, size int) (*astral.ObjectID, error) {
+	if size <= 0 {
+		size = defaultThumbnailSize
+	}
+	size = min(max(size, minThumbnailSize), maxThumbnailSize)
+
+	desc, err := mod.Index(ctx, objectID)
+	if err != nil {
+		return nil, err
+	}
+
+	img, ok := desc.(*media.ImageDescriptor)
+	if !ok {
+		return nil, media.ErrUnsupported
+	}
+
+	if int(img.Width) <= size && int(img.Height) <= size {
+		return objectID, nil
+	}
+	if uint64(img.Width)*uint64(img.Height) > maxThumbnailPixels {
+		return nil, media.ErrUnsupported
+	}
+
+	repo := mod.Objects.WriteDefault()
+
+	thumbnailID, err := mod.findThumbnail(ctx, repo, objectID, size)
+	if thumbnailID != nil || err != nil {
+		return thumbnailID, err
+	}
+
+	// why: decoding full-size images is expensive, generate one preview at a time
+	mod.thumbnailMu.Lock()
+	defer mod.thumbnailMu.Unlock()
+
+	// note: another request may have generated the preview while this one waited
+	thumbnailID, err = mod.findThumbnail(ctx, repo, objectID, size)
+	if thumbnailID != nil || err != nil {
+		return thumbnailID, err
+	}
+
+	r, err := mod.Objects.ReadDefault().Read(ctx, objectID, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	data, err := renderThumbnail(r, size, int(img.Orientation))
+	if err != nil {
+		return nil, err
+	}
+
+	w, err := repo.Create(ctx, &objects.CreateOpts{Alloc: len(data)})
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = w.Write(data); err != nil {
+		_ = w.Discard()
+		return nil, err
+	}
+
+	thumbnailID, err = w.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	err = mod.db.SaveThumbnail(objectID, size, thumbnailID)
+	if err != nil {
+		return nil, err
+	}
+
+	mod.log.Logv(2, "generated %vpx thumbnail %v of %v", size, thumbnailID, objectID)
+
+	return thumbnailID, nil
+}
+
+// findThumbnail returns the ID of a stored preview or nil if none was generated or repo lost it.
+func (mod *Module) findThumbnail(ctx *astral.Context, repo objects.Repository, objectID *astral.ObjectID, size int) (*astral.ObjectID, error) {
+	thumbnailID, err := mod.db.FindThumbnail(objectID, size)
+	if thumbnailID == nil || err != nil {
+		return nil, err
+	}
+
+	if has, _ := repo.Contains(ctx, thumbnailID); !has {
+		return nil, nil
+	}
+
+	return thumbnailID, nil
+}
+
+// renderThumbnail decodes an image, scales it down to fit in a size x size square and rotates
+// it according to its EXIF orientation. JPEG images are encoded as JPEG, others as PNG to keep
+// their transparency.
+func renderThumbnail(r io.Reader, size int, orientation int) ([]byte, error) {
+	src, format, err := image.Decode(r)
+	if err != nil {
+		return nil, media.ErrUnsupported
+	}
+
+	rgba := image.NewRGBA(image.Rect(0, 0, src.Bounds().Dx(), src.Bounds().Dy()))
+	draw.Draw(rgba, rgba.Bounds(), src, src.Bounds().Min, draw.Src)
+
+	thumb := orient(downscale(rgba, size), orientation)
+
+	var buf bytes.Buffer
+	if format == "jpeg" {
+		err = jpeg.Encode(&buf, thumb, &jpeg.Options{Quality: thumbnailQuality})
+	} else {
+		err = png.Encode(&buf, thumb)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// downscale returns a copy of an image that fits in a size x size square, averaging the source
+// pixels covered by every target pixel. Images that already fit are returned as they are.
+func downscale(src *image.RGBA, size int) *image.RGBA {
+	w, h := src.Rect.Dx(), src.Rect.Dy()
+	if w <= size && h <= size {
+		return src
+	}
+
+	dw, dh := size, size
+	if w > h {
+		dh = max(1, h*size/w)
+	} else {
+		dw = max(1, w*size/h)
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for dy := 0; dy < dh; dy++ {
+		sy0, sy1 := dy*h/dh, max((dy+1)*h/dh, dy*h/dh+1)
+		for dx := 0; dx < dw; dx++ {
+			sx0, sx1 := dx*w/dw, max((dx+1)*w/dw, dx*w/dw+1)
+
+			var sum [4]int
+			for sy := sy0; sy < sy1; sy++ {
+				row := src.Pix[sy*src.Stride:]
+				for sx := sx0; sx < sx1; sx++ {
+					for c := range sum {
+						sum[c] += int(row[sx*4+c])
+					}
+				}
+			}
+
+			n := (sy1 - sy0) * (sx1 - sx0)
+			off := dst.PixOffset(dx, dy)
+			for c := range sum {
+				dst.Pix[off+c] = uint8(sum[c] / n)
+			}
+		}
+	}
+
+	return dst
+}
+
+// orient transforms an image stored with an EXIF orientation to its upright form.
+func orient(src *image.RGBA, orientation int) *image.RGBA {
+	if orientation < 2 || orientation > 8 {
+		return src
+	}
+
+	w, h := src.Rect.Dx(), src.Rect.Dy()
+
+	// source coordinates of the target pixel x, y
+	var at func(x, y int) (int, int)
+	switch orientation {
+	case 2:
+		at = func(x, y int) (int, int) { return w - 1 - x, y }
+	case 3:
+		at = func(x, y int) (int, int) { return w - 1 - x, h - 1 - y }
+	case 4:
+		at = func(x, y int) (int, int) { return x, h - 1 - y }
+	case 5:
+		at = func(x, y int) (int, int) { return y, x }
+	case 6:
+		at = func(x, y int) (int, int) { return y, h - 1 - x }
+	case 7:
+		at = func(x, y int) (int, int) { return w - 1 - y, h - 1 - x }
+	case 8:
+		at = func(x, y int) (int, int) { return w - 1 - y, x }
+	}
+
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			sx, sy := at(x, y)
+			copy(dst.Pix[dst.PixOffset(x, y):][:4], src.Pix[src.PixOffset(sx, sy):][:4])
+		}
+	}
+
+	return dst
+}
@@ -0,0 +1,149 @@
+package objects
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/cryptopunkscc/astrald/astral"
+)
+
+// A bundle is a single file that carries objects between nodes without a network connection.
+// It starts with a BundleIndex encoded as a regular astral object, followed by the raw data
+// of every indexed object, in index order. Object boundaries follow from the sizes in their
+// IDs, and every object is verified against its ID when the bundle is read.
+
+// BundleIndex lists the objects stored in a bundle.
+type BundleIndex struct {
+	Created astral.Time
+	Objects []*astral.ObjectID
+}
+
+var _ astral.Object = &BundleIndex{}
+
+func (BundleIndex) ObjectType() string {
+	return "mod.objects.bundle_index"
+}
+
+// WriteBundle writes a bundle of the listed objects to w. The data of every object is read with open.
+func WriteBundle(w io.Writer, ids []*astral.ObjectID, open func(*astral.ObjectID) (io.ReadCloser, error)) error {
+	index := &BundleIndex{Created: astral.Now(), Objects: ids}
+
+	_, err := astral.Encode(w, index, astral.Canonical())
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		r, err := open(id)
+		if err != nil {
+			return fmt.Errorf("open %v: %w", id, err)
+		}
+
+		n, err := io.Copy(w, io.LimitReader(r, int64(id.Size)))
+		r.Close()
+		if err != nil {
+			return fmt.Errorf("copy %v: %w", id, err)
+		}
+		if uint64(n) != id.Size {
+			return fmt.Errorf("copy %v: %w", id, io.ErrUnexpectedEOF)
+		}
+	}
+
+	return nil
+}
+
+// BundleReader reads objects from a bundle in order.
+type BundleReader struct {
+	Index *BundleIndex
+
+	r    io.Reader
+	next int
+	cur  *verifiedReader
+}
+
+// NewBundleReader reads the index of a bundle.
+func NewBundleReader(r io.Reader) (*BundleReader, error) {
+	o, _, err := astral.Decode(r, astral.Canonical())
+	if err != nil {
+		return nil, fmt.Errorf("read bundle index: %w", err)
+	}
+
+	index, ok := o.(*BundleIndex)
+	if !ok {
+		return nil, errors.New("not a bundle")
+	}
+
+	return &BundleReader{Index: index, r: r}, nil
+}
+
+// Next returns the next object in the bundle and a reader of its data. The reader returns
+// ErrHashMismatch at the end if the data does not match the ID. Data left unread by the previous
+// reader is skipped. Returns io.EOF after the last object.
+func (b *BundleReader) Next() (*astral.ObjectID, io.Reader, error) {
+	if b.cur != nil {
+		if _, err := io.Copy(io.Discard, b.cur); err != nil && !errors.Is(err, ErrHashMismatch) {
+			return nil, nil, err
+		}
+	}
+
+	if b.next >= len(b.Index.Objects) {
+		return nil, nil, io.EOF
+	}
+
+	id := b.Index.Objects[b.next]
+	b.next++
+
+	b.cur = &verifiedReader{
+		id:       id,
+		r:        io.LimitReader(b.r, int64(id.Size)),
+		resolver: astral.NewWriteResolver(nil),
+	}
+
+	return id, b.cur, nil
+}
+
+// verifiedReader passes through the data of an object and checks it against the ID at the end.
+type verifiedReader struct {
+	id       *astral.ObjectID
+	r        io.Reader
+	resolver *astral.WriteResolver
+}
+
+func (r *verifiedReader) Read(p []byte) (n int, err error) {
+	n, err = r.r.Read(p)
+	r.resolver.Write(p[:n])
+
+	if err == io.EOF && !r.resolver.Resolve().IsEqual(r.id) {
+		if r.resolver.Resolve().Size < r.id.Size {
+			return n, io.ErrUnexpectedEOF
+		}
+		return n, ErrHashMismatch
+	}
+
+	return
+}
+
+// binary
+
+func (i BundleIndex) WriteTo(w io.Writer) (n int64, err error) {
+	return astral.Objectify(&i).WriteTo(w)
+}
+
+func (i *BundleIndex) ReadFrom(r io.Reader) (n int64, err error) {
+	return astral.Objectify(i).ReadFrom(r)
+}
+
+// json
+
+func (i BundleIndex) MarshalJSON() ([]byte, error) {
+	return astral.Objectify(&i).MarshalJSON()
+}
+
+func (i *BundleIndex) UnmarshalJSON(bytes []byte) error {
+	return astral.Objectify(i).UnmarshalJSON(bytes)
+}
+
+func init() {
+	_ = astral.Add(&BundleIndex{})
+}
@@ -0,0 +1,44 @@
+package objects
+
+import (
+	"io"
+
+	"github.com/cryptopunkscc/astrald/astral"
+)
+
+// ChunkManifest lists, in order, the chunks an object was split into by a chunk repository.
+// Concatenating the chunks yields the object identified by ObjectID.
+type ChunkManifest struct {
+	ObjectID *astral.ObjectID
+	Chunks   []*astral.ObjectID
+}
+
+var _ astral.Object = &ChunkManifest{}
+
+func (ChunkManifest) ObjectType() string {
+	return "mod.objects.chunk_manifest"
+}
+
+// binary
+
+func (m ChunkManifest) WriteTo(w io.Writer) (n int64, err error) {
+	return astral.Objectify(&m).WriteTo(w)
+}
+
+func (m *ChunkManifest) ReadFrom(r io.Reader) (n int64, err error) {
+	return astral.Objectify(m).ReadFrom(r)
+}
+
+// json
+
+func (m ChunkManifest) MarshalJSON() ([]byte, error) {
+	return astral.Objectify(&m).MarshalJSON()
+}
+
+func (m *ChunkManifest) UnmarshalJSON(bytes []byte) error {
+	return astral.Objectify(m).UnmarshalJSON(bytes)
+}
+
+func init() {
+	_ = astral.Add(&ChunkManifest{})
+}
@@ -0,0 +1,86 @@
+package objects
+
+import (
+	"bufio"
+	"io"
+
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/astral/channel"
+	"github.com/cryptopunkscc/astrald/lib/query"
+	"github.com/cryptopunkscc/astrald/mod/objects"
+)
+
+const bundleBlockSize = 32 << 10
+
+// Export writes a bundle of the objects to w. If recursive is set, the bundle also carries
+// every object they reference.
+func (client *Client) Export(ctx *astral.Context, ids []*astral.ObjectID, recursive bool, w io.Writer) error {
+	args := query.Args{}
+	if recursive {
+		args["recursive"] = true
+	}
+
+	ch, err := client.queryCh(ctx, objects.MethodExport, args)
+	if err != nil {
+		return err
+	}
+	defer ch.Close()
+
+	for _, id := range ids {
+		if err = ch.Send(id); err != nil {
+			return err
+		}
+	}
+	if err = ch.Send(&astral.EOS{}); err != nil {
+		return err
+	}
+
+	return ch.Switch(
+		func(blob *astral.Blob) error {
+			_, err := w.Write(*blob)
+			return err
+		},
+		channel.BreakOnEOS,
+		channel.PassErrors,
+		channel.WithContext(ctx),
+	)
+}
+
+func Export(ctx *astral.Context, ids []*astral.ObjectID, recursive bool, w io.Writer) error {
+	return Default().Export(ctx, ids, recursive, w)
+}
+
+// Import sends a bundle read from r to the node and stores its objects in the repository (the default
+// write repository if empty). Returns the IDs of the imported objects.
+func (client *Client) Import(ctx *astral.Context, repo string, r io.Reader) (ids []*astral.ObjectID, err error) {
+	args := query.Args{}
+	if len(repo) > 0 {
+		args["repo"] = repo
+	}
+
+	ch, err := client.queryCh(ctx, objects.MethodImport, args)
+	if err != nil {
+		return
+	}
+	defer ch.Close()
+
+	w := bufio.NewWriterSize(&writer{ch: ch}, bundleBlockSize)
+
+	_, err = io.Copy(w, r)
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = ch.Send(&astral.EOS{})
+	}
+	if err != nil {
+		return
+	}
+
+	err = ch.Switch(channel.Collect(&ids), channel.BreakOnEOS, channel.PassErrors, channel.WithContext(ctx))
+	return
+}
+
+func Import(ctx *astral.Context, repo string, r io.Reader) ([]*astral.ObjectID, error) {
+	return Default().Import(ctx, repo, r)
+}
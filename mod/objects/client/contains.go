@@ -0,0 +1,32 @@
+package objects
+
+import (
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/astral/channel"
+	"github.com/cryptopunkscc/astrald/lib/query"
+	"github.com/cryptopunkscc/astrald/mod/objects"
+)
+
+// Contains checks if the repository holds the object.
+func (client *Client) Contains(ctx *astral.Context, repo string, objectID *astral.ObjectID) (bool, error) {
+	ch, err := client.queryCh(ctx, objects.MethodContains, query.Args{
+		"repo": repo,
+		"id":   objectID,
+	})
+	if err != nil {
+		return false, err
+	}
+	defer ch.Close()
+
+	var has *astral.Bool
+	err = ch.Switch(channel.Expect(&has), channel.PassErrors, channel.WithContext(ctx))
+	if err != nil {
+		return false, err
+	}
+
+	return bool(*has), nil
+}
+
+func Contains(ctx *astral.Context, repo string, objectID *astral.ObjectID) (bool, error) {
+	return Default().Contains(ctx, repo, objectID)
+}
@@ -0,0 +1,29 @@
+package objects
+
+import (
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/astral/channel"
+	"github.com/cryptopunkscc/astrald/lib/query"
+	"github.com/cryptopunkscc/astrald/mod/objects"
+)
+
+// Download makes the node fetch an object from its providers into repo (the default write repository if empty).
+func (client *Client) Download(ctx *astral.Context, objectID *astral.ObjectID, repo string) (id *astral.ObjectID, err error) {
+	args := query.Args{"id": objectID}
+	if len(repo) > 0 {
+		args["repo"] = repo
+	}
+
+	ch, err := client.queryCh(ctx, objects.MethodDownload, args)
+	if err != nil {
+		return
+	}
+	defer ch.Close()
+
+	err = ch.Switch(channel.Expect(&id), channel.PassErrors, channel.WithContext(ctx))
+	return
+}
+
+func Download(ctx *astral.Context, objectID *astral.ObjectID, repo string) (*astral.ObjectID, error) {
+	return Default().Download(ctx, objectID, repo)
+}
@@ -0,0 +1,49 @@
+package objects
+
+import (
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/astral/channel"
+	"github.com/cryptopunkscc/astrald/lib/query"
+	"github.com/cryptopunkscc/astrald/mod/objects"
+)
+
+// Fetch makes the node fetch a URL, an ARL or an ObjectID and waits for the result. If progress is
+// not nil, it is called with every progress update. A failed fetch can be resumed by calling Fetch again.
+func (client *Client) Fetch(ctx *astral.Context, source string, progress func(*objects.FetchProgress)) (id *astral.ObjectID, err error) {
+	return client.fetch(ctx, query.Args{"source": source}, progress)
+}
+
+// FetchRecursive is like Fetch, but also fetches every object referenced by the fetched object,
+// directly or indirectly. Progress is reported for each referenced object that was fetched.
+func (client *Client) FetchRecursive(ctx *astral.Context, source string, progress func(*objects.FetchProgress)) (id *astral.ObjectID, err error) {
+	return client.fetch(ctx, query.Args{"source": source, "recursive": true}, progress)
+}
+
+func (client *Client) fetch(ctx *astral.Context, args query.Args, progress func(*objects.FetchProgress)) (id *astral.ObjectID, err error) {
+	ch, err := client.queryCh(ctx, objects.MethodFetch, args)
+	if err != nil {
+		return
+	}
+	defer ch.Close()
+
+	err = ch.Switch(
+		func(p *objects.FetchProgress) error {
+			if progress != nil {
+				progress(p)
+			}
+			return nil
+		},
+		channel.Expect(&id),
+		channel.PassErrors,
+		channel.WithContext(ctx),
+	)
+	return
+}
+
+func Fetch(ctx *astral.Context, source string, progress func(*objects.FetchProgress)) (*astral.ObjectID, error) {
+	return Default().Fetch(ctx, source, progress)
+}
+
+func FetchRecursive(ctx *astral.Context, source string, progress func(*objects.FetchProgress)) (*astral.ObjectID, error) {
+	return Default().FetchRecursive(ctx, source, progress)
+}
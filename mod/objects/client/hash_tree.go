@@ -0,0 +1,42 @@
+package objects
+
+import (
+	"io"
+
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/astral/channel"
+	"github.com/cryptopunkscc/astrald/lib/query"
+	"github.com/cryptopunkscc/astrald/mod/objects"
+)
+
+// HashTree fetches the hash tree of an object. The tree is not checked against anything.
+func (client *Client) HashTree(ctx *astral.Context, objectID *astral.ObjectID) (tree *objects.HashTree, err error) {
+	ch, err := client.queryCh(ctx, objects.MethodHashTree, query.Args{"id": objectID})
+	if err != nil {
+		return
+	}
+	defer ch.Close()
+
+	err = ch.Switch(channel.Expect(&tree), channel.PassErrors, channel.WithContext(ctx))
+	return
+}
+
+// ReadVerified reads a window of an object and verifies every block of it against tree
+// before returning its bytes. A limit of 0 reads to the end of the object.
+func (client *Client) ReadVerified(ctx *astral.Context, tree *objects.HashTree, offset, limit int64) (io.ReadCloser, error) {
+	start, length := tree.Align(offset, limit)
+
+	r, err := client.Read(ctx, tree.ObjectID, start, length)
+	if err != nil {
+		return nil, err
+	}
+
+	return struct {
+		io.Reader
+		io.Closer
+	}{tree.NewVerifyingReader(r, start, offset, limit), r}, nil
+}
+
+func HashTree(ctx *astral.Context, objectID *astral.ObjectID) (*objects.HashTree, error) {
+	return Default().HashTree(ctx, objectID)
+}
@@ -0,0 +1,58 @@
+package objects
+
+import (
+	"strings"
+
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/astral/channel"
+	"github.com/cryptopunkscc/astrald/lib/query"
+	"github.com/cryptopunkscc/astrald/mod/objects"
+)
+
+// PublishName points a name of the node at an object. Returns the signed name record.
+func (client *Client) PublishName(ctx *astral.Context, name string, objectID *astral.ObjectID) (signed *objects.SignedName, err error) {
+	ch, err := client.queryCh(ctx, objects.MethodPublishName, query.Args{"name": name, "id": objectID})
+	if err != nil {
+		return
+	}
+	defer ch.Close()
+
+	err = ch.Switch(channel.Expect(&signed), channel.PassErrors, channel.WithContext(ctx))
+	return
+}
+
+func PublishName(ctx *astral.Context, name string, objectID *astral.ObjectID) (*objects.SignedName, error) {
+	return Default().PublishName(ctx, name, objectID)
+}
+
+// ResolveName returns the latest record of a name published by publisher (the target node if
+// nil). If the context includes the network zone, the publisher and the via nodes are asked too.
+func (client *Client) ResolveName(ctx *astral.Context, publisher *astral.Identity, name string, via ...*astral.Identity) (signed *objects.SignedName, err error) {
+	args := query.Args{"name": name}
+	if publisher != nil {
+		args["publisher"] = publisher
+	}
+	if len(via) > 0 {
+		var ids []string
+		for _, id := range via {
+			ids = append(ids, id.String())
+		}
+		args["via"] = strings.Join(ids, ",")
+	}
+	if ctx.Zone().Is(astral.ZoneNetwork) {
+		args["zone"] = ctx.Zone()
+	}
+
+	ch, err := client.queryCh(ctx, objects.MethodResolveName, args)
+	if err != nil {
+		return
+	}
+	defer ch.Close()
+
+	err = ch.Switch(channel.Expect(&signed), channel.PassErrors, channel.WithContext(ctx))
+	return
+}
+
+func ResolveName(ctx *astral.Context, publisher *astral.Identity, name string, via ...*astral.Identity) (*objects.SignedName, error) {
+	return Default().ResolveName(ctx, publisher, name, via...)
+}
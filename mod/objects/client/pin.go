@@ -0,0 +1,60 @@
+package objects
+
+import (
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/astral/channel"
+	"github.com/cryptopunkscc/astrald/lib/query"
+	"github.com/cryptopunkscc/astrald/mod/objects"
+)
+
+// Pin protects an object and every object it references from purge. Returns the pinned closure.
+func (client *Client) Pin(ctx *astral.Context, objectID *astral.ObjectID) (closure []*astral.ObjectID, err error) {
+	ch, err := client.queryCh(ctx, objects.MethodPin, query.Args{"id": objectID})
+	if err != nil {
+		return
+	}
+	defer ch.Close()
+
+	err = ch.Switch(channel.Collect(&closure), channel.BreakOnEOS, channel.PassErrors, channel.WithContext(ctx))
+	return
+}
+
+func Pin(ctx *astral.Context, objectID *astral.ObjectID) ([]*astral.ObjectID, error) {
+	return Default().Pin(ctx, objectID)
+}
+
+// Unpin removes the pin of an object.
+func (client *Client) Unpin(ctx *astral.Context, objectID *astral.ObjectID) error {
+	ch, err := client.queryCh(ctx, objects.MethodUnpin, query.Args{"id": objectID})
+	if err != nil {
+		return err
+	}
+	defer ch.Close()
+
+	return ch.Switch(channel.ExpectAck, channel.PassErrors, channel.WithContext(ctx))
+}
+
+func Unpin(ctx *astral.Context, objectID *astral.ObjectID) error {
+	return Default().Unpin(ctx, objectID)
+}
+
+// References returns the objects referenced by an object, or its full closure if recursive is set.
+func (client *Client) References(ctx *astral.Context, objectID *astral.ObjectID, recursive bool) (refs []*astral.ObjectID, err error) {
+	args := query.Args{"id": objectID}
+	if recursive {
+		args["recursive"] = true
+	}
+
+	ch, err := client.queryCh(ctx, objects.MethodReferences, args)
+	if err != nil {
+		return
+	}
+	defer ch.Close()
+
+	err = ch.Switch(channel.Collect(&refs), channel.BreakOnEOS, channel.PassErrors, channel.WithContext(ctx))
+	return
+}
+
+func References(ctx *astral.Context, objectID *astral.ObjectID, recursive bool) ([]*astral.ObjectID, error) {
+	return Default().References(ctx, objectID, recursive)
+}
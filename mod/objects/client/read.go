@@ -21,3 +21,15 @@ func (client *Client) Read(ctx *astral.Context, objectID *astral.ObjectID, offse
 func Read(ctx *astral.Context, objectID *astral.ObjectID, offset, limit int64) (io.ReadCloser, error) {
 	return Default().Read(ctx, objectID, offset, limit)
 }
+
+// ReadRepo returns a stream of the object's bytes from offset read from a single repository
+// of the target node; caller must Close it.
+func (client *Client) ReadRepo(ctx *astral.Context, repo string, objectID *astral.ObjectID, offset, limit int64) (io.ReadCloser, error) {
+	return client.query(ctx, objects.MethodRead, query.Args{
+		"id":     objectID,
+		"offset": offset,
+		"limit":  limit,
+		"repo":   repo,
+		"zone":   "dv",
+	})
+}
@@ -0,0 +1,28 @@
+package objects
+
+import (
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/astral/channel"
+	"github.com/cryptopunkscc/astrald/lib/query"
+	"github.com/cryptopunkscc/astrald/mod/objects"
+)
+
+// AddRemoteRepo mounts the repository repo of node in the network group of the target node
+// under name. An empty repo mounts the "local" repository.
+func (client *Client) AddRemoteRepo(ctx *astral.Context, name string, node *astral.Identity, repo string) error {
+	args := query.Args{
+		"name": name,
+		"node": node,
+	}
+	if len(repo) > 0 {
+		args["repo"] = repo
+	}
+
+	ch, err := client.queryCh(ctx, objects.MethodAddRemoteRepo, args)
+	if err != nil {
+		return err
+	}
+	defer ch.Close()
+
+	return ch.Switch(channel.ExpectAck, channel.PassErrors, channel.WithContext(ctx))
+}
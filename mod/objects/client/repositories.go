@@ -1,6 +1,8 @@
 package objects
 
 import (
+	"fmt"
+
 	"github.com/cryptopunkscc/astrald/astral"
 	"github.com/cryptopunkscc/astrald/astral/channel"
 	"github.com/cryptopunkscc/astrald/mod/objects"
@@ -17,3 +19,19 @@ func (client *Client) Repositories(ctx *astral.Context) (repos []*objects.Reposi
 	err = ch.Switch(channel.Collect(&repos), channel.BreakOnEOS, channel.PassErrors, channel.WithContext(ctx))
 	return
 }
+
+// Free returns the free space of a repository of the target node. -1 if unknown.
+func (client *Client) Free(ctx *astral.Context, repo string) (int64, error) {
+	repos, err := client.Repositories(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, info := range repos {
+		if string(info.Name) == repo {
+			return int64(info.Free), nil
+		}
+	}
+
+	return 0, fmt.Errorf("repository %s not found", repo)
+}
@@ -0,0 +1,29 @@
+package objects
+
+import (
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/astral/channel"
+	"github.com/cryptopunkscc/astrald/lib/query"
+	"github.com/cryptopunkscc/astrald/mod/objects"
+)
+
+// Scrub verifies every object in a repository and returns the report. Corrupt objects are removed
+// from the repository if quarantine is set, and also replaced with copies from providers if repair is set.
+func (client *Client) Scrub(ctx *astral.Context, repo string, quarantine, repair bool) (report *objects.ScrubReport, err error) {
+	ch, err := client.queryCh(ctx, objects.MethodScrub, query.Args{
+		"repo":       repo,
+		"quarantine": quarantine,
+		"repair":     repair,
+	})
+	if err != nil {
+		return
+	}
+	defer ch.Close()
+
+	err = ch.Switch(channel.Expect(&report), channel.PassErrors, channel.WithContext(ctx))
+	return
+}
+
+func Scrub(ctx *astral.Context, repo string, quarantine, repair bool) (*objects.ScrubReport, error) {
+	return Default().Scrub(ctx, repo, quarantine, repair)
+}
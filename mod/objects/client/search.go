@@ -40,3 +40,42 @@ func (client *Client) Search(ctx *astral.Context, q objects.SearchQuery) (<-chan
 func Search(ctx *astral.Context, q objects.SearchQuery) (<-chan *objects.SearchResult, *error) {
 	return Default().Search(ctx, q)
 }
+
+// SearchPage returns a page of results of a paged search and the cursor of the next page,
+// or nil after the last page. Set opts.Cursor to the returned cursor to get the next page.
+func (client *Client) SearchPage(ctx *astral.Context, q objects.SearchQuery, opts objects.SearchPageOpts) (page []*objects.ScoredResult, next *objects.SearchCursor, err error) {
+	if len(opts.Sort) == 0 {
+		// note: an explicit sort order requests a paged search even without a limit
+		opts.Sort = objects.SortScore
+	}
+
+	args := query.Args{
+		"q":    q,
+		"sort": opts.Sort,
+	}
+	if opts.Limit > 0 {
+		args["limit"] = opts.Limit
+	}
+	if len(opts.Cursor) > 0 {
+		args["cursor"] = opts.Cursor
+	}
+
+	ch, err := client.queryCh(ctx, objects.MethodSearch, args)
+	if err != nil {
+		return
+	}
+	defer ch.Close()
+
+	err = ch.Switch(
+		channel.Collect(&page),
+		channel.Expect(&next),
+		channel.BreakOnEOS,
+		channel.PassErrors,
+		channel.WithContext(ctx),
+	)
+	return
+}
+
+func SearchPage(ctx *astral.Context, q objects.SearchQuery, opts objects.SearchPageOpts) ([]*objects.ScoredResult, *objects.SearchCursor, error) {
+	return Default().SearchPage(ctx, q, opts)
+}
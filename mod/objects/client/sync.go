@@ -0,0 +1,69 @@
+package objects
+
+import (
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/astral/channel"
+	"github.com/cryptopunkscc/astrald/lib/query"
+	"github.com/cryptopunkscc/astrald/mod/objects"
+)
+
+func syncArgs(repo string, node *astral.Identity, remote string, direction string) query.Args {
+	args := query.Args{
+		"repo": repo,
+		"node": node,
+	}
+	if len(remote) > 0 {
+		args["remote"] = remote
+	}
+	if len(direction) > 0 {
+		args["direction"] = direction
+	}
+	return args
+}
+
+// Sync transfers the objects that differ between a repository of the target node and a
+// repository of node (remote, or the same name if empty). Direction is one of objects.SyncPull
+// (default), objects.SyncPush or objects.SyncBoth.
+func (client *Client) Sync(ctx *astral.Context, repo string, node *astral.Identity, remote string, direction string) (report *objects.SyncReport, err error) {
+	ch, err := client.queryCh(ctx, objects.MethodSync, syncArgs(repo, node, remote, direction))
+	if err != nil {
+		return
+	}
+	defer ch.Close()
+
+	err = ch.Switch(channel.Expect(&report), channel.PassErrors, channel.WithContext(ctx))
+	return
+}
+
+func Sync(ctx *astral.Context, repo string, node *astral.Identity, remote string, direction string) (*objects.SyncReport, error) {
+	return Default().Sync(ctx, repo, node, remote, direction)
+}
+
+// SyncFollow works like Sync, but keeps transferring objects added to either repository until
+// ctx is cancelled. Reports are sent after the initial sync and after every transfer; the error
+// pointer is valid only after the channel closes.
+func (client *Client) SyncFollow(ctx *astral.Context, repo string, node *astral.Identity, remote string, direction string) (<-chan *objects.SyncReport, *error) {
+	args := syncArgs(repo, node, remote, direction)
+	args["follow"] = true
+
+	ch, err := client.queryCh(ctx, objects.MethodSync, args)
+	if err != nil {
+		return nil, &err
+	}
+
+	out := make(chan *objects.SyncReport)
+	var errPtr = new(error)
+
+	go func() {
+		defer close(out)
+		defer ch.Close()
+
+		*errPtr = ch.Switch(channel.Chan(out), channel.BreakOnEOS, channel.PassErrors, channel.WithContext(ctx))
+	}()
+
+	return out, errPtr
+}
+
+func SyncFollow(ctx *astral.Context, repo string, node *astral.Identity, remote string, direction string) (<-chan *objects.SyncReport, *error) {
+	return Default().SyncFollow(ctx, repo, node, remote, direction)
+}
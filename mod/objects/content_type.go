@@ -0,0 +1,46 @@
+package objects
+
+import (
+	"io"
+
+	"github.com/cryptopunkscc/astrald/astral"
+)
+
+// ContentType describes the MIME type of a raw object, sniffed from its first bytes.
+type ContentType struct {
+	Mime astral.String8
+}
+
+var _ astral.Object = &ContentType{}
+
+func (ContentType) ObjectType() string {
+	return "mod.objects.content_type"
+}
+
+// binary
+
+func (c ContentType) WriteTo(w io.Writer) (int64, error) {
+	return astral.Objectify(&c).WriteTo(w)
+}
+
+func (c *ContentType) ReadFrom(r io.Reader) (int64, error) {
+	return astral.Objectify(c).ReadFrom(r)
+}
+
+// json
+
+func (c ContentType) MarshalJSON() ([]byte, error) {
+	return astral.Objectify(&c).MarshalJSON()
+}
+
+func (c *ContentType) UnmarshalJSON(bytes []byte) error {
+	return astral.Objectify(c).UnmarshalJSON(bytes)
+}
+
+func (c ContentType) String() string {
+	return string(c.Mime)
+}
+
+func init() {
+	_ = astral.Add(&ContentType{})
+}
@@ -9,6 +9,10 @@ var (
 	ErrNoSpaceLeft    = errors.New("no space left on device")
 	ErrClosedPipe     = errors.New("pipe closed")
 	ErrPushRejected   = errors.New("push rejected")
+	ErrInvalidName    = errors.New("invalid name")
+	ErrNameNotFound   = errors.New("name not found")
+
+	ErrReferencesUnknown = errors.New("references not known yet")
 
 	ErrNilSourceIdentifier   = errors.New("source identifier is nil")
 	ErrInvalidSourceIdentity = errors.New("source identity is invalid")
@@ -0,0 +1,44 @@
+package objects
+
+import (
+	"io"
+
+	"github.com/cryptopunkscc/astrald/astral"
+)
+
+// FetchProgress reports the state of an objects.fetch transfer.
+type FetchProgress struct {
+	Source astral.String16 // URL or ARL being fetched
+	Offset astral.Uint64   // bytes fetched so far
+	Size   astral.Uint64   // total size, 0 if unknown
+}
+
+var _ astral.Object = &FetchProgress{}
+
+func (FetchProgress) ObjectType() string {
+	return "mod.objects.fetch_progress"
+}
+
+// binary
+
+func (p FetchProgress) WriteTo(w io.Writer) (int64, error) {
+	return astral.Objectify(&p).WriteTo(w)
+}
+
+func (p *FetchProgress) ReadFrom(r io.Reader) (int64, error) {
+	return astral.Objectify(p).ReadFrom(r)
+}
+
+// json
+
+func (p FetchProgress) MarshalJSON() ([]byte, error) {
+	return astral.Objectify(&p).MarshalJSON()
+}
+
+func (p *FetchProgress) UnmarshalJSON(bytes []byte) error {
+	return astral.Objectify(p).UnmarshalJSON(bytes)
+}
+
+func init() {
+	_ = astral.Add(&FetchProgress{})
+}
@@ -0,0 +1,305 @@
+package objects
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/cryptopunkscc/astrald/astral"
+)
+
+// DefaultHashTreeBlockSize is the block size of hash trees built by the node.
+const DefaultHashTreeBlockSize = 1 << 20
+
+var ErrHashMismatch = errors.New("hash mismatch")
+
+// HashTree is a companion object to an ObjectID that holds the hashes of the object's fixed-size
+// blocks. Any block-aligned range of the object can be checked against the leaves as soon as it
+// arrives, and the leaves themselves are bound together by the Merkle root.
+//
+// Leaves are sha256(0x00 || block), inner nodes are sha256(0x01 || left || right); an odd node
+// at the end of a level is carried up unchanged.
+type HashTree struct {
+	ObjectID  *astral.ObjectID
+	BlockSize astral.Uint32
+	Leaves    astral.Bytes32 // concatenated 32-byte leaf hashes
+}
+
+var _ astral.Object = &HashTree{}
+
+func (HashTree) ObjectType() string {
+	return "mod.objects.hash_tree"
+}
+
+// BuildHashTree reads the whole object from r and returns its hash tree. The data must resolve
+// to objectID, so a tree built this way is bound to the object it describes.
+func BuildHashTree(r io.Reader, objectID *astral.ObjectID, blockSize uint32) (*HashTree, error) {
+	if blockSize == 0 {
+		return nil, errors.New("invalid block size")
+	}
+
+	b := NewHashTreeBuilder(blockSize)
+	if _, err := io.Copy(b, r); err != nil {
+		return nil, err
+	}
+
+	return b.Tree(objectID)
+}
+
+// HashTreeBuilder builds the hash tree of an object from its data written in order.
+type HashTreeBuilder struct {
+	blockSize int
+	resolver  *astral.WriteResolver
+	block     []byte
+	leaves    bytes.Buffer
+}
+
+func NewHashTreeBuilder(blockSize uint32) *HashTreeBuilder {
+	return &HashTreeBuilder{
+		blockSize: int(blockSize),
+		resolver:  astral.NewWriteResolver(nil),
+		block:     make([]byte, 0, blockSize),
+	}
+}
+
+func (b *HashTreeBuilder) Write(p []byte) (n int, err error) {
+	b.resolver.Write(p)
+
+	for n < len(p) {
+		k := min(len(p)-n, b.blockSize-len(b.block))
+		b.block = append(b.block, p[n:n+k]...)
+		n += k
+
+		if len(b.block) == b.blockSize {
+			leaf := hashTreeLeaf(b.block)
+			b.leaves.Write(leaf[:])
+			b.block = b.block[:0]
+		}
+	}
+
+	return
+}
+
+// Tree returns the hash tree of the data written so far, which must resolve to objectID.
+func (b *HashTreeBuilder) Tree(objectID *astral.ObjectID) (*HashTree, error) {
+	if !b.resolver.Resolve().IsEqual(objectID) {
+		return nil, ErrHashMismatch
+	}
+
+	leaves := bytes.Clone(b.leaves.Bytes())
+	if len(b.block) > 0 || len(leaves) == 0 {
+		leaf := hashTreeLeaf(b.block)
+		leaves = append(leaves, leaf[:]...)
+	}
+
+	return &HashTree{
+		ObjectID:  objectID,
+		BlockSize: astral.Uint32(b.blockSize),
+		Leaves:    leaves,
+	}, nil
+}
+
+// BlockCount returns the number of blocks the object is split into. Empty objects have one empty block.
+func (t *HashTree) BlockCount() int64 {
+	if t.BlockSize == 0 {
+		return 0
+	}
+	return max(1, int64((t.ObjectID.Size+uint64(t.BlockSize)-1)/uint64(t.BlockSize)))
+}
+
+// Validate checks that the tree is well-formed for its ObjectID.
+func (t *HashTree) Validate() error {
+	switch {
+	case t.ObjectID == nil:
+		return errors.New("missing object id")
+	case t.BlockSize == 0:
+		return errors.New("invalid block size")
+	case int64(len(t.Leaves)) != t.BlockCount()*sha256.Size:
+		return fmt.Errorf("expected %d leaves, got %d bytes", t.BlockCount(), len(t.Leaves))
+	}
+	return nil
+}
+
+// Root returns the Merkle root of the tree.
+func (t *HashTree) Root() (root [32]byte) {
+	var level [][32]byte
+	for i := 0; i+sha256.Size <= len(t.Leaves); i += sha256.Size {
+		level = append(level, [32]byte(t.Leaves[i:i+sha256.Size]))
+	}
+	if len(level) == 0 {
+		return
+	}
+
+	for len(level) > 1 {
+		var next [][32]byte
+		for i := 0; i < len(level); i += 2 {
+			if i+1 == len(level) {
+				next = append(next, level[i])
+				continue
+			}
+			next = append(next, hashTreeNode(level[i], level[i+1]))
+		}
+		level = next
+	}
+
+	return level[0]
+}
+
+// Align expands a range of the object to whole blocks. A limit of 0 means to the end of the object.
+func (t *HashTree) Align(offset, limit int64) (start, length int64) {
+	size := int64(t.ObjectID.Size)
+	end := size
+	if limit > 0 {
+		end = min(offset+limit, size)
+	}
+
+	bs := int64(t.BlockSize)
+	start = offset / bs * bs
+	end = min((end+bs-1)/bs*bs, size)
+
+	return start, end - start
+}
+
+// VerifyBlock checks the data of a single block against its leaf.
+func (t *HashTree) VerifyBlock(index int64, data []byte) error {
+	if index < 0 || index >= t.BlockCount() {
+		return ErrOutOfBounds
+	}
+
+	if int64(len(data)) != t.blockLen(index) {
+		return fmt.Errorf("block %d: %w", index, ErrHashMismatch)
+	}
+
+	leaf := hashTreeLeaf(data)
+	if !bytes.Equal(leaf[:], t.Leaves[index*sha256.Size:(index+1)*sha256.Size]) {
+		return fmt.Errorf("block %d: %w", index, ErrHashMismatch)
+	}
+
+	return nil
+}
+
+// Verify checks a block-aligned range of the object that starts at offset.
+func (t *HashTree) Verify(offset int64, data []byte) error {
+	bs := int64(t.BlockSize)
+	if bs == 0 || offset%bs != 0 {
+		return errors.New("range not aligned to blocks")
+	}
+
+	for index := offset / bs; len(data) > 0; index++ {
+		n := min(int64(len(data)), t.blockLen(index))
+		if err := t.VerifyBlock(index, data[:n]); err != nil {
+			return err
+		}
+		data = data[n:]
+	}
+
+	return nil
+}
+
+// NewVerifyingReader wraps r, which must stream the object from the block-aligned offset start,
+// and returns the window offset..offset+limit of the object. Every block is verified before any
+// of its bytes are returned.
+func (t *HashTree) NewVerifyingReader(r io.Reader, start, offset, limit int64) io.Reader {
+	end := int64(t.ObjectID.Size)
+	if limit > 0 {
+		end = min(offset+limit, end)
+	}
+
+	return &verifyingReader{
+		tree:  t,
+		r:     r,
+		index: start / int64(t.BlockSize),
+		skip:  offset - start,
+		left:  end - offset,
+	}
+}
+
+func (t *HashTree) blockLen(index int64) int64 {
+	bs := int64(t.BlockSize)
+	return min(bs, int64(t.ObjectID.Size)-index*bs)
+}
+
+type verifyingReader struct {
+	tree  *HashTree
+	r     io.Reader
+	index int64 // next block to verify
+	skip  int64 // bytes to drop from the next block
+	left  int64 // bytes left to return
+	buf   []byte
+}
+
+func (r *verifyingReader) Read(p []byte) (n int, err error) {
+	if r.left <= 0 {
+		return 0, io.EOF
+	}
+
+	for len(r.buf) == 0 {
+		block := make([]byte, r.tree.blockLen(r.index))
+		if _, err = io.ReadFull(r.r, block); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return 0, err
+		}
+
+		if err = r.tree.VerifyBlock(r.index, block); err != nil {
+			r.left = 0
+			return 0, err
+		}
+
+		r.buf = block[min(r.skip, int64(len(block))):]
+		r.skip = max(0, r.skip-int64(len(block)))
+		r.index++
+	}
+
+	if int64(len(p)) > r.left {
+		p = p[:r.left]
+	}
+
+	n = copy(p, r.buf)
+	r.buf = r.buf[n:]
+	r.left -= int64(n)
+
+	return
+}
+
+func hashTreeLeaf(data []byte) [32]byte {
+	h := sha256.New()
+	h.Write([]byte{0})
+	h.Write(data)
+	return [32]byte(h.Sum(nil))
+}
+
+func hashTreeNode(left, right [32]byte) [32]byte {
+	h := sha256.New()
+	h.Write([]byte{1})
+	h.Write(left[:])
+	h.Write(right[:])
+	return [32]byte(h.Sum(nil))
+}
+
+// binary
+
+func (t HashTree) WriteTo(w io.Writer) (n int64, err error) {
+	return astral.Objectify(&t).WriteTo(w)
+}
+
+func (t *HashTree) ReadFrom(r io.Reader) (n int64, err error) {
+	return astral.Objectify(t).ReadFrom(r)
+}
+
+// json
+
+func (t HashTree) MarshalJSON() ([]byte, error) {
+	return astral.Objectify(&t).MarshalJSON()
+}
+
+func (t *HashTree) UnmarshalJSON(bytes []byte) error {
+	return astral.Objectify(t).UnmarshalJSON(bytes)
+}
+
+func init() {
+	_ = astral.Add(&HashTree{})
+}
@@ -124,10 +124,11 @@ func (repo *Repository) Scan(ctx *astral.Context, follow bool) (<-chan *astral.O
 }
 
 func (repo *Repository) Delete(ctx *astral.Context, objectID *astral.ObjectID) error {
-	_, ok := repo.objects.Delete(objectID.String())
+	data, ok := repo.objects.Delete(objectID.String())
 	if !ok {
 		return objects.ErrNotFound
 	}
+	repo.used.Add(int64(-len(data)))
 	return nil
 }
 
@@ -45,7 +45,10 @@ func (w *Writer) Commit() (*astral.ObjectID, error) {
 	var buf = w.buf.Bytes()
 	var objectID, _ = astral.Resolve(bytes.NewReader(buf))
 
-	w.objects.Set(objectID.String(), buf)
+	if _, ok := w.objects.Set(objectID.String(), buf); !ok {
+		w.used.Add(int64(-len(buf))) // already stored, don't count it twice
+		return objectID, nil
+	}
 
 	w.Repository.pushAdded(objectID)
 
@@ -32,6 +32,20 @@ const (
 	MethodRemoveRepository  = "objects.remove_repository"
 	MethodBlueprints        = "objects.blueprints"
 	MethodEcho              = "objects.echo"
+	MethodDownload          = "objects.download"
+	MethodHashTree          = "objects.hash_tree"
+	MethodFetch             = "objects.fetch"
+	MethodScrub             = "objects.scrub"
+	MethodExport            = "objects.export"
+	MethodImport            = "objects.import"
+	MethodReferences        = "objects.references"
+	MethodPin               = "objects.pin"
+	MethodUnpin             = "objects.unpin"
+	MethodPublishName       = "objects.publish_name"
+	MethodResolveName       = "objects.resolve_name"
+	MethodAddRemoteRepo     = "objects.add_remote_repo"
+	MethodSync              = "objects.sync"
+	MethodReconcile         = "objects.reconcile"
 
 	RepoMain      = "main"      // everything
 	RepoDevice    = "device"    // device: memory, local, removable
@@ -41,6 +55,7 @@ const (
 	RepoVirtual   = "virtual"   // virtual repos (archives, encryption, chunks)
 	RepoNetwork   = "network"   // network repos
 	RepoSystem    = "system"
+	RepoCache     = "cache" // cache of network reads
 )
 
 // MaxObjectSize is the maximum size of an object that can be loaded into memory
@@ -53,6 +68,9 @@ type Module interface {
 	// RemoveRepository removes a Repository by its name
 	RemoveRepository(name string) error
 
+	// Repositories returns all registered repositories by name
+	Repositories() map[string]Repository
+
 	// GetRepository returns a Repository by its name
 	GetRepository(name string) Repository
 
@@ -90,14 +108,27 @@ type Module interface {
 	AddHolder(Holder) error
 	Holders(objectID *astral.ObjectID) []Holder
 
+	AddReferenceExtractor(ReferenceExtractor) error
+	References(*astral.Context, *astral.ObjectID) ([]*astral.ObjectID, error)
+
+	PublishName(ctx *astral.Context, name string, objectID *astral.ObjectID) (*SignedName, error)
+	ResolveName(ctx *astral.Context, publisher *astral.Identity, name string, via ...*astral.Identity) (*SignedName, error)
+
 	AddReceiver(Receiver) error
 	Receive(astral.Object, *astral.Identity) error
 
 	Push(ctx *astral.Context, target *astral.Identity, obj astral.Object) error
 
+	// Download fetches an object from the nodes returned by Find and stores it in repo
+	// (WriteDefault if nil). The data is verified before it is committed.
+	Download(ctx *astral.Context, objectID *astral.ObjectID, repo Repository) error
+
 	// Probe probes the object (checks type and latency)
 	Probe(ctx *astral.Context, repo Repository, objectID *astral.ObjectID) (probe *Probe, err error)
 
+	// ContentType returns the MIME type of an object sniffed from its first bytes
+	ContentType(ctx *astral.Context, objectID *astral.ObjectID) (string, error)
+
 	// Deprecated: Use Probe instead.
 	GetType(ctx *astral.Context, objectID *astral.ObjectID) (objectType string, err error)
 
@@ -125,6 +156,14 @@ type Holder interface {
 	HoldObject(*astral.ObjectID) bool
 }
 
+// ReferenceExtractor finds the objects referenced by an object, such as the entries of an archive.
+// Extractors that know nothing about the object return no references and no error. Extractors
+// that can't tell the references yet, such as of an archive that isn't indexed, return
+// ErrReferencesUnknown, so that the references are extracted again on the next request.
+type ReferenceExtractor interface {
+	ExtractReferences(*astral.Context, *astral.ObjectID) ([]*astral.ObjectID, error)
+}
+
 // IsOffsetLimitValid reports whether the offset/limit window fits within the object.
 // A limit of 0 is treated as a valid zero-length window, not "read to end".
 func IsOffsetLimitValid(objectID *astral.ObjectID, offset int64, limit int64) bool {
@@ -0,0 +1,85 @@
+package objects
+
+import (
+	"fmt"
+	"io"
+	"unicode"
+
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/mod/crypto"
+)
+
+// MaxNameLen is the maximum length of a published name in bytes.
+const MaxNameLen = 64
+
+// Name is the unsigned body of a mutable name: Publisher says that Name points to ObjectID.
+// A name with a higher Sequence supersedes all earlier ones. Wrap it in SignedName before
+// storing, propagating or verifying.
+type Name struct {
+	Publisher *astral.Identity
+	Name      astral.String8
+	ObjectID  *astral.ObjectID
+	Sequence  astral.Uint64
+	IssuedAt  astral.Time
+}
+
+var _ crypto.SignableTextObject = &Name{}
+
+func (Name) ObjectType() string { return "mod.objects.name" }
+
+func (n Name) WriteTo(w io.Writer) (int64, error)     { return astral.Objectify(&n).WriteTo(w) }
+func (n *Name) ReadFrom(src io.Reader) (int64, error) { return astral.Objectify(n).ReadFrom(src) }
+
+func (n Name) MarshalJSON() ([]byte, error)  { return astral.Objectify(&n).MarshalJSON() }
+func (n *Name) UnmarshalJSON(b []byte) error { return astral.Objectify(n).UnmarshalJSON(b) }
+
+func (n *Name) SignableHash() []byte {
+	id, err := astral.ResolveObjectID(n)
+	if err != nil {
+		return nil
+	}
+	return id.Hash[:]
+}
+
+func (n *Name) SignableText() string {
+	return fmt.Sprintf("name %s #%d points to %s", n.Name, n.Sequence, n.ObjectID.String())
+}
+
+// SignedName pairs a Name body with the publisher's signature. It is the wire, stored and
+// propagated form of a name.
+type SignedName struct {
+	*Name
+	Signature *crypto.Signature
+}
+
+var _ astral.Object = &SignedName{}
+
+func (SignedName) ObjectType() string { return "mod.objects.signed_name" }
+
+func (n SignedName) WriteTo(w io.Writer) (int64, error)     { return astral.Objectify(&n).WriteTo(w) }
+func (n *SignedName) ReadFrom(src io.Reader) (int64, error) { return astral.Objectify(n).ReadFrom(src) }
+
+func (n SignedName) MarshalJSON() ([]byte, error)  { return astral.Objectify(&n).MarshalJSON() }
+func (n *SignedName) UnmarshalJSON(b []byte) error { return astral.Objectify(n).UnmarshalJSON(b) }
+
+// IsNil guards against both a nil receiver and an embedded nil *Name.
+func (n *SignedName) IsNil() bool { return n == nil || n.Name == nil }
+
+// ValidateName checks that name is non-empty, at most MaxNameLen bytes long and contains no
+// whitespace or control characters.
+func ValidateName(name string) error {
+	if len(name) == 0 || len(name) > MaxNameLen {
+		return ErrInvalidName
+	}
+	for _, r := range name {
+		if unicode.IsSpace(r) || unicode.IsControl(r) || r == unicode.ReplacementChar {
+			return ErrInvalidName
+		}
+	}
+	return nil
+}
+
+func init() {
+	_ = astral.Add(&Name{})
+	_ = astral.Add(&SignedName{})
+}
@@ -0,0 +1,48 @@
+package objects
+
+import (
+	"io"
+
+	"github.com/cryptopunkscc/astrald/astral"
+)
+
+var _ astral.Object = &ScoredResult{}
+
+// ScoredResult is a SearchResult with the relevance of the match as rated by the searcher,
+// higher is better. Paged searches return results of this type.
+type ScoredResult struct {
+	SourceID *astral.Identity
+	ObjectID *astral.ObjectID
+	Score    astral.Float64
+}
+
+func (ScoredResult) ObjectType() string { return "mod.objects.scored_result" }
+
+func (sr ScoredResult) WriteTo(w io.Writer) (n int64, err error) {
+	return astral.Objectify(&sr).WriteTo(w)
+}
+
+func (sr *ScoredResult) ReadFrom(r io.Reader) (n int64, err error) {
+	return astral.Objectify(sr).ReadFrom(r)
+}
+
+func (sr ScoredResult) MarshalJSON() ([]byte, error) {
+	return astral.Objectify(&sr).MarshalJSON()
+}
+
+func (sr *ScoredResult) UnmarshalJSON(bytes []byte) error {
+	return astral.Objectify(sr).UnmarshalJSON(bytes)
+}
+
+// SearchResult returns the result without its score.
+func (sr ScoredResult) SearchResult() *SearchResult {
+	return &SearchResult{SourceID: sr.SourceID, ObjectID: sr.ObjectID}
+}
+
+func (sr ScoredResult) String() string {
+	return sr.ObjectID.String()
+}
+
+func init() {
+	_ = astral.Add(&ScoredResult{})
+}
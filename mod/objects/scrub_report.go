@@ -0,0 +1,50 @@
+package objects
+
+import (
+	"io"
+
+	"github.com/cryptopunkscc/astrald/astral"
+)
+
+// ScrubReport is the result of verifying every object in a repository against its ObjectID.
+type ScrubReport struct {
+	Repo        astral.String8
+	StartedAt   astral.Time
+	FinishedAt  astral.Time
+	Checked     astral.Uint64      // objects verified
+	Bytes       astral.Uint64      // bytes read
+	Corrupt     []*astral.ObjectID // objects whose data does not match their ID
+	Unreadable  []*astral.ObjectID // objects that could not be read
+	Quarantined []*astral.ObjectID // corrupt objects removed from the repository
+	Repaired    []*astral.ObjectID // corrupt objects replaced with a good copy from a provider
+}
+
+var _ astral.Object = &ScrubReport{}
+
+func (ScrubReport) ObjectType() string {
+	return "mod.objects.scrub_report"
+}
+
+// binary
+
+func (r ScrubReport) WriteTo(w io.Writer) (n int64, err error) {
+	return astral.Objectify(&r).WriteTo(w)
+}
+
+func (r *ScrubReport) ReadFrom(reader io.Reader) (n int64, err error) {
+	return astral.Objectify(r).ReadFrom(reader)
+}
+
+// json
+
+func (r ScrubReport) MarshalJSON() ([]byte, error) {
+	return astral.Objectify(&r).MarshalJSON()
+}
+
+func (r *ScrubReport) UnmarshalJSON(bytes []byte) error {
+	return astral.Objectify(r).UnmarshalJSON(bytes)
+}
+
+func init() {
+	_ = astral.Add(&ScrubReport{})
+}
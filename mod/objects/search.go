@@ -9,6 +9,13 @@ type Searcher interface {
 	SearchObject(ctx *astral.Context, query SearchQuery) (<-chan *SearchResult, error)
 }
 
+// RankedSearcher is a Searcher that rates the relevance of its results. Paged searches
+// sort by the score of ranked results; results of other searchers score 0.
+type RankedSearcher interface {
+	Searcher
+	SearchRanked(ctx *astral.Context, query SearchQuery) (<-chan *ScoredResult, error)
+}
+
 // SearchPreprocessor is a hook that mutates a Search in place before it runs.
 type SearchPreprocessor interface {
 	PreprocessSearch(*Search)
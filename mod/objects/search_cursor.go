@@ -0,0 +1,41 @@
+package objects
+
+import (
+	"io"
+
+	"github.com/cryptopunkscc/astrald/astral"
+)
+
+var _ astral.Object = &SearchCursor{}
+
+// SearchCursor follows the last result of a page of a paged search if more results are
+// available. Pass it in the next query to get the next page.
+type SearchCursor struct {
+	Cursor astral.String16
+}
+
+func (SearchCursor) ObjectType() string { return "mod.objects.search_cursor" }
+
+func (c SearchCursor) WriteTo(w io.Writer) (n int64, err error) {
+	return astral.Objectify(&c).WriteTo(w)
+}
+
+func (c *SearchCursor) ReadFrom(r io.Reader) (n int64, err error) {
+	return astral.Objectify(c).ReadFrom(r)
+}
+
+func (c SearchCursor) MarshalJSON() ([]byte, error) {
+	return astral.Objectify(&c).MarshalJSON()
+}
+
+func (c *SearchCursor) UnmarshalJSON(bytes []byte) error {
+	return astral.Objectify(c).UnmarshalJSON(bytes)
+}
+
+func (c SearchCursor) String() string {
+	return string(c.Cursor)
+}
+
+func init() {
+	_ = astral.Add(&SearchCursor{})
+}
@@ -0,0 +1,17 @@
+package objects
+
+// Sort orders of paged searches
+const (
+	SortScore = "score" // highest score first
+	SortID    = "id"    // ObjectID text order
+	SortSize  = "size"  // largest objects first
+)
+
+// SearchPageOpts request a paged search, in which the results of all searchers are merged,
+// deduplicated and sorted before they are returned a page at a time. They are sent as query
+// arguments next to the SearchQuery.
+type SearchPageOpts struct {
+	Sort   string // order of results, SortScore by default
+	Limit  int    // maximum number of results in a page, 0 for no limit
+	Cursor string // continuation cursor of the next page, from the previous page
+}
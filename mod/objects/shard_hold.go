@@ -0,0 +1,38 @@
+package objects
+
+import (
+	"io"
+
+	"github.com/cryptopunkscc/astrald/astral"
+)
+
+var _ astral.Object = &ShardHold{}
+
+// ShardHold is pushed to a node that stores an erasure shard of the sender. It asks the node to
+// protect the shard from being purged, or to stop protecting it if Release is set.
+type ShardHold struct {
+	ShardID *astral.ObjectID
+	Release astral.Bool
+}
+
+func (ShardHold) ObjectType() string { return "mod.objects.shard_hold" }
+
+func (h ShardHold) WriteTo(w io.Writer) (n int64, err error) {
+	return astral.Objectify(&h).WriteTo(w)
+}
+
+func (h *ShardHold) ReadFrom(r io.Reader) (n int64, err error) {
+	return astral.Objectify(h).ReadFrom(r)
+}
+
+func (h ShardHold) MarshalJSON() ([]byte, error) {
+	return astral.Objectify(&h).MarshalJSON()
+}
+
+func (h *ShardHold) UnmarshalJSON(bytes []byte) error {
+	return astral.Objectify(h).UnmarshalJSON(bytes)
+}
+
+func init() {
+	_ = astral.Add(&ShardHold{})
+}
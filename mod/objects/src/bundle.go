@@ -0,0 +1,96 @@
+package objects
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/mod/objects"
+)
+
+// ExportBundle writes a bundle of the objects to w. If recursive is set, the bundle also
+// carries every object they reference.
+func (mod *Module) ExportBundle(ctx *astral.Context, w io.Writer, ids []*astral.ObjectID, recursive bool) (err error) {
+	if recursive {
+		ids, err = mod.Closure(ctx, ids)
+		if err != nil {
+			return
+		}
+	}
+
+	repo := mod.ReadDefault()
+
+	return objects.WriteBundle(w, ids, func(id *astral.ObjectID) (io.ReadCloser, error) {
+		return repo.Read(ctx, id, 0, 0)
+	})
+}
+
+// ImportBundle stores the objects of a bundle in repo. Objects that don't match their ID are
+// skipped and reported in the error; everything else is imported. Returns the IDs of all objects
+// of the bundle that are now in the repository.
+func (mod *Module) ImportBundle(ctx *astral.Context, r io.Reader, repo objects.Repository) (imported []*astral.ObjectID, err error) {
+	bundle, err := objects.NewBundleReader(r)
+	if err != nil {
+		return
+	}
+
+	var corrupt int
+
+	for {
+		id, data, err := bundle.Next()
+		switch {
+		case errors.Is(err, io.EOF):
+			if corrupt > 0 {
+				return imported, fmt.Errorf("%d objects failed verification", corrupt)
+			}
+			return imported, nil
+		case err != nil:
+			return imported, err
+		}
+
+		if has, _ := repo.Contains(ctx, id); has {
+			imported = append(imported, id)
+			continue
+		}
+
+		err = mod.importObject(ctx, repo, id, data)
+		switch {
+		case err == nil:
+			imported = append(imported, id)
+
+		case errors.Is(err, objects.ErrHashMismatch):
+			mod.log.Errorv(1, "import: %v failed verification", id)
+			corrupt++
+
+		default:
+			return imported, err
+		}
+	}
+}
+
+func (mod *Module) importObject(ctx *astral.Context, repo objects.Repository, id *astral.ObjectID, data io.Reader) error {
+	w, err := repo.Create(ctx, &objects.CreateOpts{Alloc: int(id.Size)})
+	if err != nil {
+		return err
+	}
+	defer w.Discard()
+
+	_, err = io.Copy(w, data)
+	if err != nil {
+		return err
+	}
+
+	committed, err := w.Commit()
+	if err != nil {
+		return err
+	}
+	if !committed.IsEqual(id) {
+		return objects.ErrHashMismatch
+	}
+
+	// seed the type of astral objects
+	_, _ = mod.Probe(ctx, repo, id)
+
+	return nil
+}
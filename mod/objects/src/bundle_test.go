@@ -0,0 +1,89 @@
+package objects
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/mod/objects"
+	"github.com/cryptopunkscc/astrald/mod/objects/mem"
+)
+
+func testBundleModule(t *testing.T) *Module {
+	mod := newTestModule(t)
+	mod.extractors.Add(&typedExtractor{mod: mod})
+
+	local := mem.New("local", 1<<20)
+	mod.repos.Set("local", local)
+	mod.repos.Set("main", local)
+
+	return mod
+}
+
+// A recursive export carries the referenced objects, and an import restores all of them.
+func TestBundle_RecursiveRoundTrip(t *testing.T) {
+	ctx := astral.NewContext(nil)
+	mod := testBundleModule(t)
+	local := mod.WriteDefault()
+
+	a := storeBytes(t, local, bytes.Repeat([]byte{1}, 1000))
+	b := storeBytes(t, local, bytes.Repeat([]byte{2}, 2000))
+
+	root, err := mod.Store(ctx, local, &objects.BundleIndex{Objects: []*astral.ObjectID{a, b}})
+	if err != nil {
+		t.Fatalf("store: %v", err)
+	}
+
+	var bundle bytes.Buffer
+	if err := mod.ExportBundle(ctx, &bundle, []*astral.ObjectID{root}, true); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+
+	dst := mem.New("dst", 1<<20)
+
+	imported, err := mod.ImportBundle(ctx, &bundle, dst)
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if len(imported) != 3 {
+		t.Fatalf("imported %d objects, want 3", len(imported))
+	}
+
+	for _, id := range []*astral.ObjectID{root, a, b} {
+		if has, _ := dst.Contains(ctx, id); !has {
+			t.Errorf("%v missing after import", id)
+		}
+	}
+}
+
+// An object whose data was altered in the bundle is not imported, the others are.
+func TestBundle_Tamper(t *testing.T) {
+	ctx := astral.NewContext(nil)
+	mod := testBundleModule(t)
+	local := mod.WriteDefault()
+
+	a := storeBytes(t, local, bytes.Repeat([]byte{1}, 1000))
+	b := storeBytes(t, local, bytes.Repeat([]byte{2}, 1000))
+
+	var bundle bytes.Buffer
+	if err := mod.ExportBundle(ctx, &bundle, []*astral.ObjectID{a, b}, false); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+
+	// flip a byte in the data of the first object
+	data := bundle.Bytes()
+	data[len(data)-1500] ^= 0xff
+
+	dst := mem.New("dst", 1<<20)
+
+	imported, err := mod.ImportBundle(ctx, bytes.NewReader(data), dst)
+	if err == nil {
+		t.Fatal("import of a tampered bundle succeeded")
+	}
+	if len(imported) != 1 || !imported[0].IsEqual(b) {
+		t.Fatalf("imported %v, want only %v", imported, b)
+	}
+	if has, _ := dst.Contains(ctx, a); has {
+		t.Error("tampered object was imported")
+	}
+}
@@ -0,0 +1,131 @@
+package objects
+
+import (
+	"errors"
+	"io"
+
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/mod/objects"
+)
+
+// cacheRepo returns the repository that caches network reads or nil if caching is disabled.
+func (mod *Module) cacheRepo() objects.Repository {
+	name := mod.config.Cache.Repo
+	if len(name) == 0 {
+		if mod.config.Cache.Size <= 0 {
+			return nil
+		}
+		name = objects.RepoCache
+	}
+
+	return mod.GetRepository(name)
+}
+
+// readThrough wraps a reader of a whole object read over the network, so that the object is
+// stored in the cache repository once it's read to the end. Partial reads are not cached.
+func (mod *Module) readThrough(ctx *astral.Context, objectID *astral.ObjectID, offset int64, limit int64, r objects.Reader) objects.Reader {
+	if offset != 0 || (limit != 0 && uint64(limit) < objectID.Size) {
+		return r
+	}
+
+	cache := mod.cacheRepo()
+	if cache == nil {
+		return r
+	}
+
+	if maxObject := mod.config.Cache.MaxObject; maxObject > 0 && int64(objectID.Size) > maxObject {
+		return r
+	}
+
+	ctx = ctx.LimitZone(astral.ZoneDevice | astral.ZoneVirtual)
+
+	if has, _ := cache.Contains(ctx, objectID); has {
+		return r
+	}
+
+	w, err := mod.cacheWriter(ctx, cache, int64(objectID.Size))
+	if err != nil {
+		mod.log.Logv(2, "cache %v: %v", objectID, err)
+		return r
+	}
+
+	return &cachingReader{Reader: r, mod: mod, ctx: ctx, cache: cache, objectID: objectID, w: w}
+}
+
+// cacheWriter creates an object in the cache, evicting its least recently read objects if
+// the cache has no room for size bytes.
+func (mod *Module) cacheWriter(ctx *astral.Context, cache objects.Repository, size int64) (objects.Writer, error) {
+	free, err := cache.Free(ctx)
+	if err == nil && free >= 0 && free < size {
+		purged, errPtr := mod.purgeRepository(ctx, cache, size-free)
+		for range purged {
+		}
+		if *errPtr != nil {
+			return nil, *errPtr
+		}
+	}
+
+	return cache.Create(ctx, &objects.CreateOpts{Alloc: int(size)})
+}
+
+// cachingReader copies the data it reads into the cache and commits it at EOF.
+type cachingReader struct {
+	objects.Reader
+	mod      *Module
+	ctx      *astral.Context
+	cache    objects.Repository
+	objectID *astral.ObjectID
+	w        objects.Writer
+}
+
+func (r *cachingReader) Read(p []byte) (n int, err error) {
+	n, err = r.Reader.Read(p)
+
+	if r.w != nil && n > 0 {
+		if _, werr := r.w.Write(p[:n]); werr != nil {
+			r.discard()
+		}
+	}
+
+	if errors.Is(err, io.EOF) && r.w != nil {
+		r.commit()
+	}
+
+	return
+}
+
+func (r *cachingReader) Close() error {
+	r.discard()
+	return r.Reader.Close()
+}
+
+func (r *cachingReader) commit() {
+	w := r.w
+	r.w = nil
+
+	id, err := w.Commit()
+	if err != nil {
+		r.mod.log.Logv(2, "cache %v: %v", r.objectID, err)
+		return
+	}
+
+	// why: the provider is not trusted, data that doesn't match the requested id is dropped
+	if !id.IsEqual(r.objectID) {
+		r.mod.log.Errorv(1, "cache %v: data resolves to %v, dropping", r.objectID, id)
+		_ = r.cache.Delete(r.ctx, id)
+		return
+	}
+
+	// track the object so that it can be evicted in read order
+	err = r.mod.db.Create(id, "")
+	if err != nil {
+		r.mod.log.Error("cache %v: %v", id, err)
+	}
+}
+
+func (r *cachingReader) discard() {
+	if r.w != nil {
+		_ = r.w.Discard()
+		r.w = nil
+	}
+}
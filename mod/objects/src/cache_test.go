@@ -0,0 +1,72 @@
+package objects
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/mod/objects"
+	"github.com/cryptopunkscc/astrald/mod/objects/mem"
+)
+
+// readThroughBytes reads a whole object from repo through the read-through cache.
+func readThroughBytes(t *testing.T, mod *Module, repo objects.Repository, id *astral.ObjectID, offset, limit int64) []byte {
+	t.Helper()
+
+	ctx := astral.NewContext(nil)
+	r, err := repo.Read(ctx, id, offset, limit)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r = mod.readThrough(ctx, id, offset, limit, r)
+	defer r.Close()
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// Whole objects read over the network land in the cache, evicting the least recently read
+// objects when it's full; partial reads are not cached.
+func TestReadThroughCache(t *testing.T) {
+	mod := newTestModule(t)
+	mod.config.Cache = CacheConfig{Size: 250}
+
+	cache := mem.New("cache", 250)
+	mod.repos.Set(objects.RepoCache, cache)
+
+	// an object of another repository read before anything was cached
+	local := mem.New("local", 1<<20)
+	mod.repos.Set(objects.RepoLocal, local)
+	localID := storeBytes(t, local, []byte("local"))
+	if err := mod.db.Create(localID, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	remote := mem.New("remote", 1<<20)
+	first := storeBytes(t, remote, bytes.Repeat([]byte{1}, 150))
+	second := storeBytes(t, remote, bytes.Repeat([]byte{2}, 150))
+
+	readThroughBytes(t, mod, remote, first, 10, 0)
+	if len(scanIDs(t, cache)) != 0 {
+		t.Fatal("partial read was cached")
+	}
+
+	readThroughBytes(t, mod, remote, first, 0, 0)
+	if has, _ := cache.Contains(astral.NewContext(nil), first); !has {
+		t.Fatal("object not cached after a full read")
+	}
+
+	readThroughBytes(t, mod, remote, second, 0, 0)
+	cached := scanIDs(t, cache)
+	if len(cached) != 1 || !cached[0].IsEqual(second) {
+		t.Fatalf("cache holds %v, want only %v", cached, second)
+	}
+	if tracked, err := mod.db.Contains(localID); err != nil || !tracked {
+		t.Fatalf("eviction dropped the tracking row of an object of another repository: %v", err)
+	}
+}
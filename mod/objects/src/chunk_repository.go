@@ -0,0 +1,330 @@
+package objects
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/mod/objects"
+	"github.com/cryptopunkscc/astrald/sig"
+	"gorm.io/gorm"
+)
+
+var _ objects.Repository = &ChunkRepository{}
+var _ objects.Holder = &ChunkRepository{}
+
+// ChunkRepository is a virtual repository that splits committed objects into content-defined
+// chunks and keeps every distinct chunk only once in an underlying store repository. Each stored
+// object gets a ChunkManifest in the store; the chunk list is indexed in the module database.
+// The store is resolved by name on every call, so it may be registered after the chunk repository.
+type ChunkRepository struct {
+	mod      *Module
+	name     string
+	label    string
+	store    string
+	addQueue *sig.Queue[*astral.ObjectID]
+
+	mu   sync.Mutex
+	pins map[string]int // store objects used by writers that are not indexed yet
+}
+
+func NewChunkRepository(mod *Module, name string, label string, store string) *ChunkRepository {
+	return &ChunkRepository{
+		mod:      mod,
+		name:     name,
+		label:    label,
+		store:    store,
+		addQueue: &sig.Queue[*astral.ObjectID]{},
+		pins:     map[string]int{},
+	}
+}
+
+func (repo *ChunkRepository) Label() string {
+	return repo.label
+}
+
+func (repo *ChunkRepository) Create(ctx *astral.Context, opts *objects.CreateOpts) (objects.Writer, error) {
+	store, err := repo.getStore()
+	if err != nil {
+		return nil, err
+	}
+
+	return newChunkWriter(ctx, repo, store), nil
+}
+
+func (repo *ChunkRepository) Contains(ctx *astral.Context, objectID *astral.ObjectID) (bool, error) {
+	return repo.mod.db.ContainsChunkedObject(repo.name, objectID)
+}
+
+// Scan streams IDs of all stored objects from the index. When following, a nil sentinel
+// separates the snapshot from objects committed later.
+func (repo *ChunkRepository) Scan(ctx *astral.Context, follow bool) (<-chan *astral.ObjectID, error) {
+	ch := make(chan *astral.ObjectID)
+
+	var subscribe <-chan *astral.ObjectID
+	if follow {
+		subscribe = sig.Subscribe(ctx, repo.addQueue)
+	}
+
+	go func() {
+		defer close(ch)
+
+		ids, err := repo.mod.db.ListChunkedObjects(repo.name)
+		if err != nil {
+			repo.mod.log.Error("chunks %v: db error: %v", repo.name, err)
+			return
+		}
+
+		for _, id := range ids {
+			if err := sig.Send(ctx, ch, id); err != nil {
+				return
+			}
+		}
+
+		if subscribe == nil {
+			return
+		}
+
+		if err := sig.Send(ctx, ch, nil); err != nil {
+			return
+		}
+
+		for id := range subscribe {
+			if err := sig.Send(ctx, ch, id); err != nil {
+				return
+			}
+		}
+	}()
+
+	return ch, nil
+}
+
+// Delete removes the object from the index along with its manifest and every chunk
+// that no other stored object still references.
+func (repo *ChunkRepository) Delete(ctx *astral.Context, objectID *astral.ObjectID) error {
+	row, err := repo.mod.db.FindChunkedObject(repo.name, objectID)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return objects.ErrNotFound
+	case err != nil:
+		return err
+	}
+
+	chunks, err := repo.mod.db.FindChunks(repo.name, objectID)
+	if err != nil {
+		return err
+	}
+
+	err = repo.mod.db.DeleteChunkedObject(repo.name, objectID)
+	if err != nil {
+		return err
+	}
+
+	store, err := repo.getStore()
+	if err != nil {
+		// the index is already updated; orphaned chunks can be purged from the store later
+		repo.mod.log.Errorv(1, "chunks %v: cannot free chunks of %v: %v", repo.name, objectID, err)
+		return nil
+	}
+
+	repo.release(ctx, store, append(chunks, row.ManifestID)...)
+
+	return nil
+}
+
+// Read returns a reader that concatenates the object's chunks starting at offset.
+// A limit of 0 reads to the end of the object.
+func (repo *ChunkRepository) Read(ctx *astral.Context, objectID *astral.ObjectID, offset int64, limit int64) (objects.Reader, error) {
+	if !ctx.Zone().Is(astral.ZoneVirtual) {
+		return nil, astral.ErrZoneExcluded
+	}
+
+	switch {
+	case offset < 0 || offset > int64(objectID.Size):
+		return nil, objects.ErrOutOfBounds
+	case limit < 0:
+		return nil, objects.ErrOutOfBounds
+	case limit == 0 || offset+limit > int64(objectID.Size):
+		limit = int64(objectID.Size) - offset
+	}
+
+	chunks, err := repo.mod.db.FindChunks(repo.name, objectID)
+	if err != nil {
+		return nil, err
+	}
+	if len(chunks) == 0 && objectID.Size > 0 {
+		return nil, objects.ErrNotFound
+	}
+
+	store, err := repo.getStore()
+	if err != nil {
+		return nil, err
+	}
+
+	// skip chunks that end before the offset
+	for len(chunks) > 0 && offset >= int64(chunks[0].Size) {
+		offset -= int64(chunks[0].Size)
+		chunks = chunks[1:]
+	}
+
+	return &chunkReader{
+		ctx:    ctx.IncludeZone(astral.ZoneDevice),
+		repo:   repo,
+		store:  store,
+		chunks: chunks,
+		offset: offset,
+		limit:  limit,
+	}, nil
+}
+
+func (repo *ChunkRepository) Free(ctx *astral.Context) (int64, error) {
+	store, err := repo.getStore()
+	if err != nil {
+		return -1, err
+	}
+
+	return store.Free(ctx)
+}
+
+// HoldObject protects chunks and manifests in the store from being purged.
+func (repo *ChunkRepository) HoldObject(objectID *astral.ObjectID) bool {
+	if repo.pinned(objectID) {
+		return true
+	}
+
+	held, err := repo.mod.db.IsChunkReferenced(objectID)
+	return err == nil && held
+}
+
+func (repo *ChunkRepository) String() string {
+	return repo.label
+}
+
+// pin protects a store object from release until it is unpinned. Writers pin every chunk they
+// use, including chunks already in the store, until their object is indexed.
+func (repo *ChunkRepository) pin(id *astral.ObjectID) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	repo.pins[id.String()]++
+}
+
+func (repo *ChunkRepository) pinned(id *astral.ObjectID) bool {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	return repo.pins[id.String()] > 0
+}
+
+func (repo *ChunkRepository) unpin(ids ...*astral.ObjectID) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	for _, id := range ids {
+		key := id.String()
+		if repo.pins[key]--; repo.pins[key] <= 0 {
+			delete(repo.pins, key)
+		}
+	}
+}
+
+// release deletes the given store objects unless they are pinned or still referenced by the index.
+func (repo *ChunkRepository) release(ctx *astral.Context, store objects.Repository, ids ...*astral.ObjectID) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	for _, id := range ids {
+		if id == nil || repo.pins[id.String()] > 0 {
+			continue
+		}
+
+		held, err := repo.mod.db.IsChunkReferenced(id)
+		if err != nil || held {
+			continue
+		}
+
+		err = store.Delete(ctx, id)
+		if err != nil && !errors.Is(err, objects.ErrNotFound) {
+			repo.mod.log.Errorv(1, "chunks %v: delete %v: %v", repo.name, id, err)
+		}
+	}
+}
+
+func (repo *ChunkRepository) getStore() (objects.Repository, error) {
+	store := repo.mod.GetRepository(repo.store)
+	if store == nil {
+		return nil, fmt.Errorf("store repository %s not found", repo.store)
+	}
+	return store, nil
+}
+
+func (repo *ChunkRepository) pushAdded(id *astral.ObjectID) {
+	repo.addQueue = repo.addQueue.Push(id)
+}
+
+var _ objects.Reader = &chunkReader{}
+
+// chunkReader reads a window of a chunked object, opening one chunk at a time.
+type chunkReader struct {
+	ctx    *astral.Context
+	repo   *ChunkRepository
+	store  objects.Repository
+	chunks []*astral.ObjectID
+	offset int64 // offset into the first remaining chunk
+	limit  int64 // bytes left to read
+	cur    objects.Reader
+}
+
+func (r *chunkReader) Read(p []byte) (n int, err error) {
+	for {
+		if r.limit <= 0 {
+			return 0, io.EOF
+		}
+
+		if r.cur == nil {
+			if len(r.chunks) == 0 {
+				return 0, io.ErrUnexpectedEOF
+			}
+
+			r.cur, err = r.store.Read(r.ctx, r.chunks[0], r.offset, 0)
+			if err != nil {
+				return 0, fmt.Errorf("read chunk %v: %w", r.chunks[0], err)
+			}
+
+			r.chunks = r.chunks[1:]
+			r.offset = 0
+		}
+
+		if int64(len(p)) > r.limit {
+			p = p[:r.limit]
+		}
+
+		n, err = r.cur.Read(p)
+		r.limit -= int64(n)
+
+		if err == io.EOF {
+			r.cur.Close()
+			r.cur = nil
+			if n == 0 {
+				continue
+			}
+			err = nil
+		}
+
+		return
+	}
+}
+
+func (r *chunkReader) Close() error {
+	r.limit = 0
+	if r.cur != nil {
+		return r.cur.Close()
+	}
+	return nil
+}
+
+func (r *chunkReader) Repo() objects.Repository {
+	return r.repo
+}
@@ -0,0 +1,171 @@
+package objects
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"testing"
+
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/mod/objects"
+	"github.com/cryptopunkscc/astrald/mod/objects/mem"
+)
+
+func testChunkRepository(t *testing.T) (*ChunkRepository, *mem.Repository) {
+	t.Helper()
+
+	mod := newTestModule(t)
+
+	store := mem.New("store", 1<<30)
+	mod.repos.Set("store", store)
+
+	return NewChunkRepository(mod, "chunks", "Chunks", "store"), store
+}
+
+func storeBytes(t *testing.T, repo objects.Repository, data []byte) *astral.ObjectID {
+	t.Helper()
+
+	w, err := repo.Create(astral.NewContext(nil), nil)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	id, err := w.Commit()
+	if err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+	return id
+}
+
+func readBytes(t *testing.T, repo objects.Repository, id *astral.ObjectID, offset, limit int64) []byte {
+	t.Helper()
+
+	r, err := repo.Read(astral.NewContext(nil), id, offset, limit)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	defer r.Close()
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("read all: %v", err)
+	}
+	return data
+}
+
+// An edited copy of a large object reuses most chunks of the original and reads back intact.
+func TestChunkRepository_Dedup(t *testing.T) {
+	repo, store := testChunkRepository(t)
+
+	original := make([]byte, 8<<20)
+	rand.New(rand.NewSource(1)).Read(original)
+
+	edited := bytes.Clone(original)
+	copy(edited[3<<20:], "an edit in the middle of the object")
+
+	id1 := storeBytes(t, repo, original)
+	used := store.Used()
+	id2 := storeBytes(t, repo, edited)
+
+	if grown := store.Used() - used; grown > used/2 {
+		t.Fatalf("second version grew the store by %d of %d bytes", grown, used)
+	}
+
+	if !bytes.Equal(readBytes(t, repo, id1, 0, 0), original) {
+		t.Fatal("original does not read back")
+	}
+	if !bytes.Equal(readBytes(t, repo, id2, 0, 0), edited) {
+		t.Fatal("edited copy does not read back")
+	}
+
+	// delete the original; the shared chunks must survive
+	if err := repo.Delete(astral.NewContext(nil), id1); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if has, _ := repo.Contains(nil, id1); has {
+		t.Fatal("deleted object still listed")
+	}
+	if !bytes.Equal(readBytes(t, repo, id2, 0, 0), edited) {
+		t.Fatal("edited copy broken after deleting the original")
+	}
+}
+
+// Reads honor offset and limit across chunk boundaries.
+func TestChunkRepository_ReadWindow(t *testing.T) {
+	repo, _ := testChunkRepository(t)
+
+	data := make([]byte, 6<<20)
+	rand.New(rand.NewSource(2)).Read(data)
+	id := storeBytes(t, repo, data)
+
+	for _, w := range []struct{ offset, limit int64 }{
+		{0, 10},
+		{1<<20 + 7, 3 << 20},
+		{5 << 20, 0},
+		{int64(len(data)) - 1, 1},
+		{int64(len(data)), 0},
+	} {
+		end := int64(len(data))
+		if w.limit > 0 {
+			end = w.offset + w.limit
+		}
+		if got := readBytes(t, repo, id, w.offset, w.limit); !bytes.Equal(got, data[w.offset:end]) {
+			t.Fatalf("window %d+%d: got %d bytes, want %d", w.offset, w.limit, len(got), end-w.offset)
+		}
+	}
+
+	if _, err := repo.Read(astral.NewContext(nil), id, int64(len(data))+1, 0); err == nil {
+		t.Fatal("read past the end succeeded")
+	}
+}
+
+// Deleting an object while a copy of it is being written keeps the chunks the writer reuses;
+// once the writer discards the copy, the chunks are freed.
+func TestChunkRepository_DeleteWhileWriting(t *testing.T) {
+	repo, store := testChunkRepository(t)
+	ctx := astral.NewContext(nil)
+
+	data := make([]byte, 4<<20)
+	rand.New(rand.NewSource(3)).Read(data)
+
+	id := storeBytes(t, repo, data)
+
+	for _, commit := range []bool{true, false} {
+		w, err := repo.Create(ctx, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// the chunks found while writing already exist, so the writer skips them
+		if _, err := w.Write(data); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+
+		// delete the stored object while the copy is still being written
+		if err := repo.Delete(ctx, id); err != nil {
+			t.Fatalf("delete: %v", err)
+		}
+		for _, chunk := range w.(*chunkWriter).pinned {
+			if !repo.HoldObject(chunk) {
+				t.Fatalf("chunk %v pinned by the writer is not held", chunk)
+			}
+		}
+
+		if !commit {
+			w.Discard()
+			if used := store.Used(); used != 0 {
+				t.Fatalf("discarded copy left %d bytes in the store", used)
+			}
+			break
+		}
+
+		if _, err := w.Commit(); err != nil {
+			t.Fatalf("commit: %v", err)
+		}
+		if !bytes.Equal(readBytes(t, repo, id, 0, 0), data) {
+			t.Fatal("copy written during the delete does not read back")
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package objects
+
+import (
+	"bytes"
+	"sync/atomic"
+
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/mod/objects"
+)
+
+var _ objects.Writer = &chunkWriter{}
+
+// chunkWriter splits written data into chunks and stores each new chunk in the store as soon
+// as its boundary is found, so memory use is bounded by the maximum chunk size.
+type chunkWriter struct {
+	ctx      *astral.Context
+	repo     *ChunkRepository
+	store    objects.Repository
+	chunker  *chunker
+	resolver *astral.WriteResolver
+	chunks   []*astral.ObjectID
+	pinned   []*astral.ObjectID // store objects pinned until the object is indexed
+	closed   atomic.Bool
+}
+
+func newChunkWriter(ctx *astral.Context, repo *ChunkRepository, store objects.Repository) *chunkWriter {
+	w := &chunkWriter{
+		ctx:      ctx,
+		repo:     repo,
+		store:    store,
+		resolver: astral.NewWriteResolver(nil),
+	}
+
+	w.chunker = newChunker(defaultChunkMin, defaultChunkBits, defaultChunkMax, w.storeChunk)
+
+	return w
+}
+
+func (w *chunkWriter) Write(p []byte) (n int, err error) {
+	if w.closed.Load() {
+		return 0, objects.ErrClosedPipe
+	}
+
+	n, err = w.chunker.Write(p)
+	w.resolver.Write(p[:n])
+
+	return
+}
+
+// Commit stores the final chunk and the manifest, then indexes the object.
+func (w *chunkWriter) Commit() (*astral.ObjectID, error) {
+	if !w.closed.CompareAndSwap(false, true) {
+		return nil, objects.ErrClosedPipe
+	}
+
+	err := w.chunker.Flush()
+	if err != nil {
+		w.discard()
+		return nil, err
+	}
+
+	objectID := w.resolver.Resolve()
+
+	manifestID, err := w.storeManifest(&objects.ChunkManifest{
+		ObjectID: objectID,
+		Chunks:   w.chunks,
+	})
+	if err != nil {
+		w.discard()
+		return nil, err
+	}
+
+	err = w.repo.mod.db.CreateChunkedObject(w.repo.name, objectID, manifestID, w.chunks)
+	if err != nil {
+		w.discard()
+		return nil, err
+	}
+
+	w.repo.unpin(w.pinned...)
+	w.pinned = nil
+
+	w.repo.pushAdded(objectID)
+
+	return objectID, nil
+}
+
+func (w *chunkWriter) Discard() error {
+	if w.closed.CompareAndSwap(false, true) {
+		w.discard()
+	}
+	return nil
+}
+
+// discard releases the pinned store objects that no indexed object references.
+func (w *chunkWriter) discard() {
+	w.repo.unpin(w.pinned...)
+	w.repo.release(w.ctx, w.store, w.pinned...)
+	w.pinned = nil
+}
+
+// pin protects a store object used by the writer from concurrent deletes and discards.
+func (w *chunkWriter) pin(id *astral.ObjectID) {
+	w.repo.pin(id)
+	w.pinned = append(w.pinned, id)
+}
+
+// storeChunk writes a chunk to the store unless an identical chunk is already there. The chunk
+// is pinned before the check, so an existing chunk cannot be released before Commit.
+func (w *chunkWriter) storeChunk(data []byte) error {
+	chunkID, err := astral.Resolve(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	w.chunks = append(w.chunks, chunkID)
+	w.pin(chunkID)
+
+	if has, err := w.store.Contains(w.ctx, chunkID); err == nil && has {
+		return nil
+	}
+
+	sw, err := w.store.Create(w.ctx, &objects.CreateOpts{Alloc: len(data)})
+	if err != nil {
+		return err
+	}
+
+	_, err = sw.Write(data)
+	if err != nil {
+		sw.Discard()
+		return err
+	}
+
+	_, err = sw.Commit()
+
+	return err
+}
+
+// storeManifest pins the manifest and writes it to the store.
+func (w *chunkWriter) storeManifest(manifest *objects.ChunkManifest) (*astral.ObjectID, error) {
+	var buf bytes.Buffer
+	_, err := astral.Encode(&buf, manifest, astral.WithEncoder(astral.CanonicalTypeEncoder))
+	if err != nil {
+		return nil, err
+	}
+
+	manifestID, err := astral.Resolve(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		return nil, err
+	}
+	w.pin(manifestID)
+
+	sw, err := w.store.Create(w.ctx, &objects.CreateOpts{Alloc: buf.Len()})
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = sw.Write(buf.Bytes())
+	if err != nil {
+		sw.Discard()
+		return nil, err
+	}
+
+	return sw.Commit()
+}
@@ -0,0 +1,85 @@
+package objects
+
+const (
+	defaultChunkMin  = 256 << 10 // 256 KiB
+	defaultChunkBits = 20        // ~1 MiB average
+	defaultChunkMax  = 4 << 20   // 4 MiB
+)
+
+// gear maps every byte value to a pseudo-random 64-bit word for the rolling hash.
+// The table must never change, or previously stored objects will stop deduplicating.
+var gear [256]uint64
+
+// chunker splits a byte stream into content-defined chunks using a gear rolling hash.
+// A boundary is placed where the low bits of the hash are zero, so an edit only
+// changes the chunks around it and the rest of the stream keeps its chunk IDs.
+type chunker struct {
+	min  int
+	max  int
+	mask uint64
+	emit func([]byte) error
+
+	buf  []byte
+	hash uint64
+}
+
+func newChunker(min int, bits int, max int, emit func([]byte) error) *chunker {
+	return &chunker{
+		min:  min,
+		max:  max,
+		mask: 1<<bits - 1,
+		emit: emit,
+		buf:  make([]byte, 0, max),
+	}
+}
+
+// Write feeds p into the chunker, emitting every chunk completed along the way.
+func (c *chunker) Write(p []byte) (n int, err error) {
+	for _, b := range p {
+		c.buf = append(c.buf, b)
+		n++
+
+		if len(c.buf) < c.min {
+			continue
+		}
+
+		c.hash = c.hash<<1 + gear[b]
+
+		if c.hash&c.mask != 0 && len(c.buf) < c.max {
+			continue
+		}
+
+		if err = c.cut(); err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// Flush emits the remaining buffered bytes as the final chunk.
+func (c *chunker) Flush() error {
+	if len(c.buf) == 0 {
+		return nil
+	}
+	return c.cut()
+}
+
+func (c *chunker) cut() error {
+	err := c.emit(c.buf)
+	c.buf = c.buf[:0]
+	c.hash = 0
+	return err
+}
+
+func init() {
+	// splitmix64 with a fixed seed keeps the table stable across builds
+	var x uint64 = 0x61737472616c64 // "astrald"
+	for i := range gear {
+		x += 0x9e3779b97f4a7c15
+		z := x
+		z = (z ^ z>>30) * 0xbf58476d1ce4e5b9
+		z = (z ^ z>>27) * 0x94d049bb133111eb
+		gear[i] = z ^ z>>31
+	}
+}
@@ -1,5 +1,7 @@
 package objects
 
+import "time"
+
 const (
 	methodPut      = "objects.put"
 	methodRead     = "objects.read"
@@ -17,6 +19,116 @@ const (
 
 type Config struct {
 	DefaultMemSize int64
+
+	// Chunks configures chunk repositories by name
+	Chunks map[string]ChunkRepoConfig
+
+	// Encrypted configures encrypted repositories by name
+	Encrypted map[string]EncryptedRepoConfig
+
+	// Erasure configures erasure-coded repositories by name
+	Erasure map[string]ErasureRepoConfig
+
+	// Remote configures repositories of other nodes mounted in the network group by name
+	Remote map[string]RemoteRepoConfig
+
+	// Quotas limits the size of repositories by name. Repositories over their high-water mark
+	// are purged in read order (least recently read first) down to their low-water mark.
+	Quotas map[string]QuotaConfig
+
+	// QuotaInterval is how often repository usage is checked against quotas
+	QuotaInterval time.Duration
+
+	// Scrub configures periodic integrity checks of repositories
+	Scrub ScrubConfig
+
+	// TextIndex configures the full-text index used by search
+	TextIndex TextIndexConfig
+
+	// Cache configures the cache of objects read over the network
+	Cache CacheConfig
+}
+
+type ChunkRepoConfig struct {
+	Label string
+	Store string // name of the repository that holds the chunks and manifests
+}
+
+type EncryptedRepoConfig struct {
+	Label string
+	Store string // name of the repository that holds the ciphertext
+	Key   string // optional: public key (type:hex) of a private key known to mod/crypto; defaults to the node key
+}
+
+type ErasureRepoConfig struct {
+	Label  string
+	Data   int      // number of data shards an object is split into (default 4)
+	Parity int      // number of parity shards, i.e. lost shards the object survives (default 2)
+	Repo   string   // name of the repository that holds the shards on every node (default "local")
+	Nodes  []string // optional: identities or aliases of the nodes that hold shards; defaults to the local swarm
+	Strict bool     // refuse to store objects when the nodes cannot survive the loss of Parity of them
 }
 
-var defaultConfig = Config{}
+type RemoteRepoConfig struct {
+	Label string
+	Node  string // identity or alias of the node
+	Repo  string // name of the repository on the node (default "local")
+}
+
+type QuotaConfig struct {
+	Size      int64 // bytes of objects the repository may hold
+	HighWater int   // percent of Size at which a purge starts (default 100)
+	LowWater  int   // percent of Size a purge brings usage down to (default 90)
+}
+
+// high returns the usage in bytes at which a purge starts
+func (cfg QuotaConfig) high() int64 {
+	if cfg.HighWater <= 0 {
+		return cfg.Size
+	}
+	return cfg.Size * int64(cfg.HighWater) / 100
+}
+
+// low returns the usage in bytes a purge aims for
+func (cfg QuotaConfig) low() int64 {
+	if cfg.LowWater <= 0 {
+		return min(cfg.Size*90/100, cfg.high())
+	}
+	return min(cfg.Size*int64(cfg.LowWater)/100, cfg.high())
+}
+
+type ScrubConfig struct {
+	Repos          []string      // repositories verified periodically
+	Interval       time.Duration // time between passes over a repository
+	Rate           int64         // maximum bytes per second read while scrubbing, 0 for no limit
+	Quarantine     bool          // remove corrupt objects from the repository
+	QuarantineRepo string        // optional: repository that keeps the damaged data of removed objects
+	Repair         bool          // replace removed objects with good copies from providers
+}
+
+type TextIndexConfig struct {
+	Repos   []string // repositories whose objects are indexed
+	MaxText int64    // maximum bytes of text content indexed per object
+}
+
+type CacheConfig struct {
+	Repo      string // optional: repository that caches network reads; defaults to a memory repository of Size bytes
+	Size      int64  // size of the default cache repository, 0 with no Repo disables the cache
+	MaxObject int64  // maximum size of a cached object, 0 for no limit
+}
+
+var defaultConfig = Config{
+	QuotaInterval: time.Minute,
+	Scrub: ScrubConfig{
+		Interval: 7 * 24 * time.Hour,
+		Rate:     16 << 20,
+	},
+	TextIndex: TextIndexConfig{
+		Repos:   []string{"local"},
+		MaxText: 1 << 20,
+	},
+	Cache: CacheConfig{
+		Size:      256 << 20,
+		MaxObject: 64 << 20,
+	},
+}
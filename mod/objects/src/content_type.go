@@ -0,0 +1,128 @@
+package objects
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/mod/objects"
+)
+
+const sniffLen = 512 // number of bytes used to sniff the content type
+
+// signatures of formats http.DetectContentType doesn't know
+var sniffSignatures = []struct {
+	offset int
+	magic  string
+	mime   string
+}{
+	{0, "fLaC", "audio/flac"},
+	{0, "BZh", "application/x-bzip2"},
+	{0, "\xfd7zXZ\x00", "application/x-xz"},
+	{0, "7z\xbc\xaf\x27\x1c", "application/x-7z-compressed"},
+	{257, "ustar", "application/x-tar"},
+}
+
+// sniffContentType returns the MIME type of data, extending http.DetectContentType with a few
+// more binary formats and with SVG and JSON text.
+func sniffContentType(data []byte) string {
+	for _, sig := range sniffSignatures {
+		if len(data) >= sig.offset+len(sig.magic) && string(data[sig.offset:sig.offset+len(sig.magic)]) == sig.magic {
+			return sig.mime
+		}
+	}
+
+	mime := http.DetectContentType(data)
+
+	switch {
+	case strings.HasPrefix(mime, "text/xml") && bytes.Contains(data, []byte("<svg")):
+		return "image/svg+xml"
+
+	case strings.HasPrefix(mime, "text/plain"):
+		trimmed := bytes.TrimLeft(data, " \t\r\n")
+		if len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') {
+			return "application/json"
+		}
+	}
+
+	return mime
+}
+
+// ContentType returns the MIME type of an object sniffed from its first bytes. Astral objects
+// are reported as application/octet-stream.
+func (mod *Module) ContentType(ctx *astral.Context, objectID *astral.ObjectID) (string, error) {
+	mime, err := mod.sniff(ctx, objectID)
+	if err != nil {
+		return "", err
+	}
+
+	if len(mime) == 0 {
+		return "application/octet-stream", nil
+	}
+	return mime, nil
+}
+
+// sniff returns the MIME type of a raw object or an empty string for an astral object. The result
+// is recorded, so every object is read once.
+func (mod *Module) sniff(ctx *astral.Context, objectID *astral.ObjectID) (string, error) {
+	mime, found, err := mod.db.FindContentType(objectID)
+	if err == nil && found {
+		return mime, nil
+	}
+
+	r, err := mod.ReadDefault().Read(ctx, objectID, 0, sniffLen)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := (&astral.Stamp{}).ReadFrom(bytes.NewReader(data)); err != nil {
+		mime = sniffContentType(data)
+	}
+
+	err = mod.db.SaveContentType(objectID, mime)
+	if err != nil {
+		mod.log.Error("save content type of %v: %v", objectID, err)
+	}
+
+	return mime, nil
+}
+
+var _ objects.Describer = &contentTypeDescriber{}
+
+// contentTypeDescriber describes raw objects held on the device with their content type. Astral
+// objects are left to the describers of their types.
+type contentTypeDescriber struct {
+	mod *Module
+}
+
+func (d *contentTypeDescriber) DescribeObject(ctx *astral.Context, objectID *astral.ObjectID) (<-chan *objects.Descriptor, error) {
+	if !ctx.Zone().Is(astral.ZoneDevice) {
+		return nil, astral.ErrZoneExcluded
+	}
+
+	mime, err := d.mod.sniff(ctx.WithZone(astral.ZoneDevice|astral.ZoneVirtual), objectID)
+	if err != nil {
+		return nil, err
+	}
+
+	var results = make(chan *objects.Descriptor, 1)
+	defer close(results)
+
+	if len(mime) > 0 {
+		results <- &objects.Descriptor{
+			SourceID: d.mod.node.Identity(),
+			ObjectID: objectID,
+			Data:     &objects.ContentType{Mime: astral.String8(mime)},
+		}
+	}
+
+	return results, nil
+}
@@ -0,0 +1,71 @@
+package objects
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/mod/objects"
+	"github.com/cryptopunkscc/astrald/mod/objects/mem"
+)
+
+func TestSniffContentType(t *testing.T) {
+	tar := make([]byte, 512)
+	copy(tar[257:], "ustar")
+
+	tests := []struct {
+		data []byte
+		want string
+	}{
+		{[]byte("\x89PNG\r\n\x1a\n"), "image/png"},
+		{[]byte("fLaC\x00\x00\x00\x22"), "audio/flac"},
+		{tar, "application/x-tar"},
+		{[]byte(`<?xml version="1.0"?><svg xmlns="http://www.w3.org/2000/svg"/>`), "image/svg+xml"},
+		{[]byte("  {\"a\": 1}"), "application/json"},
+		{[]byte("hello"), "text/plain; charset=utf-8"},
+	}
+
+	for _, test := range tests {
+		if got := sniffContentType(test.data); got != test.want {
+			t.Errorf("sniff %q: got %v, want %v", test.data[:min(len(test.data), 16)], got, test.want)
+		}
+	}
+}
+
+// Raw objects are described with their content type, astral objects are not.
+func TestContentTypeDescriber(t *testing.T) {
+	mod := testNameModule(t)
+	repo := mem.New("local", 1<<20)
+	mod.repos.Set(objects.RepoMain, repo)
+
+	ctx := astral.NewContext(nil).WithZone(astral.ZoneDevice)
+	d := &contentTypeDescriber{mod: mod}
+
+	raw := storeBytes(t, repo, []byte("GIF89a"))
+	descs, err := d.DescribeObject(ctx, raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	desc := <-descs
+	if desc == nil || desc.Data.(*objects.ContentType).Mime != "image/gif" {
+		t.Fatalf("got %v, want image/gif", desc)
+	}
+
+	var buf bytes.Buffer
+	if _, err := astral.Encode(&buf, &astral.Ack{}, astral.WithEncoder(astral.CanonicalTypeEncoder)); err != nil {
+		t.Fatal(err)
+	}
+	obj := storeBytes(t, repo, buf.Bytes())
+
+	descs, err = d.DescribeObject(ctx, obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if desc := <-descs; desc != nil {
+		t.Fatalf("astral object described as %v", desc.Data)
+	}
+
+	if mime, _ := mod.ContentType(ctx, obj); mime != "application/octet-stream" {
+		t.Fatalf("got %v for an astral object", mime)
+	}
+}
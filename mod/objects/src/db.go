@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/mod/objects"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
@@ -13,7 +14,7 @@ type DB struct {
 }
 
 func (db *DB) Migrate() error {
-	return db.AutoMigrate(&dbObject{})
+	return db.AutoMigrate(&dbObject{}, &dbChunkedObject{}, &dbChunk{}, &dbEncryptedObject{}, &dbHashTree{}, &dbFetch{}, &dbScrub{}, &dbQuarantined{}, &dbReference{}, &dbExtracted{}, &dbPin{}, &dbName{}, &dbErasureObject{}, &dbErasureShard{}, &dbShardHold{}, &dbContentType{})
 }
 
 func (db *DB) Contains(id *astral.ObjectID) (b bool, err error) {
@@ -131,3 +132,227 @@ func (db *DB) FindByType(objectType string) (rows []*dbObject, err error) {
 		Find(&rows).Error
 	return
 }
+
+// CreateChunkedObject records objectID as stored in repo, split into chunks described by manifestID.
+func (db *DB) CreateChunkedObject(repo string, objectID *astral.ObjectID, manifestID *astral.ObjectID, chunks []*astral.ObjectID) error {
+	return db.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("repo = ? AND object_id = ?", repo, objectID).Delete(&dbChunk{}).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(&dbChunkedObject{
+			Repo:       repo,
+			ObjectID:   objectID,
+			ManifestID: manifestID,
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		if len(chunks) == 0 {
+			return nil
+		}
+
+		rows := make([]*dbChunk, len(chunks))
+		for i, chunkID := range chunks {
+			rows[i] = &dbChunk{Repo: repo, ObjectID: objectID, Seq: i, ChunkID: chunkID}
+		}
+
+		return tx.CreateInBatches(rows, 500).Error
+	})
+}
+
+// FindChunkedObject returns the row of a chunked object stored in repo.
+func (db *DB) FindChunkedObject(repo string, objectID *astral.ObjectID) (row *dbChunkedObject, err error) {
+	err = db.
+		Where("repo = ? AND object_id = ?", repo, objectID).
+		First(&row).Error
+	return
+}
+
+// ContainsChunkedObject checks if repo holds objectID.
+func (db *DB) ContainsChunkedObject(repo string, objectID *astral.ObjectID) (b bool, err error) {
+	err = db.
+		Model(&dbChunkedObject{}).
+		Where("repo = ? AND object_id = ?", repo, objectID).
+		Select("count(*)>0").
+		First(&b).Error
+	return
+}
+
+// ListChunkedObjects returns IDs of all objects stored in repo.
+func (db *DB) ListChunkedObjects(repo string) (ids []*astral.ObjectID, err error) {
+	err = db.
+		Model(&dbChunkedObject{}).
+		Where("repo = ?", repo).
+		Pluck("object_id", &ids).Error
+	return
+}
+
+// FindChunks returns the chunks of an object in order.
+func (db *DB) FindChunks(repo string, objectID *astral.ObjectID) (chunks []*astral.ObjectID, err error) {
+	err = db.
+		Model(&dbChunk{}).
+		Where("repo = ? AND object_id = ?", repo, objectID).
+		Order("seq").
+		Pluck("chunk_id", &chunks).Error
+	return
+}
+
+// DeleteChunkedObject removes an object and its chunk list from repo.
+func (db *DB) DeleteChunkedObject(repo string, objectID *astral.ObjectID) error {
+	return db.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("repo = ? AND object_id = ?", repo, objectID).Delete(&dbChunk{}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Where("repo = ? AND object_id = ?", repo, objectID).Delete(&dbChunkedObject{}).Error
+	})
+}
+
+// IsChunkReferenced checks if any chunked object (in any repo) references id as a chunk or a manifest.
+func (db *DB) IsChunkReferenced(id *astral.ObjectID) (b bool, err error) {
+	err = db.
+		Model(&dbChunk{}).
+		Where("chunk_id = ?", id).
+		Select("count(*)>0").
+		First(&b).Error
+	if err != nil || b {
+		return
+	}
+
+	err = db.
+		Model(&dbChunkedObject{}).
+		Where("manifest_id = ?", id).
+		Select("count(*)>0").
+		First(&b).Error
+	return
+}
+
+// ContainsEncryptedObject checks if an encrypted repo holds objectID.
+func (db *DB) ContainsEncryptedObject(repo string, objectID *astral.ObjectID) (b bool, err error) {
+	err = db.
+		Model(&dbEncryptedObject{}).
+		Where("repo = ? AND object_id = ?", repo, objectID).
+		Select("count(*)>0").
+		First(&b).Error
+	return
+}
+
+// FindEncryptedObject returns the row of an object stored in an encrypted repo.
+func (db *DB) FindEncryptedObject(repo string, objectID *astral.ObjectID) (row *dbEncryptedObject, err error) {
+	err = db.
+		Where("repo = ? AND object_id = ?", repo, objectID).
+		First(&row).Error
+	return
+}
+
+// CreateEncryptedObject records that objectID is stored in repo as the ciphertext storedID.
+func (db *DB) CreateEncryptedObject(repo string, objectID *astral.ObjectID, storedID *astral.ObjectID) error {
+	return db.DB.Clauses(clause.OnConflict{UpdateAll: true}).Create(&dbEncryptedObject{
+		Repo:     repo,
+		ObjectID: objectID,
+		StoredID: storedID,
+	}).Error
+}
+
+// ListEncryptedObjects returns plaintext IDs of all objects stored in repo.
+func (db *DB) ListEncryptedObjects(repo string) (ids []*astral.ObjectID, err error) {
+	err = db.
+		Model(&dbEncryptedObject{}).
+		Where("repo = ?", repo).
+		Pluck("object_id", &ids).Error
+	return
+}
+
+// DeleteEncryptedObject removes the mapping of objectID in repo.
+func (db *DB) DeleteEncryptedObject(repo string, objectID *astral.ObjectID) error {
+	return db.
+		Where("repo = ? AND object_id = ?", repo, objectID).
+		Delete(&dbEncryptedObject{}).Error
+}
+
+// IsCiphertextReferenced checks if any encrypted repo stores an object as storedID.
+func (db *DB) IsCiphertextReferenced(storedID *astral.ObjectID) (b bool, err error) {
+	err = db.
+		Model(&dbEncryptedObject{}).
+		Where("stored_id = ?", storedID).
+		Select("count(*)>0").
+		First(&b).Error
+	return
+}
+
+// FindHashTree returns the cached hash tree of an object.
+func (db *DB) FindHashTree(objectID *astral.ObjectID) (row *dbHashTree, err error) {
+	err = db.
+		Where("object_id = ?", objectID).
+		First(&row).Error
+	return
+}
+
+// CreateHashTree caches the hash tree of an object.
+func (db *DB) CreateHashTree(tree *objects.HashTree) error {
+	return db.DB.Clauses(clause.OnConflict{UpdateAll: true}).Create(&dbHashTree{
+		ObjectID:  tree.ObjectID,
+		BlockSize: uint32(tree.BlockSize),
+		Leaves:    tree.Leaves,
+	}).Error
+}
+
+// FindFetch returns the state of an unfinished fetch.
+func (db *DB) FindFetch(source string) (row *dbFetch, err error) {
+	err = db.
+		Where("source = ?", source).
+		First(&row).Error
+	return
+}
+
+// ListFetches returns the state of all unfinished fetches.
+func (db *DB) ListFetches() (rows []*dbFetch, err error) {
+	err = db.DB.Find(&rows).Error
+	return
+}
+
+// SaveFetch creates or updates the state of a fetch.
+func (db *DB) SaveFetch(row *dbFetch) error {
+	return db.DB.Save(row).Error
+}
+
+// DeleteFetch removes the state of a fetch.
+func (db *DB) DeleteFetch(source string) error {
+	return db.DB.Where("source = ?", source).Delete(&dbFetch{}).Error
+}
+
+// FindScrub returns the scrub state of a repository.
+func (db *DB) FindScrub(repo string) (row *dbScrub, err error) {
+	err = db.
+		Where("repo = ?", repo).
+		First(&row).Error
+	return
+}
+
+// SaveScrub creates or updates the scrub state of a repository.
+func (db *DB) SaveScrub(row *dbScrub) error {
+	return db.DB.Save(row).Error
+}
+
+// CreateQuarantined records a corrupt object removed from a repository.
+func (db *DB) CreateQuarantined(repo string, objectID *astral.ObjectID, storedID *astral.ObjectID) error {
+	return db.DB.Clauses(clause.OnConflict{UpdateAll: true}).Create(&dbQuarantined{
+		Repo:       repo,
+		ObjectID:   objectID,
+		StoredID:   storedID,
+		DetectedAt: time.Now(),
+	}).Error
+}
+
+// IsQuarantined checks if id is held in the quarantine repository.
+func (db *DB) IsQuarantined(id *astral.ObjectID) (b bool, err error) {
+	err = db.
+		Model(&dbQuarantined{}).
+		Where("stored_id = ?", id).
+		Select("count(*)>0").
+		First(&b).Error
+	return
+}
@@ -0,0 +1,25 @@
+package objects
+
+import (
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/mod/objects"
+)
+
+// dbChunkedObject records an object stored by a chunk repository together with its manifest.
+type dbChunkedObject struct {
+	Repo       string           `gorm:"primaryKey"`
+	ObjectID   *astral.ObjectID `gorm:"primaryKey"`
+	ManifestID *astral.ObjectID `gorm:"index"`
+}
+
+func (dbChunkedObject) TableName() string { return objects.DBPrefix + "chunked_objects" }
+
+// dbChunk is a single chunk of a chunked object. Seq orders the chunks within the object.
+type dbChunk struct {
+	Repo     string           `gorm:"primaryKey"`
+	ObjectID *astral.ObjectID `gorm:"primaryKey"`
+	Seq      int              `gorm:"primaryKey"`
+	ChunkID  *astral.ObjectID `gorm:"index"`
+}
+
+func (dbChunk) TableName() string { return objects.DBPrefix + "chunks" }
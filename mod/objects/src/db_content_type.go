@@ -0,0 +1,33 @@
+package objects
+
+import (
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/mod/objects"
+	"gorm.io/gorm/clause"
+)
+
+// dbContentType records the sniffed MIME type of an object. Mime is empty for astral objects.
+type dbContentType struct {
+	ObjectID *astral.ObjectID `gorm:"primaryKey"`
+	Mime     string
+}
+
+func (dbContentType) TableName() string { return objects.DBPrefix + "content_types" }
+
+// FindContentType returns the recorded MIME type of an object. Found is false if the object was
+// not sniffed yet.
+func (db *DB) FindContentType(objectID *astral.ObjectID) (mime string, found bool, err error) {
+	var rows []dbContentType
+	err = db.Where("object_id = ?", objectID).Limit(1).Find(&rows).Error
+	if err != nil || len(rows) == 0 {
+		return
+	}
+	return rows[0].Mime, true, nil
+}
+
+func (db *DB) SaveContentType(objectID *astral.ObjectID, mime string) error {
+	return db.Clauses(clause.OnConflict{UpdateAll: true}).Create(&dbContentType{
+		ObjectID: objectID,
+		Mime:     mime,
+	}).Error
+}
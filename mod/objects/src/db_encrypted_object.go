@@ -0,0 +1,16 @@
+package objects
+
+import (
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/mod/objects"
+)
+
+// dbEncryptedObject maps the plaintext ID of an object in an encrypted repository to the ID
+// of its ciphertext in the store.
+type dbEncryptedObject struct {
+	Repo     string           `gorm:"primaryKey"`
+	ObjectID *astral.ObjectID `gorm:"primaryKey"`
+	StoredID *astral.ObjectID `gorm:"index"`
+}
+
+func (dbEncryptedObject) TableName() string { return objects.DBPrefix + "encrypted_objects" }
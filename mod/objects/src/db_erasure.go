@@ -0,0 +1,159 @@
+package objects
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"time"
+
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/mod/objects"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// dbErasureObject records an object stored by an erasure-coded repository.
+type dbErasureObject struct {
+	Repo     string           `gorm:"primaryKey"`
+	ObjectID *astral.ObjectID `gorm:"primaryKey"`
+	Data     int
+	Parity   int
+}
+
+func (dbErasureObject) TableName() string { return objects.DBPrefix + "erasure_objects" }
+
+// dbErasureShard records where a single shard of an erasure-coded object is placed. Shards that
+// failed to be stored have no row.
+type dbErasureShard struct {
+	Repo     string           `gorm:"primaryKey"`
+	ObjectID *astral.ObjectID `gorm:"primaryKey"`
+	Index    int              `gorm:"primaryKey"`
+	ShardID  *astral.ObjectID `gorm:"index"`
+	Node     *astral.Identity
+	Blocks   []byte // concatenated sha256 hashes of the shard's blocks
+}
+
+// verifyBlock checks a block of the shard against its recorded hash. Shards recorded without
+// hashes are not checked.
+func (row *dbErasureShard) verifyBlock(index int64, block []byte) error {
+	if len(row.Blocks) == 0 {
+		return nil
+	}
+	if (index+1)*sha256.Size > int64(len(row.Blocks)) {
+		return objects.ErrOutOfBounds
+	}
+
+	if sum := sha256.Sum256(block); !bytes.Equal(sum[:], row.Blocks[index*sha256.Size:(index+1)*sha256.Size]) {
+		return objects.ErrHashMismatch
+	}
+
+	return nil
+}
+
+func (dbErasureShard) TableName() string { return objects.DBPrefix + "erasure_shards" }
+
+// dbShardHold records a shard this node stores for the erasure-coded repository of another node.
+type dbShardHold struct {
+	Holder    *astral.Identity `gorm:"primaryKey"`
+	ShardID   *astral.ObjectID `gorm:"primaryKey;index"`
+	CreatedAt time.Time
+}
+
+func (dbShardHold) TableName() string { return objects.DBPrefix + "shard_holds" }
+
+// CreateErasureObject records objectID as stored in repo along with the placement of its shards.
+func (db *DB) CreateErasureObject(row *dbErasureObject, shards []*dbErasureShard) error {
+	return db.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("repo = ? AND object_id = ?", row.Repo, row.ObjectID).Delete(&dbErasureShard{}).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(row).Error
+		if err != nil || len(shards) == 0 {
+			return err
+		}
+
+		return tx.Create(shards).Error
+	})
+}
+
+// FindErasureObject returns the row of an erasure-coded object stored in repo.
+func (db *DB) FindErasureObject(repo string, objectID *astral.ObjectID) (row *dbErasureObject, err error) {
+	err = db.
+		Where("repo = ? AND object_id = ?", repo, objectID).
+		First(&row).Error
+	return
+}
+
+// ContainsErasureObject checks if repo holds objectID.
+func (db *DB) ContainsErasureObject(repo string, objectID *astral.ObjectID) (b bool, err error) {
+	err = db.
+		Model(&dbErasureObject{}).
+		Where("repo = ? AND object_id = ?", repo, objectID).
+		Select("count(*)>0").
+		First(&b).Error
+	return
+}
+
+// ListErasureObjects returns IDs of all objects stored in repo.
+func (db *DB) ListErasureObjects(repo string) (ids []*astral.ObjectID, err error) {
+	err = db.
+		Model(&dbErasureObject{}).
+		Where("repo = ?", repo).
+		Pluck("object_id", &ids).Error
+	return
+}
+
+// FindErasureShards returns the stored shards of an object ordered by index.
+func (db *DB) FindErasureShards(repo string, objectID *astral.ObjectID) (shards []*dbErasureShard, err error) {
+	err = db.
+		Where("repo = ? AND object_id = ?", repo, objectID).
+		Order("`index`").
+		Find(&shards).Error
+	return
+}
+
+// DeleteErasureObject removes an object and its shard placement from repo.
+func (db *DB) DeleteErasureObject(repo string, objectID *astral.ObjectID) error {
+	return db.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("repo = ? AND object_id = ?", repo, objectID).Delete(&dbErasureShard{}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Where("repo = ? AND object_id = ?", repo, objectID).Delete(&dbErasureObject{}).Error
+	})
+}
+
+// IsErasureShard checks if any erasure-coded object (in any repo) has id as a shard.
+func (db *DB) IsErasureShard(id *astral.ObjectID) (b bool, err error) {
+	err = db.
+		Model(&dbErasureShard{}).
+		Where("shard_id = ?", id).
+		Select("count(*)>0").
+		First(&b).Error
+	return
+}
+
+// CreateShardHold records that holder asked this node to keep a shard.
+func (db *DB) CreateShardHold(holder *astral.Identity, shardID *astral.ObjectID) error {
+	return db.DB.
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&dbShardHold{Holder: holder, ShardID: shardID}).Error
+}
+
+// DeleteShardHold removes the hold of holder on a shard.
+func (db *DB) DeleteShardHold(holder *astral.Identity, shardID *astral.ObjectID) error {
+	return db.DB.
+		Where("holder = ? AND shard_id = ?", holder, shardID).
+		Delete(&dbShardHold{}).Error
+}
+
+// IsShardHeld checks if any node asked this node to keep id as a shard.
+func (db *DB) IsShardHeld(id *astral.ObjectID) (b bool, err error) {
+	err = db.
+		Model(&dbShardHold{}).
+		Where("shard_id = ?", id).
+		Select("count(*)>0").
+		First(&b).Error
+	return
+}
@@ -0,0 +1,19 @@
+package objects
+
+import (
+	"time"
+
+	"github.com/cryptopunkscc/astrald/mod/objects"
+)
+
+// dbFetch holds the state of an unfinished objects.fetch. Data fetched so far is kept in
+// a partial file, so that a fetch can continue after a failure or a restart.
+type dbFetch struct {
+	Source    string `gorm:"primaryKey"`
+	Size      uint64 // total size, 0 if unknown
+	Fetched   uint64 // bytes saved in the partial file
+	Validator string // HTTP ETag or Last-Modified of the resource the data came from
+	UpdatedAt time.Time
+}
+
+func (dbFetch) TableName() string { return objects.DBPrefix + "fetches" }
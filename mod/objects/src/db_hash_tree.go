@@ -0,0 +1,15 @@
+package objects
+
+import (
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/mod/objects"
+)
+
+// dbHashTree caches the hash tree of an object, so it is only built once from the full data.
+type dbHashTree struct {
+	ObjectID  *astral.ObjectID `gorm:"primaryKey"`
+	BlockSize uint32
+	Leaves    []byte
+}
+
+func (dbHashTree) TableName() string { return objects.DBPrefix + "hash_trees" }
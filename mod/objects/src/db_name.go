@@ -0,0 +1,90 @@
+package objects
+
+import (
+	"errors"
+	"time"
+
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/mod/crypto"
+	"github.com/cryptopunkscc/astrald/mod/objects"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// dbName holds the latest known verified record of a name published by an identity.
+type dbName struct {
+	Publisher *astral.Identity `gorm:"primaryKey"`
+	Name      string           `gorm:"primaryKey"`
+	ObjectID  *astral.ObjectID
+	Sequence  uint64
+	IssuedAt  time.Time
+	Signature []byte
+}
+
+func (dbName) TableName() string { return objects.DBPrefix + "names" }
+
+// FindName returns the stored record of a name or nil if the name is unknown.
+func (db *DB) FindName(publisher *astral.Identity, name string) (*objects.SignedName, error) {
+	var row dbName
+	err := db.
+		Where("publisher = ? AND name = ?", publisher, name).
+		First(&row).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, nil
+	case err != nil:
+		return nil, err
+	}
+
+	sig, err := astral.DecodeAs[*crypto.Signature](row.Signature)
+	if err != nil {
+		return nil, err
+	}
+
+	return &objects.SignedName{
+		Name: &objects.Name{
+			Publisher: row.Publisher,
+			Name:      astral.String8(row.Name),
+			ObjectID:  row.ObjectID,
+			Sequence:  astral.Uint64(row.Sequence),
+			IssuedAt:  astral.Time(row.IssuedAt),
+		},
+		Signature: sig,
+	}, nil
+}
+
+// SaveName stores a signed name unless a record with the same or a higher sequence is
+// already stored. Returns true if the record was saved.
+func (db *DB) SaveName(signed *objects.SignedName) (saved bool, err error) {
+	sig, err := astral.EncodeBytes(signed.Signature)
+	if err != nil {
+		return false, err
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		var seq uint64
+		var found bool
+		err := tx.
+			Model(&dbName{}).
+			Where("publisher = ? AND name = ?", signed.Publisher, string(signed.Name.Name)).
+			Select("count(*)>0, coalesce(max(sequence), 0)").
+			Row().Scan(&found, &seq)
+		if err != nil {
+			return err
+		}
+		if found && seq >= uint64(signed.Sequence) {
+			return nil
+		}
+
+		saved = true
+		return tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(&dbName{
+			Publisher: signed.Publisher,
+			Name:      string(signed.Name.Name),
+			ObjectID:  signed.ObjectID,
+			Sequence:  uint64(signed.Sequence),
+			IssuedAt:  signed.IssuedAt.Time(),
+			Signature: sig,
+		}).Error
+	})
+	return
+}
@@ -0,0 +1,110 @@
+package objects
+
+import (
+	"time"
+
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/mod/objects"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// dbReference is an edge of the reference graph: the object ObjectID references RefID.
+type dbReference struct {
+	ObjectID *astral.ObjectID `gorm:"primaryKey"`
+	RefID    *astral.ObjectID `gorm:"primaryKey;index"`
+}
+
+func (dbReference) TableName() string { return objects.DBPrefix + "references" }
+
+// dbExtracted marks an object whose references are stored in the reference graph.
+type dbExtracted struct {
+	ObjectID    *astral.ObjectID `gorm:"primaryKey"`
+	ExtractedAt time.Time
+}
+
+func (dbExtracted) TableName() string { return objects.DBPrefix + "extracted" }
+
+// dbPin protects an object and every object it references, directly or indirectly, from purge.
+type dbPin struct {
+	ObjectID *astral.ObjectID `gorm:"primaryKey"`
+	PinnedAt time.Time
+}
+
+func (dbPin) TableName() string { return objects.DBPrefix + "pins" }
+
+// FindReferences returns the stored references of an object. Extracted is false if the references
+// of the object were never extracted.
+func (db *DB) FindReferences(id *astral.ObjectID) (refs []*astral.ObjectID, extracted bool, err error) {
+	err = db.
+		Model(&dbExtracted{}).
+		Where("object_id = ?", id).
+		Select("count(*)>0").
+		First(&extracted).Error
+	if err != nil || !extracted {
+		return
+	}
+
+	err = db.
+		Model(&dbReference{}).
+		Where("object_id = ?", id).
+		Pluck("ref_id", &refs).Error
+	return
+}
+
+// SaveReferences replaces the stored references of an object.
+func (db *DB) SaveReferences(id *astral.ObjectID, refs []*astral.ObjectID) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("object_id = ?", id).Delete(&dbReference{}).Error
+		if err != nil {
+			return err
+		}
+
+		for _, ref := range refs {
+			err = tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&dbReference{
+				ObjectID: id,
+				RefID:    ref,
+			}).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		return tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(&dbExtracted{
+			ObjectID:    id,
+			ExtractedAt: time.Now(),
+		}).Error
+	})
+}
+
+func (db *DB) CreatePin(id *astral.ObjectID) error {
+	return db.Clauses(clause.OnConflict{DoNothing: true}).Create(&dbPin{
+		ObjectID: id,
+		PinnedAt: time.Now(),
+	}).Error
+}
+
+func (db *DB) DeletePin(id *astral.ObjectID) (found bool, err error) {
+	tx := db.Where("object_id = ?", id).Delete(&dbPin{})
+	return tx.RowsAffected > 0, tx.Error
+}
+
+func (db *DB) ListPins() (ids []*astral.ObjectID, err error) {
+	err = db.
+		Model(&dbPin{}).
+		Order("pinned_at").
+		Pluck("object_id", &ids).Error
+	return
+}
+
+// IsPinned returns true if the object is pinned or referenced, directly or indirectly, by a pinned object.
+func (db *DB) IsPinned(id *astral.ObjectID) (pinned bool, err error) {
+	err = db.Raw(`WITH RECURSIVE closure(id) AS (
+			SELECT object_id FROM `+dbPin{}.TableName()+`
+			UNION
+			SELECT r.ref_id FROM `+dbReference{}.TableName()+` r JOIN closure c ON r.object_id = c.id
+		)
+		SELECT count(*)>0 FROM closure WHERE id = ?`, id).
+		Scan(&pinned).Error
+	return
+}
@@ -0,0 +1,32 @@
+package objects
+
+import (
+	"time"
+
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/mod/objects"
+)
+
+// dbScrub holds the state of the last scrub of a repository. A pass in progress has no
+// FinishedAt; it continues after Cursor, the last ObjectID (in text order) it verified.
+type dbScrub struct {
+	Repo       string `gorm:"primaryKey"`
+	Cursor     string
+	Report     []byte // JSON of the ScrubReport so far
+	StartedAt  time.Time
+	FinishedAt *time.Time
+	ReportID   *astral.ObjectID // stored report of the last finished pass
+}
+
+func (dbScrub) TableName() string { return objects.DBPrefix + "scrubs" }
+
+// dbQuarantined records a corrupt object removed from a repository. If a quarantine repository
+// is configured, StoredID is the ID under which the damaged data was kept there.
+type dbQuarantined struct {
+	Repo       string           `gorm:"primaryKey"`
+	ObjectID   *astral.ObjectID `gorm:"primaryKey"`
+	StoredID   *astral.ObjectID
+	DetectedAt time.Time
+}
+
+func (dbQuarantined) TableName() string { return objects.DBPrefix + "quarantined" }
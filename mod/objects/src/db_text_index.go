@@ -0,0 +1,235 @@
+package objects
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/mod/objects"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// textIndexTable is the FTS5 table of the text index. It has one row per indexed object with
+// the text content of the object and the values of its tags.
+const textIndexTable = objects.DBPrefix + "text_index"
+
+// dbTextObject marks an object as indexed in the text index.
+type dbTextObject struct {
+	ObjectID  *astral.ObjectID `gorm:"primaryKey"`
+	IndexedAt time.Time
+}
+
+func (dbTextObject) TableName() string { return objects.DBPrefix + "text_objects" }
+
+// dbTextRepo records that an indexed object was found in a repository. An object is dropped
+// from the index when it's no longer found in any indexed repository.
+type dbTextRepo struct {
+	Repo     string           `gorm:"primaryKey"`
+	ObjectID *astral.ObjectID `gorm:"primaryKey;index"`
+}
+
+func (dbTextRepo) TableName() string { return objects.DBPrefix + "text_repos" }
+
+// dbTextTag is a name:value pair describing an indexed object, matched by tag:value search terms.
+type dbTextTag struct {
+	ObjectID *astral.ObjectID `gorm:"primaryKey"`
+	Name     string           `gorm:"primaryKey;index"`
+	Value    string           `gorm:"primaryKey"`
+}
+
+func (dbTextTag) TableName() string { return objects.DBPrefix + "text_tags" }
+
+// MigrateTextIndex creates the tables of the text index. It fails if the SQLite build lacks FTS5.
+func (db *DB) MigrateTextIndex() error {
+	err := db.AutoMigrate(&dbTextObject{}, &dbTextRepo{}, &dbTextTag{})
+	if err != nil {
+		return err
+	}
+
+	return db.Exec(`CREATE VIRTUAL TABLE IF NOT EXISTS ` + textIndexTable +
+		` USING fts5(object_id UNINDEXED, content, tags, tokenize='unicode61 remove_diacritics 2')`).Error
+}
+
+func (db *DB) IsTextIndexed(id *astral.ObjectID) (b bool, err error) {
+	err = db.
+		Model(&dbTextObject{}).
+		Where("object_id = ?", id).
+		Select("count(*)>0").
+		First(&b).Error
+	return
+}
+
+// CreateTextEntry adds an object to the text index.
+func (db *DB) CreateTextEntry(id *astral.ObjectID, content string, tags []dbTextTag) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var values string
+		for _, tag := range tags {
+			values += tag.Value + "\n"
+		}
+
+		err := tx.Exec(`DELETE FROM `+textIndexTable+` WHERE object_id = ?`, id).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Exec(`INSERT INTO `+textIndexTable+` (object_id, content, tags) VALUES (?, ?, ?)`,
+			id, content, values).Error
+		if err != nil {
+			return err
+		}
+
+		if len(tags) > 0 {
+			err = tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&tags).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		return tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(&dbTextObject{
+			ObjectID:  id,
+			IndexedAt: time.Now(),
+		}).Error
+	})
+}
+
+func (db *DB) AddTextRepo(repo string, id *astral.ObjectID) error {
+	return db.Clauses(clause.OnConflict{DoNothing: true}).Create(&dbTextRepo{
+		Repo:     repo,
+		ObjectID: id,
+	}).Error
+}
+
+func (db *DB) FindTextRepoObjects(repo string) (ids []*astral.ObjectID, err error) {
+	err = db.
+		Model(&dbTextRepo{}).
+		Where("repo = ?", repo).
+		Pluck("object_id", &ids).Error
+	return
+}
+
+func (db *DB) FindTextObjectRepos(id *astral.ObjectID) (repos []string, err error) {
+	err = db.
+		Model(&dbTextRepo{}).
+		Where("object_id = ?", id).
+		Pluck("repo", &repos).Error
+	return
+}
+
+// RemoveTextRepo removes an object from a repository in the text index, and drops the object
+// from the index if no other indexed repository holds it.
+func (db *DB) RemoveTextRepo(repo string, id *astral.ObjectID) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("repo = ? AND object_id = ?", repo, id).Delete(&dbTextRepo{}).Error
+		if err != nil {
+			return err
+		}
+
+		var held bool
+		err = tx.Model(&dbTextRepo{}).Where("object_id = ?", id).Select("count(*)>0").First(&held).Error
+		if err != nil || held {
+			return err
+		}
+
+		err = tx.Exec(`DELETE FROM `+textIndexTable+` WHERE object_id = ?`, id).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Where("object_id = ?", id).Delete(&dbTextTag{}).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Where("object_id = ?", id).Delete(&dbTextObject{}).Error
+	})
+}
+
+// TextTagNames returns the names of all tags in the text index.
+func (db *DB) TextTagNames() (names []string, err error) {
+	err = db.
+		Model(&dbTextTag{}).
+		Distinct("name").
+		Pluck("name", &names).Error
+	return
+}
+
+// textMatch is an object found in the text index. Higher scores are better matches.
+type textMatch struct {
+	ObjectID *astral.ObjectID
+	Score    float64
+}
+
+// SearchText returns up to limit indexed objects matching the query, best matches first.
+// Bare words must all appear (as words or word prefixes) in the content or tag values of an object
+// and are ranked with BM25. Tags filter objects by their tag values; optional tags only affect
+// the ranking.
+func (db *DB) SearchText(query objects.SearchQuery, limit int) (matches []textMatch, err error) {
+	var (
+		sql       string
+		whereArgs []any
+		rank      string
+		rankArgs  []any
+	)
+
+	if match := textMatchExpr(string(query.Query)); len(match) > 0 {
+		sql = `SELECT t.object_id, %s AS score FROM ` + textIndexTable + ` t WHERE ` + textIndexTable + ` MATCH ?`
+		whereArgs = append(whereArgs, match)
+		rank = "bm25(" + textIndexTable + ")"
+	} else {
+		sql = `SELECT t.object_id, %s AS score FROM ` + dbTextObject{}.TableName() + ` t WHERE 1`
+	}
+
+	const hasTag = `EXISTS (SELECT 1 FROM ` + objects.DBPrefix + `text_tags g ` +
+		`WHERE g.object_id = t.object_id AND g.name = ? AND g.value LIKE ? ESCAPE '\')`
+
+	for _, tag := range query.Tags {
+		args := []any{string(tag.Name), "%" + escapeLike(string(tag.Value)) + "%"}
+
+		switch tag.Mod {
+		case objects.TagModRequire:
+			sql += ` AND ` + hasTag
+			whereArgs = append(whereArgs, args...)
+		case objects.TagModExclude:
+			sql += ` AND NOT ` + hasTag
+			whereArgs = append(whereArgs, args...)
+		case objects.TagModOptional:
+			rank += ` - ` + hasTag
+			rankArgs = append(rankArgs, args...)
+		case objects.TagModOptionalExclude:
+			rank += ` + ` + hasTag
+			rankArgs = append(rankArgs, args...)
+		}
+	}
+
+	// the score is the negated rank, so that higher scores are better
+	if len(rank) > 0 {
+		sql = fmt.Sprintf(sql, `-(`+rank+`)`) + ` ORDER BY score DESC, t.object_id LIMIT ?`
+	} else {
+		sql = fmt.Sprintf(sql, `0`) + ` ORDER BY t.object_id LIMIT ?`
+	}
+
+	args := append(rankArgs, whereArgs...)
+	args = append(args, limit)
+
+	err = db.Raw(sql, args...).Scan(&matches).Error
+	return
+}
+
+// textMatchExpr turns the words of a query into an FTS5 expression that requires every word
+// as a word prefix. Words are quoted, so FTS5 operators in queries have no effect.
+func textMatchExpr(query string) string {
+	var terms []string
+	for _, word := range strings.Fields(query) {
+		word = strings.ReplaceAll(word, `"`, "")
+		if len(word) > 0 {
+			terms = append(terms, `"`+word+`"*`)
+		}
+	}
+	return strings.Join(terms, " ")
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
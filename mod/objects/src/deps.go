@@ -8,13 +8,16 @@ import (
 
 // LoadDependencies injects core deps, then scans every other loaded module and
 // auto-registers it under whichever objects extension interfaces it implements
-// (Describer, Searcher, Finder, etc).
+// (Describer, Searcher, Finder, ReferenceExtractor, etc).
 func (mod *Module) LoadDependencies(*astral.Context) (err error) {
 	err = core.Inject(mod.node, &mod.Deps)
 	if err != nil {
 		return
 	}
 
+	// optional — without the user module there is no swarm to trust or to place shards on
+	core.Inject(mod.node, &mod.OptionalDeps)
+
 	if cnode, ok := mod.node.(*core.Node); ok {
 		for _, m := range cnode.Modules().Loaded() {
 			if m == mod {
@@ -44,8 +47,14 @@ func (mod *Module) LoadDependencies(*astral.Context) (err error) {
 			if r, ok := m.(objects.Receiver); ok {
 				mod.AddReceiver(r)
 			}
+
+			if e, ok := m.(objects.ReferenceExtractor); ok {
+				mod.AddReferenceExtractor(e)
+			}
 		}
 	}
 
+	mod.setupRemoteRepos()
+
 	return
 }
@@ -0,0 +1,381 @@
+package objects
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/mod/objects"
+	objectscli "github.com/cryptopunkscc/astrald/mod/objects/client"
+	"github.com/cryptopunkscc/astrald/sig"
+)
+
+const (
+	downloadPieceSize    = 4 << 20 // size of a range requested from a single provider
+	downloadMaxProviders = 8       // maximum number of providers read from concurrently
+	downloadMaxFailures  = 3       // failed ranges after which a provider is dropped
+	downloadPieceTimeout = time.Minute
+)
+
+// ErrNoProviders is returned when no provider could deliver the object.
+var ErrNoProviders = errors.New("no providers available")
+
+// Download fetches an object from the providers returned by Find and commits it to repo
+// (WriteDefault if nil). The object is split into disjoint ranges that are read from several
+// providers at once; a provider that fails is dropped and its ranges go to the others.
+// Pieces are verified as they arrive against the object's hash tree, if one is cached or served by
+// a trusted provider, and the assembled data is verified against objectID before it is committed.
+// The tree of the verified data is cached, so later network reads of the object can be checked.
+// The context must include the network zone.
+func (mod *Module) Download(ctx *astral.Context, objectID *astral.ObjectID, repo objects.Repository) error {
+	if !ctx.Zone().Is(astral.ZoneNetwork) {
+		return astral.ErrZoneExcluded
+	}
+
+	if repo == nil {
+		repo = mod.WriteDefault()
+	}
+
+	if has, _ := repo.Contains(ctx, objectID); has {
+		return nil
+	}
+
+	w, err := repo.Create(ctx, &objects.CreateOpts{Alloc: int(objectID.Size)})
+	if err != nil {
+		return err
+	}
+	defer w.Discard()
+
+	ctx, cancel := ctx.WithCancel()
+	defer cancel()
+
+	d := newDownload(mod, objectID, w)
+
+	providers, err := mod.Find(ctx, objectID)
+	if err != nil {
+		return err
+	}
+
+	go d.run(ctx, providers)
+
+	err = d.wait(ctx)
+	if err != nil {
+		return err
+	}
+
+	tree, err := d.builder.Tree(objectID)
+	if err != nil {
+		return fmt.Errorf("downloaded data: %w", err)
+	}
+
+	_, err = w.Commit()
+	if err != nil {
+		return err
+	}
+
+	d.cacheTree(tree)
+
+	return nil
+}
+
+// download assembles an object from pieces fetched out of order. Fetched pieces wait in memory
+// until all preceding pieces are written; the window keeps at most a few pieces per provider buffered.
+type download struct {
+	mod      *Module
+	objectID *astral.ObjectID
+	w        objects.Writer
+	builder  *objects.HashTreeBuilder // hashes the pieces as they are written
+
+	mu       sync.Mutex
+	cond     *sync.Cond
+	pending  sig.Set[int]   // pieces waiting for a provider
+	fetched  map[int][]byte // pieces fetched but not yet written
+	next     int            // next piece to write
+	count    int            // total number of pieces
+	workers  int            // active provider workers
+	findDone bool
+	err      error
+
+	tree      *objects.HashTree // verifies pieces as they arrive, nil if none is trusted
+	treeAsked bool
+	fetchTree func(*astral.Context, *astral.Identity, *astral.ObjectID) (*objects.HashTree, error)
+}
+
+func newDownload(mod *Module, objectID *astral.ObjectID, w objects.Writer) *download {
+	d := &download{
+		mod:       mod,
+		objectID:  objectID,
+		w:         w,
+		builder:   objects.NewHashTreeBuilder(objects.DefaultHashTreeBlockSize),
+		fetched:   map[int][]byte{},
+		count:     int((objectID.Size + downloadPieceSize - 1) / downloadPieceSize),
+		fetchTree: mod.remoteHashTree,
+	}
+	d.cond = sync.NewCond(&d.mu)
+
+	// a tree cached from an earlier copy of the object needs no provider
+	if tree := mod.cachedHashTree(objectID); tree != nil && treeFits(tree) {
+		d.tree = tree
+		d.treeAsked = true
+	}
+
+	for i := 0; i < d.count; i++ {
+		d.pending.Add(i)
+	}
+
+	return d
+}
+
+// run starts a worker for every distinct provider as they are found.
+func (d *download) run(ctx *astral.Context, providers <-chan *astral.Identity) {
+	var seen = map[string]struct{}{}
+
+	for provider := range providers {
+		if provider.IsEqual(d.mod.node.Identity()) {
+			continue
+		}
+		if _, found := seen[provider.String()]; found {
+			continue
+		}
+		if len(seen) >= downloadMaxProviders {
+			continue
+		}
+		seen[provider.String()] = struct{}{}
+
+		d.mu.Lock()
+		d.workers++
+		d.mu.Unlock()
+
+		go d.worker(ctx, provider)
+	}
+
+	d.mu.Lock()
+	d.findDone = true
+	d.cond.Broadcast()
+	d.mu.Unlock()
+}
+
+// wait blocks until every piece is written, the download fails or ctx is done.
+func (d *download) wait(ctx *astral.Context) error {
+	stop := context.AfterFunc(ctx, func() {
+		d.fail(ctx.Err())
+	})
+	defer stop()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for {
+		switch {
+		case d.err != nil:
+			return d.err
+		case d.next == d.count:
+			return nil
+		case d.findDone && d.workers == 0:
+			return ErrNoProviders
+		}
+
+		d.cond.Wait()
+	}
+}
+
+func (d *download) worker(ctx *astral.Context, provider *astral.Identity) {
+	var failures int
+
+	defer func() {
+		d.mu.Lock()
+		d.workers--
+		d.cond.Broadcast()
+		d.mu.Unlock()
+	}()
+
+	for failures < downloadMaxFailures {
+		piece, ok := d.take()
+		if !ok {
+			return
+		}
+
+		d.loadTree(ctx, provider)
+
+		data, err := d.fetch(ctx, provider, piece)
+		if err == nil {
+			err = d.verify(piece, data)
+		}
+		if err != nil {
+			d.mod.log.Logv(2, "download %v: piece %v from %v: %v", d.objectID, piece, provider, err)
+			failures++
+			d.giveBack(piece)
+			continue
+		}
+
+		d.complete(piece, data)
+	}
+
+	d.mod.log.Logv(1, "download %v: dropping provider %v", d.objectID, provider)
+}
+
+// take returns the lowest pending piece inside the write window, blocking until one is available.
+func (d *download) take() (int, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for {
+		if d.err != nil || d.next == d.count {
+			return 0, false
+		}
+
+		window := 2 * max(d.workers, 1)
+
+		var piece = -1
+		for _, p := range d.pending.Clone() {
+			if p < d.next+window && (piece == -1 || p < piece) {
+				piece = p
+			}
+		}
+
+		if piece != -1 {
+			d.pending.Remove(piece)
+			return piece, true
+		}
+
+		d.cond.Wait()
+	}
+}
+
+func (d *download) giveBack(piece int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.pending.Add(piece)
+	d.cond.Broadcast()
+}
+
+// complete buffers a fetched piece and writes out every piece that is now in order.
+func (d *download) complete(piece int, data []byte) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.fetched[piece] = data
+
+	for d.err == nil {
+		data, found := d.fetched[d.next]
+		if !found {
+			break
+		}
+		delete(d.fetched, d.next)
+
+		if _, err := d.w.Write(data); err != nil {
+			d.err = err
+			break
+		}
+		d.builder.Write(data)
+		d.next++
+	}
+
+	d.cond.Broadcast()
+}
+
+// loadTree asks the first trusted provider that comes along for the object's hash tree. Trees
+// of other providers are never used, since a forged tree would reject valid pieces. A tree that
+// does not fit the piece layout is ignored.
+func (d *download) loadTree(ctx *astral.Context, provider *astral.Identity) {
+	if !d.mod.trustsHashTrees(provider) {
+		return
+	}
+
+	d.mu.Lock()
+	if d.treeAsked {
+		d.mu.Unlock()
+		return
+	}
+	d.treeAsked = true
+	d.mu.Unlock()
+
+	tree, err := d.fetchTree(ctx, provider, d.objectID)
+	if err != nil {
+		d.mod.log.Logv(2, "download %v: no hash tree from %v: %v", d.objectID, provider, err)
+		return
+	}
+	if !treeFits(tree) {
+		return
+	}
+
+	d.mu.Lock()
+	d.tree = tree
+	d.mu.Unlock()
+}
+
+// verify checks a piece against the hash tree, if there is one.
+func (d *download) verify(piece int, data []byte) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.tree == nil {
+		return nil
+	}
+
+	return d.tree.Verify(int64(piece)*downloadPieceSize, data)
+}
+
+// cacheTree stores the hash tree of the downloaded data, which resolved to the object ID. A tree
+// served by a provider is checked against it: its root must match the root of the verified tree.
+func (d *download) cacheTree(tree *objects.HashTree) {
+	d.mu.Lock()
+	served := d.tree
+	d.mu.Unlock()
+
+	if served != nil && served.BlockSize == tree.BlockSize && served.Root() != tree.Root() {
+		d.mod.log.Error("download %v: hash tree served for the object does not match its data", d.objectID)
+	}
+
+	if d.mod.cachedHashTree(d.objectID) != nil {
+		return
+	}
+
+	err := d.mod.db.CreateHashTree(tree)
+	if err != nil {
+		d.mod.log.Errorv(1, "cache hash tree of %v: %v", d.objectID, err)
+	}
+}
+
+// treeFits checks if every piece starts at a block boundary of a tree.
+func treeFits(tree *objects.HashTree) bool {
+	return downloadPieceSize%int64(tree.BlockSize) == 0
+}
+
+func (d *download) fail(err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.err == nil {
+		d.err = err
+	}
+	d.cond.Broadcast()
+}
+
+// fetch reads a single piece from a provider.
+func (d *download) fetch(ctx *astral.Context, provider *astral.Identity, piece int) ([]byte, error) {
+	offset := int64(piece) * downloadPieceSize
+	limit := min(downloadPieceSize, int64(d.objectID.Size)-offset)
+
+	ctx, cancel := ctx.WithTimeout(downloadPieceTimeout)
+	defer cancel()
+
+	r, err := objectscli.New(provider, nil).Read(ctx, d.objectID, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	buf := make([]byte, limit)
+	_, err = io.ReadFull(r, buf)
+	if err != nil {
+		return nil, err
+	}
+
+	return buf, nil
+}
@@ -0,0 +1,138 @@
+package objects
+
+import (
+	"crypto/cipher"
+	"errors"
+	"io"
+
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/mod/objects"
+)
+
+var _ objects.Reader = &encryptedReader{}
+
+// encryptedReader decrypts a window of an encrypted object one segment at a time.
+type encryptedReader struct {
+	repo  *EncryptedRepository
+	r     objects.Reader
+	aead  cipher.AEAD
+	size  uint64 // plaintext size
+	seg   uint64 // next segment to decrypt
+	skip  int    // plaintext bytes to drop from the next segment
+	limit int64  // plaintext bytes left to return
+	buf   []byte // decrypted bytes not yet returned
+	cbuf  []byte
+}
+
+func newEncryptedReader(
+	ctx *astral.Context,
+	repo *EncryptedRepository,
+	store objects.Repository,
+	storedID *astral.ObjectID,
+	size uint64,
+	offset int64,
+	limit int64,
+) (*encryptedReader, error) {
+	salt, err := readSalt(ctx, store, storedID)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := repo.objectAEAD(ctx, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	seg := uint64(offset) / encSegmentSize
+	if seg == encSegmentCount(size) {
+		// offset at the end of an object that fills its last segment
+		seg--
+	}
+
+	r, err := store.Read(ctx, storedID, int64(encSaltSize+seg*(encSegmentSize+encTagSize)), 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return &encryptedReader{
+		repo:  repo,
+		r:     r,
+		aead:  aead,
+		size:  size,
+		seg:   seg,
+		skip:  int(uint64(offset) - seg*encSegmentSize),
+		limit: limit,
+		cbuf:  make([]byte, encSegmentSize+encTagSize),
+	}, nil
+}
+
+func (r *encryptedReader) Read(p []byte) (n int, err error) {
+	if r.limit <= 0 {
+		return 0, io.EOF
+	}
+
+	if len(r.buf) == 0 {
+		if err = r.next(); err != nil {
+			return
+		}
+	}
+
+	if int64(len(p)) > r.limit {
+		p = p[:r.limit]
+	}
+
+	n = copy(p, r.buf)
+	r.buf = r.buf[n:]
+	r.limit -= int64(n)
+
+	return
+}
+
+func (r *encryptedReader) Close() error {
+	r.limit = 0
+	return r.r.Close()
+}
+
+func (r *encryptedReader) Repo() objects.Repository {
+	return r.repo
+}
+
+// next reads and decrypts the next segment into buf.
+func (r *encryptedReader) next() error {
+	count := encSegmentCount(r.size)
+	if r.seg >= count {
+		return io.ErrUnexpectedEOF
+	}
+
+	plen := min(encSegmentSize, r.size-r.seg*encSegmentSize)
+	c := r.cbuf[:plen+encTagSize]
+
+	_, err := io.ReadFull(r.r, c)
+	if err != nil {
+		return err
+	}
+
+	plain, err := r.aead.Open(c[:0], encNonce(r.seg), c, encAAD(r.seg == count-1))
+	if err != nil {
+		return errors.New("segment authentication failed")
+	}
+
+	r.buf = plain[r.skip:]
+	r.skip = 0
+	r.seg++
+
+	return nil
+}
+
+func readSalt(ctx *astral.Context, store objects.Repository, storedID *astral.ObjectID) ([]byte, error) {
+	r, err := store.Read(ctx, storedID, 0, encSaltSize)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	salt := make([]byte, encSaltSize)
+	_, err = io.ReadFull(r, salt)
+
+	return salt, err
+}
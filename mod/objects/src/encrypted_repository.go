@@ -0,0 +1,292 @@
+package objects
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/mod/crypto"
+	"github.com/cryptopunkscc/astrald/mod/objects"
+	"github.com/cryptopunkscc/astrald/sig"
+)
+
+// Encrypted objects are stored as a random salt followed by a sequence of AES-256-GCM sealed
+// segments. Each segment holds encSegmentSize bytes of plaintext (the last one may be shorter),
+// so any offset can be read by decrypting only the segments that cover it.
+const (
+	encSaltSize    = 32
+	encSegmentSize = 64 << 10
+	encTagSize     = 16
+	encKeyInfo     = "mod.objects.encrypted_repository"
+)
+
+var _ objects.Repository = &EncryptedRepository{}
+var _ objects.Holder = &EncryptedRepository{}
+
+// EncryptedRepository wraps a store repository and keeps object payloads in it encrypted with
+// a key derived from a private key held by the crypto module. Objects are addressed by their
+// plaintext ObjectID; the mapping to ciphertext objects in the store is kept in the module database.
+type EncryptedRepository struct {
+	mod      *Module
+	name     string
+	label    string
+	store    string
+	key      *crypto.PublicKey // nil means the node key
+	addQueue *sig.Queue[*astral.ObjectID]
+
+	mu     sync.Mutex
+	master []byte
+}
+
+func NewEncryptedRepository(mod *Module, name string, label string, store string, key *crypto.PublicKey) *EncryptedRepository {
+	return &EncryptedRepository{
+		mod:      mod,
+		name:     name,
+		label:    label,
+		store:    store,
+		key:      key,
+		addQueue: &sig.Queue[*astral.ObjectID]{},
+	}
+}
+
+func (repo *EncryptedRepository) Label() string {
+	return repo.label
+}
+
+func (repo *EncryptedRepository) Create(ctx *astral.Context, opts *objects.CreateOpts) (objects.Writer, error) {
+	store, err := repo.getStore()
+	if err != nil {
+		return nil, err
+	}
+
+	var storeOpts *objects.CreateOpts
+	if opts != nil {
+		storeOpts = &objects.CreateOpts{Alloc: int(encryptedSize(uint64(opts.Alloc)))}
+	}
+
+	return newEncryptedWriter(ctx, repo, store, storeOpts)
+}
+
+func (repo *EncryptedRepository) Contains(ctx *astral.Context, objectID *astral.ObjectID) (bool, error) {
+	return repo.mod.db.ContainsEncryptedObject(repo.name, objectID)
+}
+
+// Scan streams plaintext IDs of all stored objects. When following, a nil sentinel
+// separates the snapshot from objects committed later.
+func (repo *EncryptedRepository) Scan(ctx *astral.Context, follow bool) (<-chan *astral.ObjectID, error) {
+	ch := make(chan *astral.ObjectID)
+
+	var subscribe <-chan *astral.ObjectID
+	if follow {
+		subscribe = sig.Subscribe(ctx, repo.addQueue)
+	}
+
+	go func() {
+		defer close(ch)
+
+		ids, err := repo.mod.db.ListEncryptedObjects(repo.name)
+		if err != nil {
+			repo.mod.log.Error("encrypted %v: db error: %v", repo.name, err)
+			return
+		}
+
+		for _, id := range ids {
+			if err := sig.Send(ctx, ch, id); err != nil {
+				return
+			}
+		}
+
+		if subscribe == nil {
+			return
+		}
+
+		if err := sig.Send(ctx, ch, nil); err != nil {
+			return
+		}
+
+		for id := range subscribe {
+			if err := sig.Send(ctx, ch, id); err != nil {
+				return
+			}
+		}
+	}()
+
+	return ch, nil
+}
+
+func (repo *EncryptedRepository) Delete(ctx *astral.Context, objectID *astral.ObjectID) error {
+	row, err := repo.mod.db.FindEncryptedObject(repo.name, objectID)
+	if err != nil {
+		return objects.ErrNotFound
+	}
+
+	err = repo.mod.db.DeleteEncryptedObject(repo.name, objectID)
+	if err != nil {
+		return err
+	}
+
+	store, err := repo.getStore()
+	if err != nil {
+		repo.mod.log.Errorv(1, "encrypted %v: cannot free %v: %v", repo.name, row.StoredID, err)
+		return nil
+	}
+
+	repo.release(ctx, store, row.StoredID)
+
+	return nil
+}
+
+// Read decrypts the segments covering the requested window. A limit of 0 reads to the end.
+func (repo *EncryptedRepository) Read(ctx *astral.Context, objectID *astral.ObjectID, offset int64, limit int64) (objects.Reader, error) {
+	if !ctx.Zone().Is(astral.ZoneVirtual) {
+		return nil, astral.ErrZoneExcluded
+	}
+
+	switch {
+	case offset < 0 || offset > int64(objectID.Size):
+		return nil, objects.ErrOutOfBounds
+	case limit < 0:
+		return nil, objects.ErrOutOfBounds
+	case limit == 0 || offset+limit > int64(objectID.Size):
+		limit = int64(objectID.Size) - offset
+	}
+
+	row, err := repo.mod.db.FindEncryptedObject(repo.name, objectID)
+	if err != nil {
+		return nil, objects.ErrNotFound
+	}
+
+	store, err := repo.getStore()
+	if err != nil {
+		return nil, err
+	}
+
+	return newEncryptedReader(ctx.IncludeZone(astral.ZoneDevice), repo, store, row.StoredID, objectID.Size, offset, limit)
+}
+
+func (repo *EncryptedRepository) Free(ctx *astral.Context) (int64, error) {
+	store, err := repo.getStore()
+	if err != nil {
+		return -1, err
+	}
+
+	return store.Free(ctx)
+}
+
+// HoldObject protects ciphertext objects in the store from being purged.
+func (repo *EncryptedRepository) HoldObject(objectID *astral.ObjectID) bool {
+	held, err := repo.mod.db.IsCiphertextReferenced(objectID)
+	return err == nil && held
+}
+
+func (repo *EncryptedRepository) String() string {
+	return repo.label
+}
+
+// objectAEAD returns the cipher for a single object, keyed by the repository key and the object's salt.
+func (repo *EncryptedRepository) objectAEAD(ctx *astral.Context, salt []byte) (cipher.AEAD, error) {
+	master, err := repo.masterKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := hkdf.Key(sha256.New, master, salt, encKeyInfo, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// masterKey derives the repository key from the private key and caches it.
+func (repo *EncryptedRepository) masterKey(ctx *astral.Context) ([]byte, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if repo.master != nil {
+		return repo.master, nil
+	}
+
+	if repo.mod.Crypto == nil {
+		return nil, errors.New("crypto module unavailable")
+	}
+
+	pubKey := repo.key
+	if pubKey == nil {
+		pubKey = &crypto.PublicKey{
+			Type: "secp256k1",
+			Key:  repo.mod.node.Identity().PublicKey().SerializeCompressed(),
+		}
+	}
+
+	privKey, err := repo.mod.Crypto.PrivateKey(ctx, pubKey)
+	if err != nil {
+		return nil, fmt.Errorf("load private key: %w", err)
+	}
+
+	repo.master, err = hkdf.Key(sha256.New, privKey.Key, nil, encKeyInfo, 32)
+
+	return repo.master, err
+}
+
+// release deletes a ciphertext object from the store unless another mapping still uses it.
+func (repo *EncryptedRepository) release(ctx *astral.Context, store objects.Repository, storedID *astral.ObjectID) {
+	held, err := repo.mod.db.IsCiphertextReferenced(storedID)
+	if err != nil || held {
+		return
+	}
+
+	err = store.Delete(ctx, storedID)
+	if err != nil && !errors.Is(err, objects.ErrNotFound) {
+		repo.mod.log.Errorv(1, "encrypted %v: delete %v: %v", repo.name, storedID, err)
+	}
+}
+
+func (repo *EncryptedRepository) getStore() (objects.Repository, error) {
+	store := repo.mod.GetRepository(repo.store)
+	if store == nil {
+		return nil, fmt.Errorf("store repository %s not found", repo.store)
+	}
+	return store, nil
+}
+
+func (repo *EncryptedRepository) pushAdded(id *astral.ObjectID) {
+	repo.addQueue = repo.addQueue.Push(id)
+}
+
+// encSegmentCount returns the number of segments used to store size bytes of plaintext.
+// Empty objects still get one (empty) segment, so truncation can always be detected.
+func encSegmentCount(size uint64) uint64 {
+	return max(1, (size+encSegmentSize-1)/encSegmentSize)
+}
+
+// encryptedSize returns the size of the ciphertext object for size bytes of plaintext.
+func encryptedSize(size uint64) uint64 {
+	return encSaltSize + size + encSegmentCount(size)*encTagSize
+}
+
+// encNonce returns the nonce of a segment; segments never share a key across objects.
+func encNonce(seg uint64) []byte {
+	var nonce [12]byte
+	binary.BigEndian.PutUint64(nonce[:8], seg)
+	return nonce[:]
+}
+
+// encAAD binds the last-segment flag so that truncation at a segment boundary fails to decrypt.
+func encAAD(last bool) []byte {
+	if last {
+		return []byte{1}
+	}
+	return []byte{0}
+}
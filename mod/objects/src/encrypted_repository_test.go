@@ -0,0 +1,155 @@
+package objects
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"testing"
+
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/mod/crypto"
+	"github.com/cryptopunkscc/astrald/mod/objects"
+	"github.com/cryptopunkscc/astrald/mod/objects/mem"
+)
+
+// testKeyStore is a crypto module stand-in that knows a single private key.
+type testKeyStore struct {
+	crypto.Module
+	key *crypto.PrivateKey
+}
+
+func (s *testKeyStore) PrivateKey(*astral.Context, *crypto.PublicKey) (*crypto.PrivateKey, error) {
+	return s.key, nil
+}
+
+func testEncryptedRepository(t *testing.T) (*EncryptedRepository, *mem.Repository) {
+	t.Helper()
+
+	mod := newTestModule(t)
+
+	mod.Crypto = &testKeyStore{key: &crypto.PrivateKey{Type: "test", Key: []byte("secret key material")}}
+
+	store := mem.New("store", 1<<30)
+	mod.repos.Set("store", store)
+
+	key := &crypto.PublicKey{Type: "test", Key: []byte("public")}
+
+	return NewEncryptedRepository(mod, "enc", "Encrypted", "store", key), store
+}
+
+// Stored payloads are not readable from the store, but read back through the repository.
+func TestEncryptedRepository_RoundTrip(t *testing.T) {
+	repo, store := testEncryptedRepository(t)
+
+	data := make([]byte, 3*encSegmentSize+1234)
+	rand.New(rand.NewSource(3)).Read(data)
+
+	id := storeBytes(t, repo, data)
+	if want, _ := astral.Resolve(bytes.NewReader(data)); !id.IsEqual(want) {
+		t.Fatalf("got id %v, want plaintext id %v", id, want)
+	}
+
+	// the store holds only the ciphertext
+	stored := scanIDs(t, store)
+	if len(stored) != 1 {
+		t.Fatalf("store holds %d objects, want 1", len(stored))
+	}
+	for _, storedID := range stored {
+		if storedID.IsEqual(id) {
+			t.Fatal("plaintext stored in the underlying repository")
+		}
+		if storedID.Size != encryptedSize(id.Size) {
+			t.Fatalf("ciphertext size %d, want %d", storedID.Size, encryptedSize(id.Size))
+		}
+	}
+
+	for _, w := range []struct{ offset, limit int64 }{
+		{0, 0},
+		{0, 10},
+		{encSegmentSize - 3, 10},
+		{2*encSegmentSize + 5, encSegmentSize},
+		{int64(len(data)) - 1, 0},
+		{int64(len(data)), 0},
+	} {
+		end := int64(len(data))
+		if w.limit > 0 {
+			end = w.offset + w.limit
+		}
+		if got := readBytes(t, repo, id, w.offset, w.limit); !bytes.Equal(got, data[w.offset:end]) {
+			t.Fatalf("window %d+%d: got %d bytes, want %d", w.offset, w.limit, len(got), end-w.offset)
+		}
+	}
+
+	if has, err := repo.Contains(nil, id); err != nil || !has {
+		t.Fatalf("contains: %v, %v", has, err)
+	}
+
+	if err := repo.Delete(astral.NewContext(nil), id); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if len(scanIDs(t, store)) != 0 {
+		t.Fatal("ciphertext left in the store after delete")
+	}
+	if has, err := repo.Contains(nil, id); err != nil || has {
+		t.Fatalf("contains after delete: %v, %v", has, err)
+	}
+
+	// database errors are not reported as a missing object
+	sqlDB, _ := repo.mod.db.DB.DB()
+	sqlDB.Close()
+	if _, err := repo.Contains(nil, id); err == nil {
+		t.Fatal("database error not reported")
+	}
+}
+
+// Empty objects and objects that exactly fill their segments round-trip too.
+func TestEncryptedRepository_SegmentEdges(t *testing.T) {
+	repo, _ := testEncryptedRepository(t)
+
+	for _, size := range []int{0, 1, encSegmentSize, 2 * encSegmentSize} {
+		data := bytes.Repeat([]byte{0xa5}, size)
+		id := storeBytes(t, repo, data)
+
+		if got := readBytes(t, repo, id, 0, 0); !bytes.Equal(got, data) {
+			t.Fatalf("size %d: got %d bytes", size, len(got))
+		}
+	}
+}
+
+// A flipped ciphertext bit fails authentication instead of returning corrupted data.
+func TestEncryptedRepository_Tamper(t *testing.T) {
+	repo, store := testEncryptedRepository(t)
+
+	id := storeBytes(t, repo, []byte("attack at dawn"))
+
+	// point the object at a copy of its ciphertext with the last bit flipped
+	storedID := scanIDs(t, store)[0]
+	buf := readBytes(t, store, storedID, 0, 0)
+	buf[len(buf)-1] ^= 1
+
+	tamperedID := storeBytes(t, store, buf)
+	if err := repo.mod.db.CreateEncryptedObject(repo.name, id, tamperedID); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := repo.Read(astral.NewContext(nil), id, 0, 0)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if _, err := io.ReadAll(r); err == nil {
+		t.Fatal("tampered ciphertext decrypted without error")
+	}
+}
+
+func scanIDs(t *testing.T, repo objects.Repository) (ids []*astral.ObjectID) {
+	t.Helper()
+
+	scan, err := repo.Scan(astral.NewContext(nil), false)
+	if err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	for id := range scan {
+		ids = append(ids, id)
+	}
+	return
+}
@@ -0,0 +1,140 @@
+package objects
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"sync/atomic"
+
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/mod/objects"
+)
+
+var _ objects.Writer = &encryptedWriter{}
+
+// encryptedWriter seals plaintext segment by segment into a writer of the store.
+// A full segment is only sealed once more data arrives, because the last segment
+// is sealed differently and is not known until Commit.
+type encryptedWriter struct {
+	repo     *EncryptedRepository
+	w        objects.Writer
+	aead     cipher.AEAD
+	resolver *astral.WriteResolver
+	buf      []byte
+	seg      uint64
+	closed   atomic.Bool
+}
+
+func newEncryptedWriter(ctx *astral.Context, repo *EncryptedRepository, store objects.Repository, opts *objects.CreateOpts) (*encryptedWriter, error) {
+	var salt = make([]byte, encSaltSize)
+	rand.Read(salt)
+
+	aead, err := repo.objectAEAD(ctx, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	w, err := store.Create(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = w.Write(salt)
+	if err != nil {
+		w.Discard()
+		return nil, err
+	}
+
+	return &encryptedWriter{
+		repo:     repo,
+		w:        w,
+		aead:     aead,
+		resolver: astral.NewWriteResolver(nil),
+		buf:      make([]byte, 0, encSegmentSize),
+	}, nil
+}
+
+func (w *encryptedWriter) Write(p []byte) (n int, err error) {
+	if w.closed.Load() {
+		return 0, objects.ErrClosedPipe
+	}
+
+	for len(p) > 0 {
+		if len(w.buf) == encSegmentSize {
+			if err = w.seal(false); err != nil {
+				return
+			}
+		}
+
+		c := copy(w.buf[len(w.buf):encSegmentSize], p)
+		w.buf = w.buf[:len(w.buf)+c]
+		w.resolver.Write(p[:c])
+		p = p[c:]
+		n += c
+	}
+
+	return
+}
+
+// Commit seals the last segment, commits the ciphertext to the store and maps it to the plaintext ID.
+func (w *encryptedWriter) Commit() (*astral.ObjectID, error) {
+	if !w.closed.CompareAndSwap(false, true) {
+		return nil, objects.ErrClosedPipe
+	}
+
+	err := w.seal(true)
+	if err != nil {
+		w.w.Discard()
+		return nil, err
+	}
+
+	storedID, err := w.w.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	objectID := w.resolver.Resolve()
+	ctx := astral.NewContext(nil).WithZone(astral.ZoneDevice)
+
+	// keep the existing ciphertext if the object is already stored
+	if row, err := w.repo.mod.db.FindEncryptedObject(w.repo.name, objectID); err == nil {
+		if !row.StoredID.IsEqual(storedID) {
+			if store, err := w.repo.getStore(); err == nil {
+				w.repo.release(ctx, store, storedID)
+			}
+		}
+		return objectID, nil
+	}
+
+	err = w.repo.mod.db.CreateEncryptedObject(w.repo.name, objectID, storedID)
+	if err != nil {
+		if store, err := w.repo.getStore(); err == nil {
+			w.repo.release(ctx, store, storedID)
+		}
+		return nil, err
+	}
+
+	w.repo.pushAdded(objectID)
+
+	return objectID, nil
+}
+
+func (w *encryptedWriter) Discard() error {
+	if w.closed.CompareAndSwap(false, true) {
+		return w.w.Discard()
+	}
+	return nil
+}
+
+func (w *encryptedWriter) seal(last bool) error {
+	sealed := w.aead.Seal(nil, encNonce(w.seg), w.buf, encAAD(last))
+
+	_, err := w.w.Write(sealed)
+	if err != nil {
+		return err
+	}
+
+	w.buf = w.buf[:0]
+	w.seg++
+
+	return nil
+}
@@ -0,0 +1,47 @@
+package objects
+
+import (
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/mod/auth"
+	"github.com/cryptopunkscc/astrald/mod/objects"
+)
+
+var _ objects.Receiver = &shardHolder{}
+var _ objects.Holder = &shardHolder{}
+
+// shardHolder keeps the shards this node stores for erasure-coded repositories of other nodes.
+// Nodes register their shards by pushing ShardHold objects after writing them.
+type shardHolder struct {
+	mod *Module
+}
+
+func (h *shardHolder) ReceiveObject(drop objects.Drop) error {
+	hold, ok := drop.Object().(*objects.ShardHold)
+	if !ok || hold.ShardID == nil {
+		return nil
+	}
+
+	// only nodes allowed to store objects here can keep them
+	sender := drop.SenderID()
+	ctx := astral.NewContext(nil).WithIdentity(sender)
+	if !h.mod.Auth.Authorize(ctx, &objects.CreateObjectAction{Action: auth.NewAction(sender)}) {
+		return objects.ErrPushRejected
+	}
+
+	var err error
+	if hold.Release {
+		err = h.mod.db.DeleteShardHold(sender, hold.ShardID)
+	} else {
+		err = h.mod.db.CreateShardHold(sender, hold.ShardID)
+	}
+	if err != nil {
+		return err
+	}
+
+	return drop.Accept(false)
+}
+
+func (h *shardHolder) HoldObject(objectID *astral.ObjectID) bool {
+	held, err := h.mod.db.IsShardHeld(objectID)
+	return err == nil && held
+}
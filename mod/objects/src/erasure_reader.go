@@ -0,0 +1,155 @@
+package objects
+
+import (
+	"errors"
+	"io"
+
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/mod/objects"
+)
+
+var _ objects.Reader = &erasureReader{}
+
+var errNotEnoughShards = errors.New("not enough shards available")
+
+// erasureReader reconstructs stripes of an erasure-coded object from the first Data shards that
+// can be read. A shard that fails, or serves a block that doesn't match its hash, is replaced by
+// the next one, continuing from the same stripe.
+type erasureReader struct {
+	ctx    *astral.Context
+	repo   *ErasureRepository
+	shards []*dbErasureShard // stored shards, ordered by index
+	open   map[int]io.ReadCloser
+	tried  int   // number of shards tried so far
+	stripe int64 // next stripe to decode
+	skip   int64 // bytes to skip in the next stripe
+	limit  int64 // bytes left to read
+	buf    []byte
+}
+
+func (r *erasureReader) Read(p []byte) (n int, err error) {
+	if r.limit <= 0 {
+		return 0, io.EOF
+	}
+
+	if len(r.buf) == 0 {
+		if err = r.decode(); err != nil {
+			return 0, err
+		}
+	}
+
+	n = copy(p, r.buf[:min(int64(len(r.buf)), r.limit)])
+	r.buf = r.buf[n:]
+	r.limit -= int64(n)
+
+	return
+}
+
+// decode reads a block of the current stripe from Data shards and reconstructs the data blocks.
+func (r *erasureReader) decode() error {
+	if r.open == nil {
+		r.open = map[int]io.ReadCloser{}
+	}
+
+	blocks := make([][]byte, r.repo.data+r.repo.parity)
+	var count int
+
+	for _, shard := range r.shards {
+		if count == r.repo.data {
+			break
+		}
+
+		rc, found := r.open[shard.Index]
+		if !found {
+			continue
+		}
+
+		block := make([]byte, erasureBlockSize)
+		_, err := io.ReadFull(rc, block)
+		if err == nil {
+			err = shard.verifyBlock(r.stripe, block)
+		}
+		if err != nil {
+			r.drop(shard, err)
+			continue
+		}
+
+		blocks[shard.Index] = block
+		count++
+	}
+
+	// open more shards until there are enough to decode the stripe
+	for count < r.repo.data && r.tried < len(r.shards) {
+		shard := r.shards[r.tried]
+		r.tried++
+
+		block, err := r.openShard(shard)
+		if err != nil {
+			r.repo.mod.log.Logv(2, "erasure %v: shard %v on %v: %v", r.repo.name, shard.Index, shard.Node, err)
+			continue
+		}
+
+		blocks[shard.Index] = block
+		count++
+	}
+
+	if count < r.repo.data {
+		return errNotEnoughShards
+	}
+
+	err := r.repo.enc.ReconstructData(blocks)
+	if err != nil {
+		return err
+	}
+
+	r.buf = r.buf[:0]
+	for _, block := range blocks[:r.repo.data] {
+		r.buf = append(r.buf, block...)
+	}
+	r.buf = r.buf[r.skip:]
+	r.skip = 0
+	r.stripe++
+
+	return nil
+}
+
+// openShard opens a shard at the current stripe and reads its block.
+func (r *erasureReader) openShard(shard *dbErasureShard) ([]byte, error) {
+	rc, err := r.repo.readShard(r.ctx, shard, r.stripe*erasureBlockSize)
+	if err != nil {
+		return nil, err
+	}
+
+	block := make([]byte, erasureBlockSize)
+	_, err = io.ReadFull(rc, block)
+	if err == nil {
+		err = shard.verifyBlock(r.stripe, block)
+	}
+	if err != nil {
+		rc.Close()
+		return nil, err
+	}
+
+	r.open[shard.Index] = rc
+
+	return block, nil
+}
+
+func (r *erasureReader) drop(shard *dbErasureShard, err error) {
+	r.repo.mod.log.Logv(2, "erasure %v: shard %v on %v: %v", r.repo.name, shard.Index, shard.Node, err)
+	r.open[shard.Index].Close()
+	delete(r.open, shard.Index)
+}
+
+func (r *erasureReader) Close() error {
+	r.limit = 0
+	for _, rc := range r.open {
+		rc.Close()
+	}
+	r.open = nil
+	return nil
+}
+
+func (r *erasureReader) Repo() objects.Repository {
+	return r.repo
+}
@@ -0,0 +1,396 @@
+package objects
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"sync/atomic"
+
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/lib/astrald"
+	"github.com/cryptopunkscc/astrald/mod/objects"
+	objectscli "github.com/cryptopunkscc/astrald/mod/objects/client"
+	"github.com/cryptopunkscc/astrald/sig"
+	"github.com/klauspost/reedsolomon"
+)
+
+const (
+	defaultErasureData   = 4
+	defaultErasureParity = 2
+	erasureBlockSize     = 256 << 10 // bytes of every shard per stripe
+)
+
+var _ objects.Repository = &ErasureRepository{}
+var _ objects.Holder = &ErasureRepository{}
+
+var errTooFewNodes = errors.New("too few nodes to survive the loss of parity nodes")
+
+// ErasureRepository is a virtual repository that Reed-Solomon encodes committed objects into
+// data and parity shards and spreads the shards over several nodes. An object can be read as long
+// as any Data of its shards are reachable. Objects are encoded in stripes of Data blocks, so that
+// memory use doesn't depend on the object size; every shard holds one block of every stripe.
+//
+// Shards placed on this node are read from the shard repository directly, shards of other nodes
+// only if the context includes the network zone. Other nodes are asked to keep the shards they
+// store with a pushed ShardHold. Every block read is checked against the hash
+// recorded when the shard was written, so a damaged shard is replaced by another one.
+//
+// Losing Parity nodes is survived only if there are at least Data+Parity nodes. With fewer,
+// a warning is logged, or objects are refused if the repository is strict.
+type ErasureRepository struct {
+	mod       *Module
+	name      string
+	label     string
+	data      int
+	parity    int
+	shardRepo string
+	nodes     []string
+	strict    bool
+	enc       reedsolomon.Encoder
+	addQueue  *sig.Queue[*astral.ObjectID]
+	tolerated atomic.Int64 // node losses the last placement survives, for warnings
+}
+
+func NewErasureRepository(mod *Module, name string, cfg ErasureRepoConfig) (*ErasureRepository, error) {
+	if cfg.Data == 0 {
+		cfg.Data = defaultErasureData
+	}
+	if cfg.Parity == 0 {
+		cfg.Parity = defaultErasureParity
+	}
+	if len(cfg.Repo) == 0 {
+		cfg.Repo = objects.RepoLocal
+	}
+	if len(cfg.Label) == 0 {
+		cfg.Label = fmt.Sprintf("Erasure %d+%d (%s)", cfg.Data, cfg.Parity, name)
+	}
+
+	enc, err := reedsolomon.New(cfg.Data, cfg.Parity)
+	if err != nil {
+		return nil, err
+	}
+
+	repo := &ErasureRepository{
+		mod:       mod,
+		name:      name,
+		label:     cfg.Label,
+		data:      cfg.Data,
+		parity:    cfg.Parity,
+		shardRepo: cfg.Repo,
+		nodes:     cfg.Nodes,
+		strict:    cfg.Strict,
+		enc:       enc,
+		addQueue:  &sig.Queue[*astral.ObjectID]{},
+	}
+	repo.tolerated.Store(int64(cfg.Parity))
+
+	return repo, nil
+}
+
+func (repo *ErasureRepository) Label() string {
+	return repo.label
+}
+
+func (repo *ErasureRepository) Create(ctx *astral.Context, opts *objects.CreateOpts) (objects.Writer, error) {
+	placement, err := repo.placement()
+	if err != nil {
+		return nil, err
+	}
+
+	var alloc int
+	if opts != nil && opts.Alloc > 0 {
+		alloc = repo.shardSize(int64(opts.Alloc))
+	}
+
+	return newErasureWriter(ctx, repo, placement, alloc), nil
+}
+
+func (repo *ErasureRepository) Contains(ctx *astral.Context, objectID *astral.ObjectID) (bool, error) {
+	return repo.mod.db.ContainsErasureObject(repo.name, objectID)
+}
+
+// Scan streams IDs of all stored objects from the index. When following, a nil sentinel
+// separates the snapshot from objects committed later.
+func (repo *ErasureRepository) Scan(ctx *astral.Context, follow bool) (<-chan *astral.ObjectID, error) {
+	ch := make(chan *astral.ObjectID)
+
+	var subscribe <-chan *astral.ObjectID
+	if follow {
+		subscribe = sig.Subscribe(ctx, repo.addQueue)
+	}
+
+	go func() {
+		defer close(ch)
+
+		ids, err := repo.mod.db.ListErasureObjects(repo.name)
+		if err != nil {
+			repo.mod.log.Error("erasure %v: db error: %v", repo.name, err)
+			return
+		}
+
+		for _, id := range ids {
+			if err := sig.Send(ctx, ch, id); err != nil {
+				return
+			}
+		}
+
+		if subscribe == nil {
+			return
+		}
+
+		if err := sig.Send(ctx, ch, nil); err != nil {
+			return
+		}
+
+		for id := range subscribe {
+			if err := sig.Send(ctx, ch, id); err != nil {
+				return
+			}
+		}
+	}()
+
+	return ch, nil
+}
+
+// Delete removes the object from the index and deletes its shards from the nodes that can be
+// reached. Shards of unreachable nodes are left behind.
+func (repo *ErasureRepository) Delete(ctx *astral.Context, objectID *astral.ObjectID) error {
+	if _, err := repo.mod.db.FindErasureObject(repo.name, objectID); err != nil {
+		return objects.ErrNotFound
+	}
+
+	shards, err := repo.mod.db.FindErasureShards(repo.name, objectID)
+	if err != nil {
+		return err
+	}
+
+	err = repo.mod.db.DeleteErasureObject(repo.name, objectID)
+	if err != nil {
+		return err
+	}
+
+	for _, shard := range shards {
+		if held, err := repo.mod.db.IsErasureShard(shard.ShardID); err != nil || held {
+			continue
+		}
+
+		err = repo.deleteShard(ctx, shard)
+		if err != nil && !errors.Is(err, objects.ErrNotFound) {
+			repo.mod.log.Errorv(1, "erasure %v: delete shard %v from %v: %v", repo.name, shard.ShardID, shard.Node, err)
+		}
+	}
+
+	return nil
+}
+
+// Read returns a reader that reconstructs the object from its shards starting at offset.
+// A limit of 0 reads to the end of the object.
+func (repo *ErasureRepository) Read(ctx *astral.Context, objectID *astral.ObjectID, offset int64, limit int64) (objects.Reader, error) {
+	if !ctx.Zone().Is(astral.ZoneVirtual) {
+		return nil, astral.ErrZoneExcluded
+	}
+
+	switch {
+	case offset < 0 || offset > int64(objectID.Size):
+		return nil, objects.ErrOutOfBounds
+	case limit < 0:
+		return nil, objects.ErrOutOfBounds
+	case limit == 0 || offset+limit > int64(objectID.Size):
+		limit = int64(objectID.Size) - offset
+	}
+
+	if _, err := repo.mod.db.FindErasureObject(repo.name, objectID); err != nil {
+		return nil, objects.ErrNotFound
+	}
+
+	shards, err := repo.mod.db.FindErasureShards(repo.name, objectID)
+	if err != nil {
+		return nil, err
+	}
+
+	stripe := offset / repo.stripeSize()
+
+	return &erasureReader{
+		ctx:    ctx,
+		repo:   repo,
+		shards: shards,
+		stripe: stripe,
+		skip:   offset - stripe*repo.stripeSize(),
+		limit:  limit,
+	}, nil
+}
+
+// Free returns -1, as the free space is spread over several nodes.
+func (repo *ErasureRepository) Free(ctx *astral.Context) (int64, error) {
+	return -1, nil
+}
+
+// HoldObject protects the shards kept on this node from being purged.
+func (repo *ErasureRepository) HoldObject(objectID *astral.ObjectID) bool {
+	held, err := repo.mod.db.IsErasureShard(objectID)
+	return err == nil && held
+}
+
+func (repo *ErasureRepository) String() string {
+	return repo.label
+}
+
+func (repo *ErasureRepository) stripeSize() int64 {
+	return int64(repo.data) * erasureBlockSize
+}
+
+// shardSize returns the size of every shard of an object of the given size.
+func (repo *ErasureRepository) shardSize(size int64) int {
+	stripes := (size + repo.stripeSize() - 1) / repo.stripeSize()
+	return int(stripes * erasureBlockSize)
+}
+
+// placement returns the node for every shard. Shards are dealt over the configured nodes (or the
+// members of the local swarm) in turn, so with fewer nodes than shards some nodes get several
+// and the object may not survive the loss of Parity nodes.
+func (repo *ErasureRepository) placement() ([]*astral.Identity, error) {
+	var nodes []*astral.Identity
+
+	switch {
+	case len(repo.nodes) > 0:
+		for _, name := range repo.nodes {
+			node, err := repo.mod.Dir.ResolveIdentity(name)
+			if err != nil {
+				return nil, fmt.Errorf("invalid node %v: %w", name, err)
+			}
+			nodes = append(nodes, node)
+		}
+
+	case repo.mod.User != nil:
+		nodes = repo.mod.User.LocalSwarm()
+	}
+
+	if len(nodes) == 0 {
+		nodes = []*astral.Identity{repo.mod.node.Identity()}
+	}
+
+	placement := make([]*astral.Identity, repo.data+repo.parity)
+	for i := range placement {
+		placement[i] = nodes[i%len(nodes)]
+	}
+
+	tolerated := repo.toleratedLosses(placement)
+	if tolerated < repo.parity {
+		if repo.strict {
+			return nil, fmt.Errorf("%w: %d nodes survive %d node losses", errTooFewNodes, len(nodes), tolerated)
+		}
+		if repo.tolerated.Swap(int64(tolerated)) != int64(tolerated) {
+			repo.mod.log.Error("erasure %v: %v nodes hold %v shards, objects survive the loss of %v nodes instead of %v",
+				repo.name, len(nodes), len(placement), tolerated, repo.parity)
+		}
+	} else {
+		repo.tolerated.Store(int64(tolerated))
+	}
+
+	return placement, nil
+}
+
+// toleratedLosses returns how many nodes of a placement can be lost in the worst case while
+// leaving at least Data shards.
+func (repo *ErasureRepository) toleratedLosses(placement []*astral.Identity) int {
+	var counts = map[string]int{}
+	for _, node := range placement {
+		counts[node.String()]++
+	}
+
+	loads := slices.Sorted(maps.Values(counts))
+	slices.Reverse(loads)
+
+	var lost, tolerated int
+	for _, load := range loads {
+		if lost += load; lost > repo.parity {
+			break
+		}
+		tolerated++
+	}
+
+	return tolerated
+}
+
+func (repo *ErasureRepository) isLocal(node *astral.Identity) bool {
+	return node.IsEqual(repo.mod.node.Identity())
+}
+
+func (repo *ErasureRepository) getStore() (objects.Repository, error) {
+	store := repo.mod.GetRepository(repo.shardRepo)
+	if store == nil {
+		return nil, fmt.Errorf("shard repository %s not found", repo.shardRepo)
+	}
+	return store, nil
+}
+
+func (repo *ErasureRepository) createShard(ctx *astral.Context, node *astral.Identity, alloc int) (objects.Writer, error) {
+	if !repo.isLocal(node) {
+		if !ctx.Zone().Is(astral.ZoneNetwork) {
+			return nil, astral.ErrZoneExcluded
+		}
+
+		return objectscli.New(node, astrald.Default()).Create(ctx, repo.shardRepo, alloc)
+	}
+
+	store, err := repo.getStore()
+	if err != nil {
+		return nil, err
+	}
+
+	return store.Create(ctx, &objects.CreateOpts{Alloc: alloc})
+}
+
+func (repo *ErasureRepository) readShard(ctx *astral.Context, shard *dbErasureShard, offset int64) (io.ReadCloser, error) {
+	if !repo.isLocal(shard.Node) {
+		if !ctx.Zone().Is(astral.ZoneNetwork) {
+			return nil, astral.ErrZoneExcluded
+		}
+
+		return objectscli.New(shard.Node, astrald.Default()).ReadRepo(ctx, repo.shardRepo, shard.ShardID, offset, 0)
+	}
+
+	store, err := repo.getStore()
+	if err != nil {
+		return nil, err
+	}
+
+	return store.Read(ctx, shard.ShardID, offset, 0)
+}
+
+// holdShard registers a shard with the node that stores it, so that the node doesn't purge it.
+// Shards on this node are held by the repository itself.
+func (repo *ErasureRepository) holdShard(ctx *astral.Context, node *astral.Identity, shardID *astral.ObjectID, release bool) error {
+	if repo.isLocal(node) {
+		return nil
+	}
+	if !ctx.Zone().Is(astral.ZoneNetwork) {
+		return astral.ErrZoneExcluded
+	}
+
+	return repo.mod.Push(ctx, node, &objects.ShardHold{ShardID: shardID, Release: astral.Bool(release)})
+}
+
+func (repo *ErasureRepository) deleteShard(ctx *astral.Context, shard *dbErasureShard) error {
+	if !repo.isLocal(shard.Node) {
+		err := repo.holdShard(ctx, shard.Node, shard.ShardID, true)
+		if err != nil {
+			return err
+		}
+
+		return objectscli.New(shard.Node, astrald.Default()).Delete(ctx, shard.ShardID, repo.shardRepo)
+	}
+
+	store, err := repo.getStore()
+	if err != nil {
+		return err
+	}
+
+	return store.Delete(ctx, shard.ShardID)
+}
+
+func (repo *ErasureRepository) pushAdded(id *astral.ObjectID) {
+	repo.addQueue = repo.addQueue.Push(id)
+}
@@ -0,0 +1,178 @@
+package objects
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math/rand"
+	"testing"
+
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/mod/auth"
+	"github.com/cryptopunkscc/astrald/mod/objects"
+	"github.com/cryptopunkscc/astrald/mod/objects/mem"
+)
+
+func testErasureRepository(t *testing.T) (*ErasureRepository, *mem.Repository) {
+	t.Helper()
+
+	mod := newTestModule(t)
+
+	store := mem.New("store", 1<<30)
+	mod.repos.Set("store", store)
+
+	repo, err := NewErasureRepository(mod, "erasure", ErasureRepoConfig{Data: 4, Parity: 2, Repo: "store"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return repo, store
+}
+
+// An object survives the loss of as many shards as there are parity shards, but no more.
+func TestErasureRepository_LostShards(t *testing.T) {
+	repo, store := testErasureRepository(t)
+	ctx := astral.NewContext(nil).WithZone(astral.ZoneDevice | astral.ZoneVirtual)
+
+	data := make([]byte, 2*repo.stripeSize()+12345)
+	rand.New(rand.NewSource(1)).Read(data)
+
+	id := storeBytes(t, repo, data)
+
+	shards, err := repo.mod.db.FindErasureShards(repo.name, id)
+	if err != nil || len(shards) != 6 {
+		t.Fatalf("got %d shards (err %v), want 6", len(shards), err)
+	}
+
+	// lose a data shard and a parity shard
+	for _, i := range []int{1, 4} {
+		if err := store.Delete(ctx, shards[i].ShardID); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := readBytes(t, repo, id, 0, 0); !bytes.Equal(got, data) {
+		t.Fatal("object does not match after losing two shards")
+	}
+
+	offset := repo.stripeSize() - 100
+	if got := readBytes(t, repo, id, offset, 1000); !bytes.Equal(got, data[offset:offset+1000]) {
+		t.Fatal("range across stripes does not match")
+	}
+
+	if err := store.Delete(ctx, shards[0].ShardID); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := repo.Read(ctx, id, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	if _, err := io.ReadAll(r); !errors.Is(err, errNotEnoughShards) {
+		t.Fatalf("got %v, want errNotEnoughShards", err)
+	}
+}
+
+// A shard that serves damaged data is detected by its block hashes and replaced by the others.
+func TestErasureRepository_DamagedShard(t *testing.T) {
+	repo, store := testErasureRepository(t)
+
+	data := make([]byte, repo.stripeSize()+100)
+	rand.New(rand.NewSource(2)).Read(data)
+
+	id := storeBytes(t, repo, data)
+
+	shards, err := repo.mod.db.FindErasureShards(repo.name, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the second block of the first shard rots
+	shard := readBytes(t, store, shards[0].ShardID, 0, 0)
+	shard[erasureBlockSize+1] ^= 1
+	other := mem.New("other", 1<<30)
+	rotID := storeBytes(t, other, shard)
+	repo.mod.repos.Replace("store", &rottenRepository{Repository: store, rotten: shards[0].ShardID, from: other, with: rotID})
+
+	if got := readBytes(t, repo, id, 0, 0); !bytes.Equal(got, data) {
+		t.Fatal("object does not match with a damaged shard")
+	}
+}
+
+// With fewer nodes than shards, a strict repository refuses new objects.
+func TestErasureRepository_Placement(t *testing.T) {
+	repo, _ := testErasureRepository(t)
+
+	var swarm testSwarm
+	for range 3 {
+		swarm.members = append(swarm.members, astral.GenerateIdentity())
+	}
+	repo.mod.User = swarm
+
+	placement, err := repo.placement()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := repo.toleratedLosses(placement); n != 1 {
+		t.Fatalf("3 nodes holding 6 shards survive %d node losses, want 1", n)
+	}
+
+	repo.strict = true
+	if _, err := repo.Create(astral.NewContext(nil), nil); !errors.Is(err, errTooFewNodes) {
+		t.Fatalf("got %v, want errTooFewNodes", err)
+	}
+
+	for range 3 {
+		swarm.members = append(swarm.members, astral.GenerateIdentity())
+	}
+	repo.mod.User = swarm
+
+	placement, err = repo.placement()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := repo.toleratedLosses(placement); n != 2 {
+		t.Fatalf("6 nodes survive %d node losses, want 2", n)
+	}
+}
+
+// testAuth allows or denies every action.
+type testAuth struct {
+	auth.Module
+	allow bool
+}
+
+func (a *testAuth) Authorize(*astral.Context, auth.ActionObject) bool { return a.allow }
+
+// Shards stored for other nodes are held until released, and only for nodes allowed to store objects.
+func TestErasureRepository_ShardHold(t *testing.T) {
+	repo, _ := testErasureRepository(t)
+	authz := &testAuth{allow: true}
+	repo.mod.Auth = authz
+
+	holder := &shardHolder{mod: repo.mod}
+	shardID := testObjectID(t, "shard")
+	sender := astral.GenerateIdentity()
+
+	push := func(release bool) error {
+		return holder.ReceiveObject(&Drop{
+			mod:      repo.mod,
+			senderID: sender,
+			object:   &objects.ShardHold{ShardID: shardID, Release: astral.Bool(release)},
+		})
+	}
+
+	if err := push(false); err != nil || !holder.HoldObject(shardID) {
+		t.Fatalf("shard not held after a hold (err %v)", err)
+	}
+	if err := push(true); err != nil || holder.HoldObject(shardID) {
+		t.Fatalf("shard held after a release (err %v)", err)
+	}
+
+	authz.allow = false
+	if err := push(false); !errors.Is(err, objects.ErrPushRejected) || holder.HoldObject(shardID) {
+		t.Fatalf("hold of a node without permission: got %v", err)
+	}
+}
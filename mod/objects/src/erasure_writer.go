@@ -0,0 +1,223 @@
+package objects
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/mod/objects"
+)
+
+var _ objects.Writer = &erasureWriter{}
+
+// erasureWriter buffers a single stripe of data, encodes it and streams a block of it to every
+// shard. A shard that fails is dropped; the object is committed as long as no more than Parity
+// shards failed.
+type erasureWriter struct {
+	ctx       *astral.Context
+	repo      *ErasureRepository
+	placement []*astral.Identity
+	alloc     int
+	shards    []objects.Writer // nil until the first stripe is written, nil entries failed
+	blocks    [][]byte         // hashes of the blocks written to every shard
+	buf       []byte
+	resolver  *astral.WriteResolver
+	closed    atomic.Bool
+}
+
+func newErasureWriter(ctx *astral.Context, repo *ErasureRepository, placement []*astral.Identity, alloc int) *erasureWriter {
+	return &erasureWriter{
+		ctx:       ctx,
+		repo:      repo,
+		placement: placement,
+		alloc:     alloc,
+		buf:       make([]byte, 0, repo.stripeSize()),
+		resolver:  astral.NewWriteResolver(nil),
+	}
+}
+
+func (w *erasureWriter) Write(p []byte) (n int, err error) {
+	if w.closed.Load() {
+		return 0, objects.ErrClosedPipe
+	}
+
+	for len(p) > 0 {
+		m := min(len(p), cap(w.buf)-len(w.buf))
+		w.buf = append(w.buf, p[:m]...)
+		w.resolver.Write(p[:m])
+		p = p[m:]
+		n += m
+
+		if len(w.buf) == cap(w.buf) {
+			if err = w.flush(); err != nil {
+				return
+			}
+		}
+	}
+
+	return
+}
+
+// Commit encodes the final stripe, commits every shard and indexes the object.
+func (w *erasureWriter) Commit() (*astral.ObjectID, error) {
+	if !w.closed.CompareAndSwap(false, true) {
+		return nil, objects.ErrClosedPipe
+	}
+
+	if len(w.buf) > 0 {
+		if err := w.flush(); err != nil {
+			w.discard()
+			return nil, err
+		}
+	}
+
+	objectID := w.resolver.Resolve()
+
+	var rows []*dbErasureShard
+	for i, shard := range w.shards {
+		if shard == nil {
+			continue
+		}
+
+		shardID, err := shard.Commit()
+		if err != nil {
+			w.repo.mod.log.Errorv(1, "erasure %v: commit shard %v of %v on %v: %v", w.repo.name, i, objectID, w.placement[i], err)
+			w.shards[i] = nil
+			continue
+		}
+
+		// a shard the node was not asked to keep could be purged there at any time
+		err = w.repo.holdShard(w.ctx, w.placement[i], shardID, false)
+		if err != nil {
+			w.repo.mod.log.Errorv(1, "erasure %v: hold shard %v of %v on %v: %v", w.repo.name, i, objectID, w.placement[i], err)
+			w.shards[i] = nil
+			continue
+		}
+
+		rows = append(rows, &dbErasureShard{
+			Repo:     w.repo.name,
+			ObjectID: objectID,
+			Index:    i,
+			ShardID:  shardID,
+			Node:     w.placement[i],
+			Blocks:   w.blocks[i],
+		})
+	}
+
+	if w.shards != nil && len(rows) < w.repo.data {
+		return nil, fmt.Errorf("only %d of %d shards stored", len(rows), len(w.shards))
+	}
+
+	err := w.repo.mod.db.CreateErasureObject(&dbErasureObject{
+		Repo:     w.repo.name,
+		ObjectID: objectID,
+		Data:     w.repo.data,
+		Parity:   w.repo.parity,
+	}, rows)
+	if err != nil {
+		return nil, err
+	}
+
+	w.repo.pushAdded(objectID)
+
+	return objectID, nil
+}
+
+func (w *erasureWriter) Discard() error {
+	if w.closed.CompareAndSwap(false, true) {
+		w.discard()
+	}
+	return nil
+}
+
+func (w *erasureWriter) discard() {
+	for _, shard := range w.shards {
+		if shard != nil {
+			shard.Discard()
+		}
+	}
+	w.shards = nil
+}
+
+// flush encodes the buffered stripe, padded with zeros, and writes a block to every shard.
+func (w *erasureWriter) flush() error {
+	if w.shards == nil {
+		if err := w.open(); err != nil {
+			return err
+		}
+	}
+
+	data := w.buf[:cap(w.buf)]
+	clear(data[len(w.buf):])
+
+	blocks := make([][]byte, len(w.shards))
+	for i := range blocks {
+		if i < w.repo.data {
+			blocks[i] = data[i*erasureBlockSize : (i+1)*erasureBlockSize]
+		} else {
+			blocks[i] = make([]byte, erasureBlockSize)
+		}
+	}
+
+	err := w.repo.enc.Encode(blocks)
+	if err != nil {
+		return err
+	}
+
+	for i, shard := range w.shards {
+		if shard == nil {
+			continue
+		}
+
+		if _, err := shard.Write(blocks[i]); err != nil {
+			w.repo.mod.log.Errorv(1, "erasure %v: write shard %v to %v: %v", w.repo.name, i, w.placement[i], err)
+			shard.Discard()
+			w.shards[i] = nil
+			continue
+		}
+
+		sum := sha256.Sum256(blocks[i])
+		w.blocks[i] = append(w.blocks[i], sum[:]...)
+	}
+
+	if err := w.checkFailed(); err != nil {
+		return err
+	}
+
+	w.buf = w.buf[:0]
+
+	return nil
+}
+
+// open creates every shard on its node.
+func (w *erasureWriter) open() error {
+	w.shards = make([]objects.Writer, len(w.placement))
+	w.blocks = make([][]byte, len(w.placement))
+
+	for i, node := range w.placement {
+		shard, err := w.repo.createShard(w.ctx, node, w.alloc)
+		if err != nil {
+			w.repo.mod.log.Errorv(1, "erasure %v: create shard %v on %v: %v", w.repo.name, i, node, err)
+			continue
+		}
+		w.shards[i] = shard
+	}
+
+	return w.checkFailed()
+}
+
+func (w *erasureWriter) checkFailed() error {
+	var failed int
+	for _, shard := range w.shards {
+		if shard == nil {
+			failed++
+		}
+	}
+
+	if failed > w.repo.parity {
+		return fmt.Errorf("%d of %d shards failed", failed, len(w.shards))
+	}
+
+	return nil
+}
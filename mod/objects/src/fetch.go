@@ -1,87 +1,474 @@
 package objects
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
+	"os"
+	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/core"
 	"github.com/cryptopunkscc/astrald/lib/arl"
 	"github.com/cryptopunkscc/astrald/lib/query"
 	"github.com/cryptopunkscc/astrald/mod/objects"
+	"github.com/cryptopunkscc/astrald/sig"
 )
 
-func (mod *Module) fetch(addr string) (objectID *astral.ObjectID, err error) {
+const (
+	fetchSaveSize   = 8 << 20 // progress is saved after every this many bytes, so that it survives failures
+	fetchRetries    = 5       // attempts in a row that bring no new data before a fetch is suspended
+	fetchRetryDelay = 5 * time.Second
+)
+
+// fetchTask is a running fetch of a URL or an ARL into the default write repository.
+// Data is appended to a partial file and its state is kept in the database, so a suspended
+// or interrupted fetch continues where it stopped the next time it is started.
+type fetchTask struct {
+	source string
+	done   chan struct{}
+
+	mu       sync.Mutex
+	last     *objects.FetchProgress
+	queue    *sig.Queue[*objects.FetchProgress]
+	objectID *astral.ObjectID
+	err      error
+}
+
+// Fetch starts fetching a URL or an ARL, or returns the task if it's already running.
+func (mod *Module) Fetch(source string) *fetchTask {
+	task := &fetchTask{
+		source: source,
+		done:   make(chan struct{}),
+		last:   &objects.FetchProgress{Source: astral.String16(source)},
+		queue:  &sig.Queue[*objects.FetchProgress]{},
+	}
+
+	if running, ok := mod.fetches.Set(source, task); !ok {
+		return running
+	}
+
+	go mod.runFetch(task)
+
+	return task
+}
+
+// Subscribe returns the current progress and a channel of further updates, which is closed when the task ends.
+func (task *fetchTask) Subscribe(ctx *astral.Context) (*objects.FetchProgress, <-chan *objects.FetchProgress) {
+	task.mu.Lock()
+	defer task.mu.Unlock()
+
+	return task.last, sig.Subscribe(ctx, task.queue)
+}
+
+// Wait blocks until the fetch ends and returns the ID of the fetched object.
+func (task *fetchTask) Wait(ctx *astral.Context) (*astral.ObjectID, error) {
+	select {
+	case <-task.done:
+		return task.objectID, task.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (task *fetchTask) report(row *dbFetch) {
+	task.mu.Lock()
+	defer task.mu.Unlock()
+
+	task.last = &objects.FetchProgress{
+		Source: astral.String16(row.Source),
+		Offset: astral.Uint64(row.Fetched),
+		Size:   astral.Uint64(row.Size),
+	}
+	task.queue = task.queue.Push(task.last)
+}
+
+func (mod *Module) runFetch(task *fetchTask) {
+	ctx := mod.ctx.WithIdentity(mod.node.Identity())
+
+	defer func() {
+		mod.fetches.Delete(task.source)
+		task.mu.Lock()
+		task.queue.Close()
+		task.mu.Unlock()
+		close(task.done)
+	}()
+
+	row, err := mod.db.FindFetch(task.source)
+	if err != nil {
+		row = &dbFetch{Source: task.source}
+		if err = mod.db.SaveFetch(row); err != nil {
+			task.err = err
+			return
+		}
+	}
+	task.report(row)
+
+	for attempt := 1; ; attempt++ {
+		fetched := row.Fetched
+
+		err = mod.fetchOnce(ctx, task, row)
+		if err == nil {
+			break
+		}
+
+		if row.Fetched > fetched {
+			attempt = 1
+		}
+
+		if ctx.Err() != nil || attempt >= fetchRetries {
+			mod.log.Errorv(1, "fetch %v suspended at %v bytes: %v", task.source, row.Fetched, err)
+			task.err = err
+			return
+		}
+
+		mod.log.Logv(1, "fetch %v: %v, retrying", task.source, err)
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(fetchRetryDelay):
+		}
+	}
+
+	task.objectID, task.err = mod.finishFetch(ctx, task.source)
+}
+
+// fetchOnce opens the source at the fetched offset and appends what it returns to the partial
+// file until EOF.
+func (mod *Module) fetchOnce(ctx *astral.Context, task *fetchTask, row *dbFetch) (err error) {
+	f, err := mod.openPartial(row)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	var body io.ReadCloser
+
 	switch {
-	case isURL(addr):
-		return mod.fetchURL(addr)
+	case isURL(row.Source):
+		body, err = mod.fetchURL(ctx, row)
 
-	case isARL(addr):
+	case isARL(row.Source):
 		var a *arl.ARL
 
-		a, err = arl.Parse(addr, mod.Dir)
+		a, err = arl.Parse(row.Source, mod.Dir)
 		if err != nil {
 			return
 		}
-		return mod.fetchARL(a)
+		body, err = mod.fetchARL(ctx, row, a)
+
+	default:
+		err = errors.New("scheme not supported")
+	}
+	if err != nil {
+		return
 	}
+	defer body.Close()
+
+	for {
+		var n int64
+
+		n, err = io.Copy(f, io.LimitReader(body, fetchSaveSize))
+		if n > 0 {
+			if saveErr := mod.savePartial(f, row, n); saveErr != nil {
+				return saveErr
+			}
+			task.report(row)
+		}
 
-	return objectID, errors.New("scheme not supported")
+		switch {
+		case err != nil:
+			return
+		case n < fetchSaveSize:
+			if row.Size > 0 && row.Fetched < row.Size {
+				return io.ErrUnexpectedEOF
+			}
+			return nil
+		}
+	}
 }
 
-func (mod *Module) fetchURL(url string) (objectID *astral.ObjectID, err error) {
-	// Make a GET request to the URL
-	response, err := http.Get(url)
+// openPartial opens the partial file of a fetch for appending. Data written after the last save
+// is dropped; if the file lost saved data, the fetch starts over.
+func (mod *Module) openPartial(row *dbFetch) (*os.File, error) {
+	err := os.MkdirAll(mod.fetchDir, 0700)
 	if err != nil {
-		return
+		return nil, err
+	}
+
+	// why: O_APPEND keeps writes at the end of the file after restartFetch truncates it
+	f, err := os.OpenFile(mod.partialPath(row.Source), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
 	}
-	defer response.Body.Close()
 
-	var alloc = max(response.ContentLength, 0)
+	info, err := f.Stat()
+	if err == nil && uint64(info.Size()) < row.Fetched {
+		mod.log.Logv(1, "fetch %v: partial data is missing, starting over", row.Source)
+		err = mod.restartFetch(row)
+	}
+	if err == nil {
+		err = f.Truncate(int64(row.Fetched))
+	}
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
 
-	ctx := astral.NewContext(nil).WithIdentity(mod.node.Identity())
+	return f, nil
+}
 
-	w, err := mod.WriteDefault().Create(ctx, &objects.CreateOpts{
-		Alloc: int(alloc),
-	})
+// savePartial flushes n bytes appended to the partial file and records them as fetched.
+func (mod *Module) savePartial(f *os.File, row *dbFetch, n int64) error {
+	err := f.Sync()
 	if err != nil {
-		return
+		return err
 	}
-	defer w.Discard()
 
-	_, err = io.Copy(w, response.Body)
+	row.Fetched += uint64(n)
+
+	return mod.db.SaveFetch(row)
+}
+
+// partialPath returns the path of the file that holds the data fetched from source so far.
+func (mod *Module) partialPath(source string) string {
+	hash := sha256.Sum256([]byte(source))
+	return filepath.Join(mod.fetchDir, hex.EncodeToString(hash[:])+".part")
+}
+
+// fetchURL requests the resource from the fetched offset on. If the server can't serve the
+// range, or the resource changed since the previous attempt, the fetch starts over.
+func (mod *Module) fetchURL(ctx *astral.Context, row *dbFetch) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, row.Source, nil)
 	if err != nil {
-		return
+		return nil, err
+	}
+
+	if row.Fetched > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", row.Fetched))
+		if len(row.Validator) > 0 {
+			req.Header.Set("If-Range", row.Validator)
+		}
+	}
+
+	response, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch response.StatusCode {
+	case http.StatusOK:
+		if row.Fetched > 0 {
+			mod.log.Logv(1, "fetch %v: cannot resume, starting over", row.Source)
+			if err = mod.restartFetch(row); err != nil {
+				response.Body.Close()
+				return nil, err
+			}
+		}
+		row.Size = uint64(max(response.ContentLength, 0))
+
+	case http.StatusPartialContent:
+		start, total, ok := parseContentRange(response.Header.Get("Content-Range"))
+		if !ok || start != row.Fetched {
+			response.Body.Close()
+			return nil, errors.New("unexpected content range")
+		}
+		row.Size = total
+
+	case http.StatusRequestedRangeNotSatisfiable:
+		response.Body.Close()
+		if row.Size > 0 && row.Fetched == row.Size {
+			return io.NopCloser(strings.NewReader("")), nil
+		}
+		if err = mod.restartFetch(row); err != nil {
+			return nil, err
+		}
+		return nil, errors.New("requested range not satisfiable")
+
+	default:
+		response.Body.Close()
+		return nil, fmt.Errorf("http: %s", response.Status)
 	}
 
-	return w.Commit()
+	// If-Range only accepts strong validators
+	row.Validator = response.Header.Get("ETag")
+	if strings.HasPrefix(row.Validator, "W/") {
+		row.Validator = ""
+	}
+	if len(row.Validator) == 0 {
+		row.Validator = response.Header.Get("Last-Modified")
+	}
+
+	err = mod.db.SaveFetch(row)
+	if err != nil {
+		response.Body.Close()
+		return nil, err
+	}
+
+	return response.Body, nil
 }
 
-func (mod *Module) fetchARL(a *arl.ARL) (objectID *astral.ObjectID, err error) {
+// fetchARL runs the query of the ARL. An objects.read query is resumed by moving its offset;
+// any other query can't be resumed and starts over.
+func (mod *Module) fetchARL(ctx *astral.Context, row *dbFetch, a *arl.ARL) (io.ReadCloser, error) {
 	if a.Caller.IsZero() {
 		a.Caller = mod.node.Identity()
 	}
 
-	var q = astral.NewQuery(a.Caller, a.Target, a.Query)
+	path, params := core.ParseQuery(a.Query)
+
+	var done bool
+
+	switch {
+	case path == objects.MethodRead:
+		offset, _ := params.GetUint64("offset")
+		limit, _ := params.GetUint64("limit")
+
+		if id, err := params.GetObjectID("id"); err == nil && offset <= id.Size {
+			row.Size = id.Size - offset
+			if limit > 0 {
+				row.Size = min(row.Size, limit)
+			}
+		}
+
+		params["offset"] = strconv.FormatUint(offset+row.Fetched, 10)
+		if limit > 0 {
+			// a limit of 0 reads to the end, so nothing is read once the limit is reached
+			done = row.Fetched >= limit
+			params["limit"] = strconv.FormatUint(limit-min(limit, row.Fetched), 10)
+		}
+
+	case row.Fetched > 0:
+		mod.log.Logv(1, "fetch %v: cannot resume, starting over", row.Source)
+		if err := mod.restartFetch(row); err != nil {
+			return nil, err
+		}
+	}
+
+	err := mod.db.SaveFetch(row)
+	if err != nil {
+		return nil, err
+	}
+
+	if done {
+		return io.NopCloser(strings.NewReader("")), nil
+	}
+
+	var q = astral.NewQuery(a.Caller, a.Target, core.Query(path, params))
+
+	return query.RouteInFlight(ctx, mod.node, astral.Launch(q))
+}
+
+// restartFetch drops the data fetched so far.
+func (mod *Module) restartFetch(row *dbFetch) error {
+	err := os.Truncate(mod.partialPath(row.Source), 0)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	row.Fetched = 0
+	row.Validator = ""
+
+	return mod.db.SaveFetch(row)
+}
+
+// finishFetch stores the data of a completed fetch as the final object.
+func (mod *Module) finishFetch(ctx *astral.Context, source string) (*astral.ObjectID, error) {
+	path := mod.partialPath(source)
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	w, err := mod.WriteDefault().Create(ctx, &objects.CreateOpts{Alloc: int(info.Size())})
+	if err != nil {
+		return nil, err
+	}
+	defer w.Discard()
+
+	_, err = io.Copy(w, f)
+	if err != nil {
+		return nil, err
+	}
+
+	objectID, err := w.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	err = mod.db.DeleteFetch(source)
+	if err != nil {
+		return nil, err
+	}
+
+	err = os.Remove(path)
+	if err != nil {
+		mod.log.Errorv(1, "fetch %v: remove partial file: %v", source, err)
+	}
 
-	conn, err := query.RouteInFlight(mod.ctx, mod.node, astral.Launch(q))
+	return objectID, nil
+}
+
+// resumeFetches restarts fetches that were interrupted by a shutdown.
+func (mod *Module) resumeFetches() {
+	rows, err := mod.db.ListFetches()
 	if err != nil {
+		mod.log.Error("list fetches: %v", err)
+		return
+	}
+
+	for _, row := range rows {
+		mod.log.Logv(1, "resuming fetch of %v at %v bytes", row.Source, row.Fetched)
+		mod.Fetch(row.Source)
+	}
+}
+
+// parseContentRange parses the start and the total size of a "bytes start-end/total" header.
+// The total is 0 if unknown.
+func parseContentRange(s string) (start uint64, total uint64, ok bool) {
+	s, found := strings.CutPrefix(s, "bytes ")
+	if !found {
 		return
 	}
 
-	ctx := astral.NewContext(nil).WithIdentity(mod.node.Identity())
+	rng, size, found := strings.Cut(s, "/")
+	if !found {
+		return
+	}
+
+	first, _, found := strings.Cut(rng, "-")
+	if !found {
+		return
+	}
 
-	w, err := mod.WriteDefault().Create(ctx, nil)
+	start, err := strconv.ParseUint(first, 10, 64)
 	if err != nil {
 		return
 	}
-	defer w.Discard()
 
-	io.Copy(w, conn)
+	if size != "*" {
+		total, err = strconv.ParseUint(size, 10, 64)
+		if err != nil {
+			return
+		}
+	}
 
-	return w.Commit()
+	return start, total, true
 }
 
 func isURL(url string) bool {
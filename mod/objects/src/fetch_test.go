@@ -0,0 +1,123 @@
+package objects
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/core"
+	"github.com/cryptopunkscc/astrald/lib/arl"
+	"github.com/cryptopunkscc/astrald/mod/objects"
+	"github.com/cryptopunkscc/astrald/mod/objects/mem"
+	"github.com/cryptopunkscc/astrald/sig"
+)
+
+// A fetch cut off mid-transfer keeps what it got and continues from there with a Range request.
+func TestFetch_ResumeURL(t *testing.T) {
+	data := make([]byte, 3*fetchSaveSize/2)
+	rand.New(rand.NewSource(5)).Read(data)
+
+	var requests atomic.Int32
+	var ranges []string
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			// announce the full length, but drop the connection after a third of it
+			w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+			w.Header().Set("ETag", `"v1"`)
+			w.WriteHeader(http.StatusOK)
+			w.Write(data[:len(data)/3])
+			return
+		}
+
+		ranges = append(ranges, r.Header.Get("Range"))
+		w.Header().Set("ETag", `"v1"`)
+		http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(data))
+	}))
+	defer srv.Close()
+
+	mod := newTestModule(t)
+	mod.fetchDir = t.TempDir()
+	local := mem.New("local", 1<<30)
+	mod.repos.Set("local", local)
+
+	ctx := astral.NewContext(nil)
+	task := &fetchTask{queue: &sig.Queue[*objects.FetchProgress]{}}
+	row := &dbFetch{Source: srv.URL}
+	if err := mod.db.SaveFetch(row); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := mod.fetchOnce(ctx, task, row); err == nil {
+		t.Fatal("interrupted transfer reported success")
+	}
+	if row.Fetched != uint64(len(data)/3) {
+		t.Fatalf("kept %d bytes of the interrupted transfer, want %d", row.Fetched, len(data)/3)
+	}
+
+	if err := mod.fetchOnce(ctx, task, row); err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+	if len(ranges) != 1 || ranges[0] != "bytes="+strconv.Itoa(len(data)/3)+"-" {
+		t.Fatalf("resumed with ranges %q", ranges)
+	}
+
+	objectID, err := mod.finishFetch(ctx, srv.URL)
+	if err != nil {
+		t.Fatalf("finish: %v", err)
+	}
+	if want, _ := astral.Resolve(bytes.NewReader(data)); !objectID.IsEqual(want) {
+		t.Fatalf("fetched %v, want %v", objectID, want)
+	}
+
+	// the partial file is gone, only the object remains
+	if _, err := os.Stat(mod.partialPath(srv.URL)); !os.IsNotExist(err) {
+		t.Fatalf("partial file left after the fetch: %v", err)
+	}
+	if ids := scanIDs(t, local); len(ids) != 1 || !ids[0].IsEqual(objectID) {
+		t.Fatalf("local repo holds %d objects after the fetch", len(ids))
+	}
+	if _, err := mod.db.FindFetch(srv.URL); err == nil {
+		t.Fatal("fetch state left in the database")
+	}
+}
+
+// A read interrupted right after its limit was reached is resumed without reading any further.
+func TestFetch_ResumeAtLimit(t *testing.T) {
+	// the node has no router, so a query would panic
+	mod := newTestModule(t)
+	mod.fetchDir = t.TempDir()
+	mod.repos.Set("local", mem.New("local", 1<<30))
+
+	id, _ := astral.Resolve(bytes.NewReader(make([]byte, 1000)))
+	a := &arl.ARL{
+		Target: astral.GenerateIdentity(),
+		Query:  core.Query(objects.MethodRead, core.Params{"id": id.String(), "offset": "100", "limit": "500"}),
+	}
+
+	row := &dbFetch{Source: a.String(), Fetched: 500}
+	if err := mod.db.SaveFetch(row); err != nil {
+		t.Fatal(err)
+	}
+
+	body, err := mod.fetchARL(astral.NewContext(nil), row, a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer body.Close()
+
+	if data, err := io.ReadAll(body); err != nil || len(data) != 0 {
+		t.Fatalf("read %d bytes past the limit (%v)", len(data), err)
+	}
+	if row.Size != 500 {
+		t.Fatalf("got size %d, want 500", row.Size)
+	}
+}
@@ -0,0 +1,88 @@
+package objects
+
+import (
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/mod/objects"
+	objectscli "github.com/cryptopunkscc/astrald/mod/objects/client"
+)
+
+// HashTree returns the hash tree of an object held by the node. Trees are built from the full
+// data on first use and cached in the database. Objects are never fetched from the network for this.
+func (mod *Module) HashTree(ctx *astral.Context, objectID *astral.ObjectID) (*objects.HashTree, error) {
+	if tree := mod.cachedHashTree(objectID); tree != nil {
+		return tree, nil
+	}
+
+	r, err := mod.ReadDefault().Read(ctx.WithZone(astral.ZoneDevice|astral.ZoneVirtual), objectID, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	tree, err := objects.BuildHashTree(r, objectID, objects.DefaultHashTreeBlockSize)
+	if err != nil {
+		return nil, err
+	}
+
+	err = mod.db.CreateHashTree(tree)
+	if err != nil {
+		mod.log.Errorv(1, "cache hash tree of %v: %v", objectID, err)
+	}
+
+	return tree, nil
+}
+
+// cachedHashTree returns the hash tree of an object cached in the database, or nil. Cached trees
+// were built from data that resolved to the object ID, so they can be trusted.
+func (mod *Module) cachedHashTree(objectID *astral.ObjectID) *objects.HashTree {
+	row, err := mod.db.FindHashTree(objectID)
+	if err != nil {
+		return nil
+	}
+
+	tree := &objects.HashTree{
+		ObjectID:  objectID,
+		BlockSize: astral.Uint32(row.BlockSize),
+		Leaves:    row.Leaves,
+	}
+	if tree.Validate() != nil {
+		return nil
+	}
+
+	return tree
+}
+
+// trustsHashTrees checks if hash trees served by a node can be trusted. A tree cannot be checked
+// against the object ID without the whole object, so only trees built by the node itself or by
+// a member of its swarm are used.
+func (mod *Module) trustsHashTrees(nodeID *astral.Identity) bool {
+	if nodeID.IsEqual(mod.node.Identity()) {
+		return true
+	}
+	if mod.User == nil {
+		return false
+	}
+
+	for _, member := range mod.User.LocalSwarm() {
+		if member.IsEqual(nodeID) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// remoteHashTree asks a provider for the hash tree of an object and checks that it is well-formed.
+// A well-formed tree is not authenticated; use it only if the provider is trusted (see trustsHashTrees).
+func (mod *Module) remoteHashTree(ctx *astral.Context, provider *astral.Identity, objectID *astral.ObjectID) (*objects.HashTree, error) {
+	tree, err := objectscli.New(provider, nil).HashTree(ctx, objectID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !tree.ObjectID.IsEqual(objectID) {
+		return nil, objects.ErrHashMismatch
+	}
+
+	return tree, tree.Validate()
+}
@@ -0,0 +1,235 @@
+package objects
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math/rand"
+	"testing"
+
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/mod/objects"
+	"github.com/cryptopunkscc/astrald/mod/user"
+)
+
+// testSwarm is a user module that only knows the members of the swarm.
+type testSwarm struct {
+	user.Module
+	members []*astral.Identity
+}
+
+func (s testSwarm) LocalSwarm() []*astral.Identity { return s.members }
+
+// Any window read through a verifying reader matches the data, whatever the block alignment.
+func TestHashTree_VerifyingReader(t *testing.T) {
+	const blockSize = 1000
+
+	data := make([]byte, 10*blockSize+123)
+	rand.New(rand.NewSource(4)).Read(data)
+	id, _ := astral.Resolve(bytes.NewReader(data))
+
+	tree, err := objects.BuildHashTree(bytes.NewReader(data), id, blockSize)
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	if err := tree.Validate(); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+
+	for _, w := range []struct{ offset, limit int64 }{
+		{0, 0},
+		{0, 1},
+		{blockSize - 1, 2},
+		{3*blockSize + 7, 4 * blockSize},
+		{int64(len(data)) - 5, 0},
+		{int64(len(data)), 0},
+	} {
+		start, length := tree.Align(w.offset, w.limit)
+		if start%blockSize != 0 || start > w.offset {
+			t.Fatalf("window %d+%d: bad aligned start %d", w.offset, w.limit, start)
+		}
+
+		got, err := io.ReadAll(tree.NewVerifyingReader(bytes.NewReader(data[start:start+length]), start, w.offset, w.limit))
+		if err != nil {
+			t.Fatalf("window %d+%d: %v", w.offset, w.limit, err)
+		}
+
+		end := int64(len(data))
+		if w.limit > 0 {
+			end = w.offset + w.limit
+		}
+		if !bytes.Equal(got, data[w.offset:end]) {
+			t.Fatalf("window %d+%d: got %d bytes, want %d", w.offset, w.limit, len(got), end-w.offset)
+		}
+	}
+}
+
+// A corrupted block is rejected before any of its bytes are returned, and changes the root.
+func TestHashTree_Tamper(t *testing.T) {
+	const blockSize = 64
+
+	data := bytes.Repeat([]byte("0123456789abcdef"), 20)
+	id, _ := astral.Resolve(bytes.NewReader(data))
+
+	tree, err := objects.BuildHashTree(bytes.NewReader(data), id, blockSize)
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+
+	bad := bytes.Clone(data)
+	bad[2*blockSize+1] ^= 1
+
+	if _, err := objects.BuildHashTree(bytes.NewReader(bad), id, blockSize); !errors.Is(err, objects.ErrHashMismatch) {
+		t.Fatalf("built a tree from data that does not match the id: %v", err)
+	}
+
+	r := tree.NewVerifyingReader(bytes.NewReader(bad[blockSize:]), blockSize, blockSize+10, 0)
+	got, err := io.ReadAll(r)
+	if !errors.Is(err, objects.ErrHashMismatch) {
+		t.Fatalf("expected hash mismatch, got %v", err)
+	}
+	if len(got) != blockSize-10 {
+		t.Fatalf("returned %d bytes, want only the intact first block (%d)", len(got), blockSize-10)
+	}
+
+	badID, _ := astral.Resolve(bytes.NewReader(bad))
+	badTree, _ := objects.BuildHashTree(bytes.NewReader(bad), badID, blockSize)
+	if badTree.Root() == tree.Root() {
+		t.Fatal("root does not change with the data")
+	}
+}
+
+// A hash tree served by a provider outside the swarm is never asked for, so a forged tree cannot
+// reject valid pieces; a tree from a swarm member or the local cache is used.
+func TestDownload_ForgedTree(t *testing.T) {
+	sibling := astral.GenerateIdentity()
+	mod := newTestModule(t)
+	mod.User = testSwarm{members: []*astral.Identity{sibling}}
+
+	data := make([]byte, 3*objects.DefaultHashTreeBlockSize+100)
+	rand.New(rand.NewSource(5)).Read(data)
+	id, _ := astral.Resolve(bytes.NewReader(data))
+
+	tree, err := objects.BuildHashTree(bytes.NewReader(data), id, objects.DefaultHashTreeBlockSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a well-formed tree of other data, claiming to describe the object
+	other := bytes.Clone(data)
+	other[0] ^= 1
+	otherID, _ := astral.Resolve(bytes.NewReader(other))
+	forged, _ := objects.BuildHashTree(bytes.NewReader(other), otherID, objects.DefaultHashTreeBlockSize)
+	forged.ObjectID = id
+	if err := forged.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := astral.NewContext(nil)
+
+	d := newDownload(mod, id, nil)
+	var asked int
+	d.fetchTree = func(*astral.Context, *astral.Identity, *astral.ObjectID) (*objects.HashTree, error) {
+		asked++
+		return forged, nil
+	}
+
+	d.loadTree(ctx, astral.GenerateIdentity())
+	if asked != 0 || d.tree != nil {
+		t.Fatal("asked a provider outside the swarm for the hash tree")
+	}
+	if err := d.verify(0, data); err != nil {
+		t.Fatalf("valid piece rejected: %v", err)
+	}
+
+	d.fetchTree = func(*astral.Context, *astral.Identity, *astral.ObjectID) (*objects.HashTree, error) {
+		return tree, nil
+	}
+	d.loadTree(ctx, sibling)
+	if d.tree == nil {
+		t.Fatal("hash tree of a swarm member not used")
+	}
+	if err := d.verify(0, other); !errors.Is(err, objects.ErrHashMismatch) {
+		t.Fatalf("corrupted piece accepted: %v", err)
+	}
+
+	// a cached tree is trusted from the start
+	if err := mod.db.CreateHashTree(tree); err != nil {
+		t.Fatal(err)
+	}
+	d = newDownload(mod, id, nil)
+	if err := d.verify(0, other); !errors.Is(err, objects.ErrHashMismatch) {
+		t.Fatalf("cached hash tree not used: %v", err)
+	}
+}
+
+// Reads from untrusted nodes are checked block by block with a trusted tree; without one, a read
+// of the whole object is checked against the object ID.
+func TestCheckedRead(t *testing.T) {
+	const blockSize = 100
+
+	data := make([]byte, 10*blockSize+50)
+	rand.New(rand.NewSource(6)).Read(data)
+	id, _ := astral.Resolve(bytes.NewReader(data))
+
+	tree, err := objects.BuildHashTree(bytes.NewReader(data), id, blockSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bad := bytes.Clone(data)
+	bad[5*blockSize+1] ^= 1
+
+	read := func(check checkedRead, src []byte) ([]byte, error) {
+		start, length := check.window()
+		end := int64(len(src))
+		if length > 0 {
+			end = start + length
+		}
+		return io.ReadAll(check.wrap(io.NopCloser(bytes.NewReader(src[start:end]))))
+	}
+
+	got, err := read(checkedRead{objectID: id, tree: tree, offset: 250, limit: 300}, data)
+	if err != nil || !bytes.Equal(got, data[250:550]) {
+		t.Fatalf("window read with a tree: %v", err)
+	}
+	if _, err := read(checkedRead{objectID: id, tree: tree, offset: 450, limit: 300}, bad); !errors.Is(err, objects.ErrHashMismatch) {
+		t.Fatalf("corrupted window read with a tree: %v", err)
+	}
+
+	got, err = read(checkedRead{objectID: id}, data)
+	if err != nil || !bytes.Equal(got, data) {
+		t.Fatalf("whole read: %v", err)
+	}
+	if _, err := read(checkedRead{objectID: id}, bad); !errors.Is(err, objects.ErrHashMismatch) {
+		t.Fatalf("corrupted whole read: %v", err)
+	}
+}
+
+// The tree of downloaded data is cached once the data resolved to the object ID.
+func TestDownload_CacheTree(t *testing.T) {
+	mod := newTestModule(t)
+
+	data := make([]byte, 2*objects.DefaultHashTreeBlockSize+100)
+	rand.New(rand.NewSource(7)).Read(data)
+	id, _ := astral.Resolve(bytes.NewReader(data))
+
+	d := newDownload(mod, id, nil)
+	d.builder.Write(data[:1000])
+	if _, err := d.builder.Tree(id); !errors.Is(err, objects.ErrHashMismatch) {
+		t.Fatalf("tree of partial data: %v", err)
+	}
+	d.builder.Write(data[1000:])
+
+	tree, err := d.builder.Tree(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.cacheTree(tree)
+
+	want, _ := objects.BuildHashTree(bytes.NewReader(data), id, objects.DefaultHashTreeBlockSize)
+	cached := mod.cachedHashTree(id)
+	if cached == nil || cached.Root() != want.Root() {
+		t.Fatal("hash tree of the downloaded data was not cached")
+	}
+}
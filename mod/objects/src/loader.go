@@ -1,12 +1,17 @@
 package objects
 
 import (
+	"os"
+	"path/filepath"
+
 	"github.com/cryptopunkscc/astrald/astral"
 	"github.com/cryptopunkscc/astrald/astral/log"
 	"github.com/cryptopunkscc/astrald/core"
 	"github.com/cryptopunkscc/astrald/core/assets"
+	"github.com/cryptopunkscc/astrald/mod/crypto"
 	"github.com/cryptopunkscc/astrald/mod/objects"
 	"github.com/cryptopunkscc/astrald/mod/objects/mem"
+	"github.com/cryptopunkscc/astrald/resources"
 )
 
 type Loader struct{}
@@ -27,11 +32,39 @@ func (Loader) Load(node astral.Node, assets assets.Assets, log *log.Logger) (cor
 
 	mod.setupDefaultRepos()
 
+	// keep unfinished fetches next to the node data, so they survive restarts
+	mod.fetchDir = filepath.Join(os.TempDir(), "astrald-fetches")
+	if res, ok := assets.Res().(*resources.FileResources); ok {
+		mod.fetchDir = filepath.Join(res.DataRoot(), "fetches")
+	}
+
+	// keep quarantined data, pinned objects and shards of other nodes
+	mod.holders.Add(&quarantineHolder{mod: mod})
+	mod.holders.Add(&pinHolder{mod: mod})
+
+	shards := &shardHolder{mod: mod}
+	mod.holders.Add(shards)
+	mod.receivers.Add(shards)
+
+	mod.extractors.Add(&typedExtractor{mod: mod})
+	mod.receivers.Add(&nameReceiver{mod: mod})
+
 	err := mod.db.Migrate()
 	if err != nil {
 		return nil, err
 	}
 
+	mod.describers.Add(&contentTypeDescriber{mod: mod})
+
+	// the text index needs FTS5, search works without it
+	err = mod.db.MigrateTextIndex()
+	if err != nil {
+		mod.log.Error("text index unavailable: %v", err)
+	} else {
+		mod.textSearcher = &textSearcher{mod: mod}
+		mod.searchers.Add(mod.textSearcher)
+	}
+
 	return mod, nil
 }
 
@@ -72,9 +105,61 @@ func (mod *Module) setupDefaultRepos() {
 	mod.repos.Set("mem0", mem0)
 	memory.Add("mem0")
 
+	// cache of network reads
+	if len(mod.config.Cache.Repo) == 0 && mod.config.Cache.Size > 0 {
+		mod.repos.Set(objects.RepoCache, mem.New("Network cache", mod.config.Cache.Size))
+		memory.Add(objects.RepoCache)
+	}
+
 	mod.system = mem.New("System memory", mod.config.DefaultMemSize)
 	mod.repos.Set(objects.RepoSystem, mod.system)
 	memory.Add(objects.RepoSystem)
+
+	// chunk repos
+	for name, cfg := range mod.config.Chunks {
+		if len(cfg.Label) == 0 {
+			cfg.Label = "Chunks (" + name + ")"
+		}
+
+		repo := NewChunkRepository(mod, name, cfg.Label, cfg.Store)
+		mod.repos.Set(name, repo)
+		virtual.Add(name)
+		mod.holders.Add(repo)
+	}
+
+	// encrypted repos
+	for name, cfg := range mod.config.Encrypted {
+		if len(cfg.Label) == 0 {
+			cfg.Label = "Encrypted (" + name + ")"
+		}
+
+		var key *crypto.PublicKey
+		if len(cfg.Key) > 0 {
+			key = &crypto.PublicKey{}
+			if err := key.UnmarshalText([]byte(cfg.Key)); err != nil {
+				mod.log.Error("encrypted repo %v: invalid key: %v", name, err)
+				continue
+			}
+		}
+
+		repo := NewEncryptedRepository(mod, name, cfg.Label, cfg.Store, key)
+		mod.repos.Set(name, repo)
+		virtual.Add(name)
+		mod.holders.Add(repo)
+	}
+
+	// erasure-coded repos
+	for name, cfg := range mod.config.Erasure {
+		repo, err := NewErasureRepository(mod, name, cfg)
+		if err != nil {
+			mod.log.Error("erasure repo %v: %v", name, err)
+			continue
+		}
+
+		mod.repos.Set(name, repo)
+		virtual.Add(name)
+		mod.holders.Add(repo)
+	}
 }
 
 func init() {
@@ -5,7 +5,6 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"net/http"
 	"strings"
 	"sync"
 	"time"
@@ -14,9 +13,12 @@ import (
 	"github.com/cryptopunkscc/astrald/astral/log"
 	"github.com/cryptopunkscc/astrald/lib/routing"
 	"github.com/cryptopunkscc/astrald/mod/auth"
+	"github.com/cryptopunkscc/astrald/mod/crypto"
 	"github.com/cryptopunkscc/astrald/mod/dir"
 	"github.com/cryptopunkscc/astrald/mod/nodes"
 	"github.com/cryptopunkscc/astrald/mod/objects"
+	"github.com/cryptopunkscc/astrald/mod/scheduler"
+	"github.com/cryptopunkscc/astrald/mod/user"
 	"github.com/cryptopunkscc/astrald/sig"
 )
 
@@ -25,13 +27,20 @@ var _ objects.Module = &Module{}
 const defaultExternalDiscovererTimeout = 15 * time.Second
 
 type Deps struct {
-	Auth  auth.Module
-	Dir   dir.Module
-	Nodes nodes.Module
+	Auth      auth.Module
+	Crypto    crypto.Module
+	Dir       dir.Module
+	Nodes     nodes.Module
+	Scheduler scheduler.Module
+}
+
+type OptionalDeps struct {
+	User user.Module
 }
 
 type Module struct {
 	Deps
+	OptionalDeps
 	node   astral.Node
 	config Config
 	db     *DB
@@ -46,11 +55,20 @@ type Module struct {
 	finders    sig.Set[objects.Finder]
 	receivers  sig.Set[objects.Receiver]
 	holders    sig.Set[objects.Holder]
+	extractors sig.Set[objects.ReferenceExtractor]
 	repos      sig.Map[string, objects.Repository]
+	fetches    sig.Map[string, *fetchTask]
+	fetchDir   string // partial files of unfinished fetches
+	scrubbing  sig.Set[string]
+
+	searchSessions sig.Map[string, *searchSession]
+
+	textSearcher *textSearcher // nil if the text index is unavailable
 
 	externalMu sync.Mutex
 
 	groups              sig.Map[string, *RepoGroup]
+	usage               sig.Map[string, *repoUsage] // usage of repositories with a quota
 	objectsReadsJournal *objectsReadsJournal
 }
 
@@ -59,6 +77,22 @@ type Module struct {
 func (mod *Module) Run(ctx *astral.Context) error {
 	mod.ctx = ctx
 
+	go mod.resumeFetches()
+	for name := range mod.config.Quotas {
+		go mod.trackUsage(ctx, name)
+	}
+	if mod.textSearcher != nil {
+		go mod.runTextIndex(ctx)
+	}
+
+	<-mod.Scheduler.Ready()
+	if len(mod.config.Quotas) > 0 {
+		mod.Scheduler.Schedule(mod.newQuotaTask())
+	}
+	if len(mod.config.Scrub.Repos) > 0 {
+		mod.Scheduler.Schedule(mod.newScrubTask())
+	}
+
 	<-ctx.Done()
 
 	err := mod.objectsReadsJournal.Flush()
@@ -200,7 +234,7 @@ func (mod *Module) Probe(ctx *astral.Context, repo objects.Repository, objectID
 	}
 
 	// check the mimeType
-	probe.Mime = astral.String8(http.DetectContentType(data))
+	probe.Mime = astral.String8(sniffContentType(data))
 
 	return
 }
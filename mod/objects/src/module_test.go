@@ -0,0 +1,40 @@
+package objects
+
+import (
+	"testing"
+
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/astral/log"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+type testNode struct {
+	astral.Router
+	identity *astral.Identity
+}
+
+func (n *testNode) Identity() *astral.Identity { return n.identity }
+
+// newTestModule returns a module with a migrated in-memory database, a node with a new identity
+// and no repositories.
+func newTestModule(t *testing.T) *Module {
+	t.Helper()
+
+	gdb, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+
+	mod := &Module{
+		db:   &DB{DB: gdb},
+		log:  log.New(nil),
+		node: &testNode{identity: astral.GenerateIdentity()},
+	}
+	if err := mod.db.Migrate(); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	mod.objectsReadsJournal = newObjectsReadsJournal(mod.db.UpdateReadAt, nil)
+
+	return mod
+}
@@ -0,0 +1,196 @@
+package objects
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/astral/channel"
+	"github.com/cryptopunkscc/astrald/lib/query"
+	"github.com/cryptopunkscc/astrald/mod/objects"
+	"github.com/cryptopunkscc/astrald/mod/secp256k1"
+)
+
+const resolveNameTimeout = 15 * time.Second
+
+var errNameMismatch = errors.New("name record does not match the query")
+
+// PublishName signs and stores a new record pointing the node's name at objectID. The record
+// supersedes every earlier record of the name.
+func (mod *Module) PublishName(ctx *astral.Context, name string, objectID *astral.ObjectID) (*objects.SignedName, error) {
+	if err := objects.ValidateName(name); err != nil {
+		return nil, err
+	}
+	if objectID == nil || objectID.IsZero() {
+		return nil, errors.New("object id is required")
+	}
+
+	publisher := mod.node.Identity()
+
+	prev, err := mod.db.FindName(publisher, name)
+	if err != nil {
+		return nil, err
+	}
+
+	body := &objects.Name{
+		Publisher: publisher,
+		Name:      astral.String8(name),
+		ObjectID:  objectID,
+		Sequence:  1,
+		IssuedAt:  astral.Now(),
+	}
+	if prev != nil {
+		body.Sequence = prev.Sequence + 1
+	}
+
+	sig, err := mod.Crypto.Sign(ctx, secp256k1.FromIdentity(publisher), body)
+	if err != nil {
+		return nil, err
+	}
+
+	signed := &objects.SignedName{Name: body, Signature: sig}
+
+	saved, err := mod.db.SaveName(signed)
+	switch {
+	case err != nil:
+		return nil, err
+	case !saved:
+		return nil, errors.New("a newer record of the name exists")
+	}
+
+	return signed, nil
+}
+
+// ResolveName returns the record of the name with the highest sequence known. If the network
+// zone is included in the context, the publisher and every identity in via are asked for their
+// records as well. Every remote record is verified before it is stored or returned.
+func (mod *Module) ResolveName(ctx *astral.Context, publisher *astral.Identity, name string, via ...*astral.Identity) (*objects.SignedName, error) {
+	if err := objects.ValidateName(name); err != nil {
+		return nil, err
+	}
+
+	best, err := mod.db.FindName(publisher, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if ctx.Zone().Is(astral.ZoneNetwork) {
+		for _, signed := range mod.resolveRemoteName(ctx, publisher, name, via) {
+			if best == nil || signed.Sequence > best.Sequence {
+				best = signed
+			}
+		}
+	}
+
+	if best == nil {
+		return nil, objects.ErrNameNotFound
+	}
+
+	return best, nil
+}
+
+// resolveRemoteName asks the publisher and the given holders for their records of a name.
+func (mod *Module) resolveRemoteName(ctx *astral.Context, publisher *astral.Identity, name string, via []*astral.Identity) (found []*objects.SignedName) {
+	ctx, cancel := ctx.WithTimeout(resolveNameTimeout)
+	defer cancel()
+
+	var targets []*astral.Identity
+	for _, id := range append([]*astral.Identity{publisher}, via...) {
+		if id.IsZero() || id.IsEqual(mod.node.Identity()) {
+			continue
+		}
+		targets = append(targets, id)
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, target := range targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			signed, err := mod.queryName(ctx, target, publisher, name)
+			if err != nil {
+				mod.log.Logv(2, "resolve name %v from %v: %v", name, target, err)
+				return
+			}
+
+			_, err = mod.db.SaveName(signed)
+			if err != nil {
+				mod.log.Error("resolve name %v: save: %v", name, err)
+			}
+
+			mu.Lock()
+			found = append(found, signed)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	return
+}
+
+// queryName fetches the local record of a name from a remote node and verifies it.
+func (mod *Module) queryName(ctx *astral.Context, target *astral.Identity, publisher *astral.Identity, name string) (*objects.SignedName, error) {
+	q := query.New(mod.node.Identity(), target, objects.MethodResolveName, query.Args{
+		"name":      name,
+		"publisher": publisher,
+	})
+
+	ch, err := query.Route(ctx, mod.node, q)
+	if err != nil {
+		return nil, err
+	}
+	defer ch.Close()
+
+	var signed *objects.SignedName
+	err = ch.Switch(channel.Expect(&signed), channel.PassErrors, channel.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	if signed.IsNil() || !signed.Publisher.IsEqual(publisher) || string(signed.Name.Name) != name {
+		return nil, errNameMismatch
+	}
+
+	return signed, mod.verifyName(signed)
+}
+
+// verifyName checks that a name record is well-formed and signed by its publisher.
+func (mod *Module) verifyName(signed *objects.SignedName) error {
+	if signed.IsNil() || signed.Publisher.IsZero() || signed.ObjectID == nil || signed.Signature == nil {
+		return errors.New("incomplete name record")
+	}
+	if err := objects.ValidateName(string(signed.Name.Name)); err != nil {
+		return err
+	}
+
+	return mod.Crypto.Verify(secp256k1.FromIdentity(signed.Publisher), signed.Signature, signed.Name)
+}
+
+var _ objects.Receiver = &nameReceiver{}
+
+// nameReceiver stores name records pushed by other nodes after verifying them.
+type nameReceiver struct {
+	mod *Module
+}
+
+func (r *nameReceiver) ReceiveObject(drop objects.Drop) error {
+	signed, ok := drop.Object().(*objects.SignedName)
+	if !ok {
+		return nil
+	}
+
+	if err := r.mod.verifyName(signed); err != nil {
+		r.mod.log.Errorv(1, "rejecting name record from %v: %v", drop.SenderID(), err)
+		return objects.ErrPushRejected
+	}
+
+	_, err := r.mod.db.SaveName(signed)
+	if err != nil {
+		return err
+	}
+
+	return drop.Accept(false)
+}
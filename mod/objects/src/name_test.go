@@ -0,0 +1,119 @@
+package objects
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/mod/crypto"
+	"github.com/cryptopunkscc/astrald/mod/objects"
+	"github.com/cryptopunkscc/astrald/mod/secp256k1"
+)
+
+// testSigner is a crypto module stand-in that signs hashes with a single secp256k1 key.
+type testSigner struct {
+	crypto.Module
+	key *crypto.PrivateKey
+}
+
+func (s *testSigner) Sign(_ *astral.Context, _ *crypto.PublicKey, obj crypto.SignableTextObject) (*crypto.Signature, error) {
+	return secp256k1.SignASN1(s.key, obj.SignableHash())
+}
+
+func (s *testSigner) Verify(key *crypto.PublicKey, sig *crypto.Signature, obj crypto.SignableTextObject) error {
+	return secp256k1.VerifyASN1(key, obj.SignableHash(), sig)
+}
+
+func testNameModule(t *testing.T) *Module {
+	t.Helper()
+
+	// the node signs with its own key
+	key := secp256k1.New()
+	mod := newTestModule(t)
+	mod.node = &testNode{identity: secp256k1.Identity(secp256k1.PublicKey(key))}
+	mod.Crypto = &testSigner{key: key}
+
+	return mod
+}
+
+func testObjectID(t *testing.T, s string) *astral.ObjectID {
+	t.Helper()
+
+	id, err := astral.Resolve(strings.NewReader(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+// Every publish supersedes the previous record and resolves to the latest target.
+func TestName_PublishResolve(t *testing.T) {
+	mod := testNameModule(t)
+	ctx := astral.NewContext(nil).WithZone(astral.ZoneDevice)
+
+	first, second := testObjectID(t, "first"), testObjectID(t, "second")
+
+	if _, err := mod.PublishName(ctx, "site", first); err != nil {
+		t.Fatal(err)
+	}
+	signed, err := mod.PublishName(ctx, "site", second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signed.Sequence != 2 {
+		t.Fatalf("got sequence %d, want 2", signed.Sequence)
+	}
+
+	resolved, err := mod.ResolveName(ctx, mod.node.Identity(), "site")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resolved.ObjectID.IsEqual(second) {
+		t.Fatalf("resolved to %v, want %v", resolved.ObjectID, second)
+	}
+	if err := mod.verifyName(resolved); err != nil {
+		t.Fatalf("stored record does not verify: %v", err)
+	}
+
+	// an older record does not replace a newer one
+	saved, err := mod.db.SaveName(&objects.SignedName{Name: &objects.Name{
+		Publisher: signed.Publisher,
+		Name:      signed.Name.Name,
+		ObjectID:  first,
+		Sequence:  1,
+	}, Signature: signed.Signature})
+	if err != nil || saved {
+		t.Fatalf("stale record saved (err %v)", err)
+	}
+
+	if _, err := mod.ResolveName(ctx, mod.node.Identity(), "other"); !errors.Is(err, objects.ErrNameNotFound) {
+		t.Fatalf("got %v, want ErrNameNotFound", err)
+	}
+	if _, err := mod.PublishName(ctx, "with space", first); !errors.Is(err, objects.ErrInvalidName) {
+		t.Fatalf("got %v, want ErrInvalidName", err)
+	}
+}
+
+// A record pointed at another object or signed by another key fails verification.
+func TestName_VerifyTampered(t *testing.T) {
+	mod := testNameModule(t)
+	ctx := astral.NewContext(nil).WithZone(astral.ZoneDevice)
+
+	signed, err := mod.PublishName(ctx, "site", testObjectID(t, "first"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tampered := *signed.Name
+	tampered.ObjectID = testObjectID(t, "evil")
+	if err := mod.verifyName(&objects.SignedName{Name: &tampered, Signature: signed.Signature}); err == nil {
+		t.Fatal("tampered target verified")
+	}
+
+	forged := *signed.Name
+	forged.Publisher = astral.GenerateIdentity()
+	if err := mod.verifyName(&objects.SignedName{Name: &forged, Signature: signed.Signature}); err == nil {
+		t.Fatal("forged publisher verified")
+	}
+}
@@ -7,15 +7,18 @@ import (
 	"github.com/cryptopunkscc/astrald/astral"
 	"github.com/cryptopunkscc/astrald/core"
 	"github.com/cryptopunkscc/astrald/lib/query"
+	"github.com/cryptopunkscc/astrald/mod/objects"
 )
 
 // NetworkReader streams an object from a remote provider. Seeking reopens the
-// underlying connection at a new offset rather than seeking in place.
+// underlying connection at a new offset rather than seeking in place. What the
+// provider sends is checked as described in checkedRead.
 type NetworkReader struct {
 	mod      *Module
 	objectID *astral.ObjectID
 	consumer *astral.Identity
 	provider *astral.Identity
+	tree     *objects.HashTree // trusted hash tree of the object or nil
 
 	pos int64
 	io.ReadCloser
@@ -32,10 +35,6 @@ func (r *NetworkReader) Read(p []byte) (n int, err error) {
 func (r *NetworkReader) Seek(offset int64, whence int) (int64, error) {
 	r.ReadCloser.Close()
 
-	params := core.Params{
-		"id": r.objectID.String(),
-	}
-
 	var o int64
 
 	switch whence {
@@ -46,7 +45,14 @@ func (r *NetworkReader) Seek(offset int64, whence int) (int64, error) {
 	case io.SeekEnd:
 		o = int64(r.objectID.Size) + offset
 	}
-	params.SetInt("offset", int(o))
+
+	check := checkedRead{objectID: r.objectID, tree: r.tree, offset: o}
+	start, _ := check.window()
+
+	params := core.Params{
+		"id": r.objectID.String(),
+	}
+	params.SetInt("offset", int(start))
 
 	var q = astral.NewQuery(
 		r.consumer,
@@ -66,8 +72,89 @@ func (r *NetworkReader) Seek(offset int64, whence int) (int64, error) {
 		return 0, err
 	}
 
-	r.ReadCloser = conn
+	r.ReadCloser = check.wrap(conn)
 	r.pos = o
 
 	return 0, nil
 }
+
+// checkedRead is a read of a window of an object from a node that isn't trusted. With a trusted
+// hash tree, the read starts at a block boundary and every block is verified before any of its
+// bytes are returned. Without one, only a read of the whole object can be checked: it ends with
+// objects.ErrHashMismatch instead of io.EOF if the data doesn't resolve to the object ID.
+type checkedRead struct {
+	objectID *astral.ObjectID
+	tree     *objects.HashTree
+	offset   int64
+	limit    int64
+}
+
+// window returns the range to request from the node.
+func (c checkedRead) window() (offset, limit int64) {
+	if c.tree == nil {
+		return c.offset, c.limit
+	}
+	return c.tree.Align(c.offset, c.limit)
+}
+
+// whole checks if the window covers the whole object.
+func (c checkedRead) whole() bool {
+	return c.offset == 0 && (c.limit == 0 || uint64(c.limit) >= c.objectID.Size)
+}
+
+// wrap returns a reader of the requested window of the data read from the node.
+func (c checkedRead) wrap(r io.ReadCloser) io.ReadCloser {
+	if c.tree != nil {
+		start, _ := c.window()
+		return &checkedReader{Reader: c.tree.NewVerifyingReader(r, start, c.offset, c.limit), Closer: r}
+	}
+
+	if c.whole() {
+		return &checkedReader{Reader: &resolvingReader{r: r, objectID: c.objectID, resolver: astral.NewWriteResolver(nil)}, Closer: r}
+	}
+
+	return r
+}
+
+type checkedReader struct {
+	io.Reader
+	io.Closer
+}
+
+// resolvingReader fails at the end of the data if it doesn't resolve to the object ID.
+type resolvingReader struct {
+	r        io.Reader
+	objectID *astral.ObjectID
+	resolver *astral.WriteResolver
+}
+
+func (r *resolvingReader) Read(p []byte) (n int, err error) {
+	n, err = r.r.Read(p)
+	r.resolver.Write(p[:n])
+
+	if err == io.EOF && !r.resolver.Resolve().IsEqual(r.objectID) {
+		err = objects.ErrHashMismatch
+	}
+
+	return
+}
+
+// trustedHashTree returns the hash tree of an object cached by the node or, if the provider is
+// trusted (see trustsHashTrees), served by the provider. Returns nil if neither has one.
+func (mod *Module) trustedHashTree(ctx *astral.Context, provider *astral.Identity, objectID *astral.ObjectID) *objects.HashTree {
+	if tree := mod.cachedHashTree(objectID); tree != nil {
+		return tree
+	}
+
+	if !mod.trustsHashTrees(provider) {
+		return nil
+	}
+
+	tree, err := mod.remoteHashTree(ctx, provider, objectID)
+	if err != nil {
+		mod.log.Logv(2, "no hash tree of %v from %v: %v", objectID, provider, err)
+		return nil
+	}
+
+	return tree
+}
@@ -0,0 +1,39 @@
+package objects
+
+import (
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/astral/channel"
+	"github.com/cryptopunkscc/astrald/lib/routing"
+)
+
+type opAddRemoteRepoArgs struct {
+	Name  string
+	Node  string
+	Repo  string `query:"optional"`
+	Label string `query:"optional"`
+	In    string `query:"optional"`
+	Out   string `query:"optional"`
+}
+
+// OpAddRemoteRepo mounts a repository of another node in the network group under a local name.
+// Use objects.remove_repository to unmount it.
+func (mod *Module) OpAddRemoteRepo(ctx *astral.Context, q *routing.IncomingQuery, args opAddRemoteRepoArgs) (err error) {
+	if !q.Caller().IsEqual(mod.node.Identity()) {
+		return q.Reject()
+	}
+
+	ch := channel.New(q.AcceptRaw(), channel.WithFormats(args.In, args.Out))
+	defer ch.Close()
+
+	node, err := mod.Dir.ResolveIdentity(args.Node)
+	if err != nil {
+		return ch.Send(astral.Err(err))
+	}
+
+	err = mod.AddRemoteRepository(args.Name, node, args.Repo, args.Label)
+	if err != nil {
+		return ch.Send(astral.Err(err))
+	}
+
+	return ch.Send(&astral.Ack{})
+}
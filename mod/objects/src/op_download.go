@@ -0,0 +1,43 @@
+package objects
+
+import (
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/astral/channel"
+	"github.com/cryptopunkscc/astrald/lib/routing"
+)
+
+type opDownloadArgs struct {
+	ID   *astral.ObjectID
+	Repo string `query:"optional"`
+	Out  string `query:"optional"`
+}
+
+// OpDownload fetches an object from all providers that have it and stores it in a
+// repository (the default write repository if none is given). Replies with the ObjectID.
+func (mod *Module) OpDownload(ctx *astral.Context, q *routing.IncomingQuery, args opDownloadArgs) error {
+	if !q.Caller().IsEqual(mod.node.Identity()) {
+		return q.Reject()
+	}
+
+	ch := channel.New(q.AcceptRaw(), channel.WithOutputFormat(args.Out))
+	defer ch.Close()
+
+	if args.ID == nil || args.ID.IsZero() {
+		return ch.Send(astral.NewError("id is required"))
+	}
+
+	repo := mod.WriteDefault()
+	if len(args.Repo) > 0 {
+		repo = mod.GetRepository(args.Repo)
+		if repo == nil {
+			return ch.Send(astral.NewError("repository not found"))
+		}
+	}
+
+	err := mod.Download(ctx.WithIdentity(q.Caller()).IncludeZone(astral.ZoneNetwork), args.ID, repo)
+	if err != nil {
+		return ch.Send(astral.Err(err))
+	}
+
+	return ch.Send(args.ID)
+}
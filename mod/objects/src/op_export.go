@@ -0,0 +1,80 @@
+package objects
+
+import (
+	"bufio"
+
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/astral/channel"
+	"github.com/cryptopunkscc/astrald/lib/routing"
+	"github.com/cryptopunkscc/astrald/mod/auth"
+	"github.com/cryptopunkscc/astrald/mod/objects"
+)
+
+type opExportArgs struct {
+	ID        *astral.ObjectID `query:"optional"`
+	Recursive bool             `query:"optional"`
+	In        string           `query:"optional"`
+	Out       string           `query:"optional"`
+}
+
+// OpExport streams a bundle of objects as Blobs followed by EOS. The objects are given by the ID arg,
+// or read from the channel until EOS. With recursive, referenced objects are included as well.
+// The caller needs read access to every exported object.
+func (mod *Module) OpExport(ctx *astral.Context, q *routing.IncomingQuery, args opExportArgs) (err error) {
+	ctx = ctx.WithIdentity(q.Caller())
+
+	ch := channel.New(q.AcceptRaw(), channel.WithFormats(args.In, args.Out))
+	defer ch.Close()
+
+	var ids []*astral.ObjectID
+	if args.ID != nil {
+		ids = append(ids, args.ID)
+	} else {
+		err = ch.Switch(channel.Collect(&ids), channel.BreakOnEOS, channel.WithContext(ctx))
+		if err != nil {
+			return ch.Send(astral.Err(err))
+		}
+	}
+
+	if args.Recursive {
+		ids, err = mod.Closure(ctx, ids)
+		if err != nil {
+			return ch.Send(astral.Err(err))
+		}
+	}
+
+	for _, id := range ids {
+		allowed := mod.Auth.Authorize(ctx, &objects.ReadObjectAction{
+			Action:   auth.NewAction(q.Caller()),
+			ObjectID: id,
+		})
+		if !allowed {
+			return ch.Send(astral.NewError("access to " + id.String() + " denied"))
+		}
+	}
+
+	w := bufio.NewWriterSize(&blobWriter{ch: ch}, 32<<10)
+
+	err = mod.ExportBundle(ctx, w, ids, false)
+	if err == nil {
+		err = w.Flush()
+	}
+	if err != nil {
+		return ch.Send(astral.Err(err))
+	}
+
+	return ch.Send(&astral.EOS{})
+}
+
+// blobWriter sends written data over a channel as Blobs.
+type blobWriter struct {
+	ch *channel.Channel
+}
+
+func (w *blobWriter) Write(p []byte) (n int, err error) {
+	err = w.ch.Send((*astral.Blob)(&p))
+	if err == nil {
+		n = len(p)
+	}
+	return
+}
@@ -0,0 +1,95 @@
+package objects
+
+import (
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/astral/channel"
+	"github.com/cryptopunkscc/astrald/lib/routing"
+	"github.com/cryptopunkscc/astrald/mod/objects"
+)
+
+type opFetchArgs struct {
+	Source    string
+	Recursive bool   `query:"optional"`
+	Out       string `query:"optional"`
+}
+
+// OpFetch fetches a URL or an ARL into the default write repository, resuming an earlier
+// attempt if there was one. Streams FetchProgress while the fetch runs, then the ObjectID.
+// The fetch keeps running in the background if the caller goes away.
+//
+// The source can also be an ObjectID, which is downloaded from its providers. With recursive,
+// every object referenced by the fetched object, directly or indirectly, is downloaded too;
+// a FetchProgress with the ObjectID as the source is sent after each of them.
+func (mod *Module) OpFetch(ctx *astral.Context, q *routing.IncomingQuery, args opFetchArgs) error {
+	if !q.Caller().IsEqual(mod.node.Identity()) {
+		return q.Reject()
+	}
+
+	ch := channel.New(q.AcceptRaw(), channel.WithOutputFormat(args.Out))
+	defer ch.Close()
+
+	ctx = ctx.IncludeZone(astral.ZoneNetwork)
+
+	objectID, err := astral.ParseID(args.Source)
+	switch {
+	case err == nil:
+		if !args.Recursive {
+			err = mod.Download(ctx, objectID, mod.WriteDefault())
+			if err != nil {
+				return ch.Send(astral.Err(err))
+			}
+		}
+
+	case isURL(args.Source) || isARL(args.Source):
+		objectID, err = mod.sendFetch(ctx, ch, mod.Fetch(args.Source))
+		if err != nil {
+			return err
+		}
+		if objectID == nil {
+			return nil
+		}
+
+	default:
+		return ch.Send(astral.NewError("scheme not supported"))
+	}
+
+	if args.Recursive {
+		err = mod.FetchReferences(ctx, objectID, func(id *astral.ObjectID) {
+			ch.Send(&objects.FetchProgress{
+				Source: astral.String16(id.String()),
+				Offset: astral.Uint64(id.Size),
+				Size:   astral.Uint64(id.Size),
+			})
+		})
+		if err != nil {
+			return ch.Send(astral.Err(err))
+		}
+	}
+
+	return ch.Send(objectID)
+}
+
+// sendFetch streams the progress of a fetch task and returns the fetched ObjectID. If the fetch
+// failed, the error is sent and a nil ObjectID is returned.
+func (mod *Module) sendFetch(ctx *astral.Context, ch *channel.Channel, task *fetchTask) (*astral.ObjectID, error) {
+	last, updates := task.Subscribe(ctx)
+
+	err := ch.Send(last)
+	if err != nil {
+		return nil, err
+	}
+
+	for progress := range updates {
+		err = ch.Send(progress)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	objectID, err := task.Wait(ctx)
+	if err != nil {
+		return nil, ch.Send(astral.Err(err))
+	}
+
+	return objectID, nil
+}
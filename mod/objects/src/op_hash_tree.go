@@ -0,0 +1,36 @@
+package objects
+
+import (
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/astral/channel"
+	"github.com/cryptopunkscc/astrald/lib/routing"
+	"github.com/cryptopunkscc/astrald/mod/auth"
+	"github.com/cryptopunkscc/astrald/mod/objects"
+)
+
+type opHashTreeArgs struct {
+	ID  *astral.ObjectID
+	Out string `query:"optional"`
+}
+
+// OpHashTree returns the hash tree of an object the node holds, so that ranges read with
+// objects.read can be verified block by block. Requires read access to the object.
+func (mod *Module) OpHashTree(ctx *astral.Context, q *routing.IncomingQuery, args opHashTreeArgs) error {
+	allowed := mod.Auth.Authorize(ctx, &objects.ReadObjectAction{
+		Action:   auth.NewAction(q.Caller()),
+		ObjectID: args.ID,
+	})
+	if !allowed {
+		return q.Reject()
+	}
+
+	ch := channel.New(q.AcceptRaw(), channel.WithOutputFormat(args.Out))
+	defer ch.Close()
+
+	tree, err := mod.HashTree(ctx.WithIdentity(q.Caller()), args.ID)
+	if err != nil {
+		return ch.Send(astral.Err(err))
+	}
+
+	return ch.Send(tree)
+}
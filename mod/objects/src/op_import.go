@@ -0,0 +1,74 @@
+package objects
+
+import (
+	"io"
+
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/astral/channel"
+	"github.com/cryptopunkscc/astrald/lib/routing"
+)
+
+type opImportArgs struct {
+	Repo string `query:"optional"`
+	In   string `query:"optional"`
+	Out  string `query:"optional"`
+}
+
+// OpImport reads a bundle sent as Blobs followed by EOS and stores its objects in a repository
+// (the default write repository if none is given). Replies with the IDs of the imported objects
+// followed by EOS, or an error. Objects are verified against their IDs.
+func (mod *Module) OpImport(ctx *astral.Context, q *routing.IncomingQuery, args opImportArgs) error {
+	ctx = ctx.WithIdentity(q.Caller())
+
+	ch := channel.New(q.AcceptRaw(), channel.WithFormats(args.In, args.Out))
+	defer ch.Close()
+
+	repo := mod.WriteDefault()
+	if len(args.Repo) > 0 {
+		repo = mod.GetRepository(args.Repo)
+		if repo == nil {
+			return ch.Send(astral.NewError("repository not found"))
+		}
+	}
+
+	pr, pw := io.Pipe()
+
+	type result struct {
+		ids []*astral.ObjectID
+		err error
+	}
+	done := make(chan result, 1)
+
+	go func() {
+		ids, err := mod.ImportBundle(ctx, pr, repo)
+		pr.CloseWithError(io.ErrClosedPipe) // unblock the sender if the import stopped early
+		done <- result{ids, err}
+	}()
+
+	err := ch.Switch(
+		func(blob *astral.Blob) error {
+			_, err := pw.Write(*blob)
+			if err == io.ErrClosedPipe {
+				return nil // the import stopped, drain the rest
+			}
+			return err
+		},
+		channel.BreakOnEOS,
+		channel.WithContext(ctx),
+	)
+	pw.CloseWithError(err)
+
+	res := <-done
+
+	for _, id := range res.ids {
+		if err := ch.Send(id); err != nil {
+			return err
+		}
+	}
+
+	if res.err != nil {
+		return ch.Send(astral.Err(res.err))
+	}
+
+	return ch.Send(&astral.EOS{})
+}
@@ -0,0 +1,37 @@
+package objects
+
+import (
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/astral/channel"
+	"github.com/cryptopunkscc/astrald/lib/routing"
+)
+
+type opPinArgs struct {
+	ID  *astral.ObjectID
+	Out string `query:"optional"`
+}
+
+// OpPin pins an object, protecting it and every object it references from purge. Streams the
+// ObjectIDs of the pinned closure, then EOS.
+func (mod *Module) OpPin(ctx *astral.Context, q *routing.IncomingQuery, args opPinArgs) error {
+	if !q.Caller().IsEqual(mod.node.Identity()) {
+		return q.Reject()
+	}
+
+	ch := channel.New(q.AcceptRaw(), channel.WithOutputFormat(args.Out))
+	defer ch.Close()
+
+	closure, err := mod.Pin(ctx.WithIdentity(q.Caller()), args.ID)
+	if err != nil {
+		return ch.Send(astral.Err(err))
+	}
+
+	for _, id := range closure {
+		err = ch.Send(id)
+		if err != nil {
+			return err
+		}
+	}
+
+	return ch.Send(&astral.EOS{})
+}
@@ -0,0 +1,30 @@
+package objects
+
+import (
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/astral/channel"
+	"github.com/cryptopunkscc/astrald/lib/routing"
+)
+
+type opPublishNameArgs struct {
+	Name string
+	ID   *astral.ObjectID
+	Out  string `query:"optional"`
+}
+
+// OpPublishName points a name of the node at an object. Returns the signed name record.
+func (mod *Module) OpPublishName(ctx *astral.Context, q *routing.IncomingQuery, args opPublishNameArgs) error {
+	if !q.Caller().IsEqual(mod.node.Identity()) {
+		return q.Reject()
+	}
+
+	ch := channel.New(q.AcceptRaw(), channel.WithOutputFormat(args.Out))
+	defer ch.Close()
+
+	signed, err := mod.PublishName(ctx.WithIdentity(q.Caller()), args.Name, args.ID)
+	if err != nil {
+		return ch.Send(astral.Err(err))
+	}
+
+	return ch.Send(signed)
+}
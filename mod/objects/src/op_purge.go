@@ -33,7 +33,7 @@ func (mod *Module) OpPurge(ctx *astral.Context, q *routing.IncomingQuery, args o
 	ctx, cancel := ctx.WithCancel()
 	defer cancel()
 
-	purged, errPtr := mod.purgeRepository(ctx, repo)
+	purged, errPtr := mod.purgeRepository(ctx, repo, 0)
 	for id := range purged {
 		err := ch.Send(id)
 		if err != nil {
@@ -0,0 +1,38 @@
+package objects
+
+import (
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/astral/channel"
+	"github.com/cryptopunkscc/astrald/lib/routing"
+)
+
+type opReconcileArgs struct {
+	Repo string
+}
+
+// OpReconcile answers the set reconciliation of objects.sync run by another node against a
+// snapshot of a repository. Network repositories are not included in the snapshot.
+func (mod *Module) OpReconcile(ctx *astral.Context, q *routing.IncomingQuery, args opReconcileArgs) (err error) {
+	ctx = ctx.WithIdentity(q.Caller()).WithZone(astral.ZoneDevice | astral.ZoneVirtual)
+
+	repo := mod.GetRepository(args.Repo)
+	if repo == nil {
+		return q.Reject()
+	}
+
+	set, err := scanSyncSet(ctx, repo)
+	if err != nil {
+		mod.log.Errorv(1, "reconcile %v: %v", args.Repo, err)
+		return q.Reject()
+	}
+
+	ch := channel.New(q.AcceptRaw())
+	defer ch.Close()
+
+	err = respondReconcile(ch, set)
+	if err != nil {
+		mod.log.Errorv(1, "reconcile %v with %v: %v", args.Repo, q.Caller(), err)
+	}
+
+	return err
+}
@@ -0,0 +1,52 @@
+package objects
+
+import (
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/astral/channel"
+	"github.com/cryptopunkscc/astrald/lib/routing"
+	"github.com/cryptopunkscc/astrald/mod/auth"
+	"github.com/cryptopunkscc/astrald/mod/objects"
+)
+
+type opReferencesArgs struct {
+	ID        *astral.ObjectID
+	Recursive bool   `query:"optional"`
+	Out       string `query:"optional"`
+}
+
+// OpReferences streams the ObjectIDs of objects referenced by an object, then EOS. With recursive,
+// it streams the full closure of the object instead (the object first). Requires read access to the object.
+func (mod *Module) OpReferences(ctx *astral.Context, q *routing.IncomingQuery, args opReferencesArgs) error {
+	allowed := mod.Auth.Authorize(ctx, &objects.ReadObjectAction{
+		Action:   auth.NewAction(q.Caller()),
+		ObjectID: args.ID,
+	})
+	if !allowed {
+		return q.Reject()
+	}
+
+	ctx = ctx.WithIdentity(q.Caller())
+
+	ch := channel.New(q.AcceptRaw(), channel.WithOutputFormat(args.Out))
+	defer ch.Close()
+
+	var refs []*astral.ObjectID
+	var err error
+	if args.Recursive {
+		refs, err = mod.Closure(ctx, []*astral.ObjectID{args.ID})
+	} else {
+		refs, err = mod.References(ctx, args.ID)
+	}
+	if err != nil {
+		return ch.Send(astral.Err(err))
+	}
+
+	for _, id := range refs {
+		err = ch.Send(id)
+		if err != nil {
+			return err
+		}
+	}
+
+	return ch.Send(&astral.EOS{})
+}
@@ -0,0 +1,56 @@
+package objects
+
+import (
+	"strings"
+
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/astral/channel"
+	"github.com/cryptopunkscc/astrald/lib/routing"
+)
+
+type opResolveNameArgs struct {
+	Name      string
+	Publisher *astral.Identity `query:"optional"`
+	Via       string           `query:"optional"` // comma-separated identities of other holders of the name
+	Zone      astral.Zone      `query:"optional"`
+	Out       string           `query:"optional"`
+}
+
+// OpResolveName returns the latest verified record of a name. The publisher defaults to the
+// node itself. Only local callers can resolve names over the network.
+func (mod *Module) OpResolveName(ctx *astral.Context, q *routing.IncomingQuery, args opResolveNameArgs) error {
+	ctx = ctx.WithIdentity(q.Caller()).IncludeZone(args.Zone)
+	if !q.Caller().IsEqual(mod.node.Identity()) {
+		// why: a remote caller must not make us fan out queries on its behalf
+		ctx = ctx.ExcludeZone(astral.ZoneNetwork)
+	}
+
+	ch := channel.New(q.AcceptRaw(), channel.WithOutputFormat(args.Out))
+	defer ch.Close()
+
+	publisher := args.Publisher
+	if publisher.IsZero() {
+		publisher = mod.node.Identity()
+	}
+
+	var via []*astral.Identity
+	for _, s := range strings.Split(args.Via, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+
+		id, err := mod.Dir.ResolveIdentity(s)
+		if err != nil {
+			return ch.Send(astral.Err(err))
+		}
+		via = append(via, id)
+	}
+
+	signed, err := mod.ResolveName(ctx, publisher, args.Name, via...)
+	if err != nil {
+		return ch.Send(astral.Err(err))
+	}
+
+	return ch.Send(signed)
+}
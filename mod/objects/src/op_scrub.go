@@ -0,0 +1,38 @@
+package objects
+
+import (
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/astral/channel"
+	"github.com/cryptopunkscc/astrald/lib/routing"
+)
+
+type opScrubArgs struct {
+	Repo       string
+	Quarantine bool         `query:"optional"`
+	Repair     bool         `query:"optional"`
+	Rate       astral.Int64 `query:"optional"`
+	Out        string       `query:"optional"`
+}
+
+// OpScrub verifies every object in a repository against its ID and replies with a ScrubReport.
+// Corrupt objects are only reported unless quarantine or repair is requested. An interrupted
+// scrub continues where it stopped. Rate limits the read speed in bytes per second.
+func (mod *Module) OpScrub(ctx *astral.Context, q *routing.IncomingQuery, args opScrubArgs) error {
+	if !q.Caller().IsEqual(mod.node.Identity()) {
+		return q.Reject()
+	}
+
+	ch := channel.New(q.AcceptRaw(), channel.WithOutputFormat(args.Out))
+	defer ch.Close()
+
+	report, err := mod.Scrub(ctx.WithIdentity(q.Caller()).IncludeZone(astral.ZoneNetwork), args.Repo, scrubOpts{
+		Quarantine: args.Quarantine,
+		Repair:     args.Repair,
+		Rate:       int64(args.Rate),
+	})
+	if err != nil {
+		return ch.Send(astral.Err(err))
+	}
+
+	return ch.Send(report)
+}
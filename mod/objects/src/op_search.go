@@ -12,14 +12,20 @@ import (
 )
 
 type SearchArgs struct {
-	Query string      `query:"key:q"`
-	Repo  string      `query:"optional"` // return only objects that this repo contains
-	Zone  astral.Zone `query:"optional"`
-	Out   string      `query:"optional"`
+	Query  string      `query:"key:q"`
+	Repo   string      `query:"optional"` // return only objects that this repo contains
+	Zone   astral.Zone `query:"optional"`
+	Sort   string      `query:"optional"` // sort order of a paged search
+	Limit  int         `query:"optional"` // page size of a paged search
+	Cursor string      `query:"optional"` // cursor of the next page of a paged search
+	Out    string      `query:"optional"`
 }
 
 // OpSearch streams matches for the query, deduplicated by ObjectID and
 // optionally filtered to objects the named repo contains. Bounded to one minute.
+//
+// If any of sort, limit or cursor is set, the search is paged: ScoredResults are sent sorted, at
+// most limit of them, followed by a SearchCursor if there are more.
 func (mod *Module) OpSearch(ctx *astral.Context, q *routing.IncomingQuery, args SearchArgs) (err error) {
 	ctx, cancel := ctx.WithIdentity(q.Caller()).IncludeZone(args.Zone).WithTimeout(time.Minute)
 	defer cancel()
@@ -39,6 +45,11 @@ func (mod *Module) OpSearch(ctx *astral.Context, q *routing.IncomingQuery, args
 	var searchQuery objects.SearchQuery
 	_ = searchQuery.UnmarshalText([]byte(args.Query))
 
+	if len(args.Sort) > 0 || args.Limit > 0 || len(args.Cursor) > 0 {
+		opts := objects.SearchPageOpts{Sort: args.Sort, Limit: max(args.Limit, 0), Cursor: args.Cursor}
+		return mod.sendSearchPage(ctx, ch, searchQuery, opts, repo)
+	}
+
 	// run the search
 	matches, err := mod.Search(ctx, searchQuery)
 	if err != nil {
@@ -79,3 +90,26 @@ func (mod *Module) OpSearch(ctx *astral.Context, q *routing.IncomingQuery, args
 
 	return ch.Send(&astral.EOS{})
 }
+
+func (mod *Module) sendSearchPage(ctx *astral.Context, ch *channel.Channel, query objects.SearchQuery, opts objects.SearchPageOpts, repo objects.Repository) error {
+	page, next, err := mod.SearchPage(ctx, query, opts, repo)
+	if err != nil {
+		return ch.Send(astral.Err(err))
+	}
+
+	for _, result := range page {
+		err = ch.Send(result)
+		if err != nil {
+			return fmt.Errorf("error writing match: %w", err)
+		}
+	}
+
+	if next != nil {
+		err = ch.Send(next)
+		if err != nil {
+			return err
+		}
+	}
+
+	return ch.Send(&astral.EOS{})
+}
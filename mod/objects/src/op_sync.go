@@ -0,0 +1,110 @@
+package objects
+
+import (
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/astral/channel"
+	"github.com/cryptopunkscc/astrald/lib/routing"
+	"github.com/cryptopunkscc/astrald/mod/objects"
+)
+
+type opSyncArgs struct {
+	Repo      string
+	Node      string
+	Remote    string `query:"optional"` // repository on the node, defaults to Repo
+	Direction string `query:"optional"` // pull (default), push or both
+	Follow    bool   `query:"optional"`
+	Out       string `query:"optional"`
+}
+
+// OpSync mirrors a local repository and a repository of another node. Only the objects that
+// differ are transferred. Sends a SyncReport once the repositories are reconciled, then EOS.
+// With Follow, objects added to either repository afterwards are transferred as well, with a
+// new report after each transfer, until the query is closed.
+func (mod *Module) OpSync(ctx *astral.Context, q *routing.IncomingQuery, args opSyncArgs) (err error) {
+	if !q.Caller().IsEqual(mod.node.Identity()) {
+		return q.Reject()
+	}
+
+	ctx = ctx.WithIdentity(q.Caller()).WithZone(astral.ZoneDevice | astral.ZoneVirtual)
+	ctx, cancel := ctx.WithCancel()
+	defer cancel()
+
+	ch := channel.New(q.AcceptRaw(), channel.WithOutputFormat(args.Out))
+	defer ch.Close()
+
+	repo := mod.GetRepository(args.Repo)
+	if repo == nil {
+		return ch.Send(astral.NewError("repository not found"))
+	}
+
+	node, err := mod.Dir.ResolveIdentity(args.Node)
+	if err != nil {
+		return ch.Send(astral.Err(err))
+	}
+
+	remote := args.Remote
+	if len(remote) == 0 {
+		remote = args.Repo
+	}
+
+	task, err := mod.newSyncTask(repo, node, remote, args.Direction)
+	if err != nil {
+		return ch.Send(astral.Err(err))
+	}
+
+	if !args.Follow {
+		err = task.reconcile(ctx)
+		if err != nil {
+			return ch.Send(astral.Err(err))
+		}
+
+		err = ch.Send(task.Report())
+		if err != nil {
+			return
+		}
+
+		return ch.Send(&astral.EOS{})
+	}
+
+	// if the channel closes, stop following
+	go func() {
+		for {
+			_, err := ch.Receive()
+			if err != nil {
+				cancel()
+				return
+			}
+		}
+	}()
+
+	// follow before reconciling so that no object added in between is missed
+	done := make(chan error, 1)
+	go func() {
+		done <- task.follow(ctx)
+	}()
+
+	err = task.reconcile(ctx)
+	if err != nil {
+		return ch.Send(astral.Err(err))
+	}
+
+	task.mu.Lock()
+	task.onReport = func(report *objects.SyncReport) { ch.Send(report) }
+	report := task.report
+	task.mu.Unlock()
+
+	err = ch.Send(&report)
+	if err != nil {
+		return
+	}
+
+	err = <-done
+	if ctx.Err() != nil {
+		return nil
+	}
+	if err != nil {
+		return ch.Send(astral.Err(err))
+	}
+
+	return ch.Send(&astral.EOS{})
+}
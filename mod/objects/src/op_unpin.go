@@ -0,0 +1,29 @@
+package objects
+
+import (
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/astral/channel"
+	"github.com/cryptopunkscc/astrald/lib/routing"
+)
+
+type opUnpinArgs struct {
+	ID  *astral.ObjectID
+	Out string `query:"optional"`
+}
+
+// OpUnpin removes the pin of an object.
+func (mod *Module) OpUnpin(ctx *astral.Context, q *routing.IncomingQuery, args opUnpinArgs) error {
+	if !q.Caller().IsEqual(mod.node.Identity()) {
+		return q.Reject()
+	}
+
+	ch := channel.New(q.AcceptRaw(), channel.WithOutputFormat(args.Out))
+	defer ch.Close()
+
+	err := mod.Unpin(args.ID)
+	if err != nil {
+		return ch.Send(astral.Err(err))
+	}
+
+	return ch.Send(&astral.Ack{})
+}
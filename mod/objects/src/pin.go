@@ -0,0 +1,102 @@
+package objects
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/mod/objects"
+)
+
+var ErrNotPinned = errors.New("object is not pinned")
+
+var _ objects.Holder = &pinHolder{}
+
+// Pin protects an object and its full closure from purge. References of the closure are extracted
+// again, so objects that arrived since the last extraction are covered. Returns the closure.
+func (mod *Module) Pin(ctx *astral.Context, objectID *astral.ObjectID) ([]*astral.ObjectID, error) {
+	closure, err := mod.closure(ctx, []*astral.ObjectID{objectID}, mod.refreshReferences)
+	if err != nil {
+		return nil, err
+	}
+
+	err = mod.db.CreatePin(objectID)
+	if err != nil {
+		return nil, err
+	}
+
+	return closure, nil
+}
+
+// Unpin removes the pin of an object. Objects in its closure stay protected if another pin covers them.
+func (mod *Module) Unpin(objectID *astral.ObjectID) error {
+	found, err := mod.db.DeletePin(objectID)
+	switch {
+	case err != nil:
+		return err
+	case !found:
+		return ErrNotPinned
+	}
+	return nil
+}
+
+// refreshReferences extracts the references of an object anew, falling back to the stored references
+// if the object can't be read.
+func (mod *Module) refreshReferences(ctx *astral.Context, objectID *astral.ObjectID) ([]*astral.ObjectID, error) {
+	refs, err := mod.extractReferences(ctx, objectID)
+	if err == nil {
+		return refs, nil
+	}
+
+	stored, extracted, dbErr := mod.db.FindReferences(objectID)
+	if dbErr == nil && extracted {
+		return stored, nil
+	}
+
+	return nil, err
+}
+
+// FetchReferences downloads every object in the closure of an object that the device doesn't hold,
+// calling fetched after each download. Objects that can't be downloaded are skipped and reported
+// in the returned error; their references are fetched when they are. The context must include
+// the network zone.
+func (mod *Module) FetchReferences(ctx *astral.Context, objectID *astral.ObjectID, fetched func(*astral.ObjectID)) error {
+	local := ctx.WithZone(astral.ZoneDevice | astral.ZoneVirtual)
+	repo := mod.WriteDefault()
+
+	var failed int
+
+	_, err := mod.closure(ctx, []*astral.ObjectID{objectID}, func(_ *astral.Context, id *astral.ObjectID) ([]*astral.ObjectID, error) {
+		if has, _ := mod.ReadDefault().Contains(local, id); !has {
+			err := mod.Download(ctx, id, repo)
+			if err != nil {
+				mod.log.Errorv(1, "fetch references of %v: %v: %v", objectID, id, err)
+				failed++
+				return nil, nil
+			}
+			if fetched != nil {
+				fetched(id)
+			}
+		}
+
+		return mod.References(local, id)
+	})
+	if err != nil {
+		return err
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d referenced objects could not be fetched", failed)
+	}
+	return nil
+}
+
+// pinHolder protects pinned objects and their closures from being purged.
+type pinHolder struct {
+	mod *Module
+}
+
+func (h *pinHolder) HoldObject(objectID *astral.ObjectID) bool {
+	pinned, err := h.mod.db.IsPinned(objectID)
+	return err == nil && pinned
+}
@@ -8,7 +8,9 @@ import (
 	"github.com/cryptopunkscc/astrald/sig"
 )
 
-func (mod *Module) purgeRepository(ctx *astral.Context, repo objects.Repository) (<-chan *astral.ObjectID, *error) {
+// purgeRepository deletes unheld objects from repo, least recently read first, and streams their IDs.
+// If free is positive, it stops once objects totalling at least free bytes have been deleted.
+func (mod *Module) purgeRepository(ctx *astral.Context, repo objects.Repository, free int64) (<-chan *astral.ObjectID, *error) {
 	out := make(chan *astral.ObjectID)
 	errPtr := new(error)
 
@@ -23,6 +25,7 @@ func (mod *Module) purgeRepository(ctx *astral.Context, repo objects.Repository)
 
 		// purge in read order: oldest-read objects first, paged via keyset cursor
 		var after *readCursor
+		var freed int64
 		for {
 			select {
 			case <-ctx.Done():
@@ -42,27 +45,32 @@ func (mod *Module) purgeRepository(ctx *astral.Context, repo objects.Repository)
 					continue
 				}
 
+				// note: the read journal tracks objects of every repository
+				if has, _ := repo.Contains(ctx, id); !has {
+					mod.untrack(ctx, repo, id)
+					continue
+				}
+
 				err := repo.Delete(ctx, id)
 				switch {
 				case err == nil:
 					// why: the object is gone, so its tracking row must go too —
 					// otherwise it lingers as the oldest entry and every later
 					// purge re-deletes it before reaching real candidates.
-					derr := mod.db.DeleteObjectCacheByID(id)
-					if derr != nil {
-						mod.log.Error("purge: drop cache row %v: %v", id, derr)
-					}
+					mod.untrack(ctx, repo, id)
 					err = sig.Send(ctx, out, id)
 					if err != nil {
 						*errPtr = err
 						return
 					}
 
-				case errors.Is(err, objects.ErrNotFound):
-					derr := mod.db.DeleteObjectCacheByID(id)
-					if derr != nil {
-						mod.log.Error("purge: drop stale cache row %v: %v", id, derr)
+					freed += int64(id.Size)
+					if free > 0 && freed >= free {
+						return
 					}
+
+				case errors.Is(err, objects.ErrNotFound):
+					mod.untrack(ctx, repo, id)
 					continue
 				case errors.Is(err, errors.ErrUnsupported):
 					continue
@@ -81,3 +89,21 @@ func (mod *Module) purgeRepository(ctx *astral.Context, repo objects.Repository)
 
 	return out, errPtr
 }
+
+// untrack drops the tracking row of an object purged from repo, unless another repository
+// still holds it.
+func (mod *Module) untrack(ctx *astral.Context, repo objects.Repository, id *astral.ObjectID) {
+	for _, other := range mod.repos.Clone() {
+		if _, ok := other.(*RepoGroup); ok || other == repo {
+			continue
+		}
+		if has, _ := other.Contains(ctx, id); has {
+			return
+		}
+	}
+
+	err := mod.db.DeleteObjectCacheByID(id)
+	if err != nil {
+		mod.log.Error("purge: drop tracking row %v: %v", id, err)
+	}
+}
@@ -0,0 +1,145 @@
+package objects
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/mod/scheduler"
+)
+
+const usageRecountInterval = time.Hour // how often the usage of a repository is counted from scratch
+
+// quotaTask purges the repositories that exceed their high-water mark and schedules its next run.
+type quotaTask struct {
+	mod *Module
+}
+
+var _ scheduler.Task = &quotaTask{}
+
+func (mod *Module) newQuotaTask() *quotaTask {
+	return &quotaTask{mod: mod}
+}
+
+func (task *quotaTask) String() string {
+	return "objects.quota"
+}
+
+func (task *quotaTask) Run(ctx *astral.Context) error {
+	mod := task.mod
+
+	for name, cfg := range mod.config.Quotas {
+		err := mod.enforceQuota(ctx, name, cfg)
+		if err != nil {
+			mod.log.Errorv(1, "quota %v: %v", name, err)
+		}
+	}
+
+	interval := mod.config.QuotaInterval
+	if interval <= 0 {
+		interval = defaultConfig.QuotaInterval
+	}
+
+	delay, _ := mod.ctx.WithTimeout(interval)
+	_, err := mod.Scheduler.Schedule(mod.newQuotaTask(), delay)
+
+	return err
+}
+
+// enforceQuota purges the named repository in read order until its usage drops to the
+// low-water mark, if it's above the high-water mark. Held objects are never purged.
+func (mod *Module) enforceQuota(ctx *astral.Context, name string, cfg QuotaConfig) error {
+	repo := mod.GetRepository(name)
+	if repo == nil {
+		return fmt.Errorf("repository %s not found", name)
+	}
+
+	tracked, _ := mod.usage.Get(name)
+	if tracked == nil || !tracked.counted.Load() {
+		return nil
+	}
+
+	usage := tracked.bytes.Load()
+	if usage <= cfg.high() {
+		return nil
+	}
+
+	var count int
+	var start = usage
+
+	purged, errPtr := mod.purgeRepository(ctx, repo, usage-cfg.low())
+	for id := range purged {
+		count++
+		usage -= int64(id.Size)
+		tracked.bytes.Add(-int64(id.Size))
+	}
+
+	mod.log.Logv(1, "quota %v: purged %v objects (%v bytes)", name, count, start-usage)
+
+	if usage > cfg.low() {
+		if *errPtr != nil {
+			return *errPtr
+		}
+		return fmt.Errorf("usage %v bytes still above low-water mark, remaining objects are held or untracked", usage)
+	}
+
+	return nil
+}
+
+// repoUsage is the total size of the objects in a repository.
+type repoUsage struct {
+	bytes   atomic.Int64
+	counted atomic.Bool // set once the repository was counted
+}
+
+// trackUsage keeps the usage of the named repository up to date until ctx is done. Objects
+// deleted by others than the quota are not reported, so the repository is recounted every
+// usageRecountInterval.
+func (mod *Module) trackUsage(ctx *astral.Context, name string) {
+	usage, _ := mod.usage.Set(name, &repoUsage{})
+
+	for ctx.Err() == nil {
+		recount, cancel := ctx.WithTimeout(usageRecountInterval)
+		mod.countUsage(recount, name, usage)
+		<-recount.Done()
+		cancel()
+	}
+}
+
+// countUsage counts the objects in a repository, then adds the objects it reports as added
+// until ctx is done.
+func (mod *Module) countUsage(ctx *astral.Context, name string, usage *repoUsage) {
+	repo := mod.GetRepository(name)
+	if repo == nil {
+		mod.log.Errorv(1, "quota %v: repository not found", name)
+		return
+	}
+
+	scan, err := repo.Scan(ctx, true)
+	if err != nil {
+		mod.log.Errorv(1, "quota %v: scan: %v", name, err)
+		return
+	}
+
+	var total int64
+	var following bool
+	for id := range scan {
+		switch {
+		case id == nil: // the backlog is drained, live updates follow
+			usage.bytes.Store(total)
+			usage.counted.Store(true)
+			following = true
+		case following:
+			usage.bytes.Add(int64(id.Size))
+		default:
+			total += int64(id.Size)
+		}
+	}
+
+	// note: repositories that can't follow close the scan after the backlog
+	if !following && ctx.Err() == nil {
+		usage.bytes.Store(total)
+		usage.counted.Store(true)
+	}
+}
@@ -0,0 +1,102 @@
+package objects
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/mod/objects/mem"
+)
+
+type testHolder struct{ id *astral.ObjectID }
+
+func (h testHolder) HoldObject(objectID *astral.ObjectID) bool { return objectID.IsEqual(h.id) }
+
+// A repository over its high-water mark loses its least recently read unheld objects,
+// and only as many as needed to get down to the low-water mark.
+func TestEnforceQuota(t *testing.T) {
+	mod := newTestModule(t)
+
+	cache := mem.New("cache", 1<<20)
+	mod.repos.Set("cache", cache)
+
+	// ten 100-byte objects, read in order of creation
+	var ids []*astral.ObjectID
+	reads := map[astral.ObjectID]astral.Time{}
+	for i := 0; i < 10; i++ {
+		id := storeBytes(t, cache, bytes.Repeat([]byte{byte(i)}, 100))
+		if err := mod.db.Create(id, ""); err != nil {
+			t.Fatal(err)
+		}
+		reads[*id] = astral.Time(time.Unix(int64(1000+i), 0).UTC())
+		ids = append(ids, id)
+	}
+	if err := mod.db.UpdateReadAt(reads); err != nil {
+		t.Fatal(err)
+	}
+
+	// an object of another repository read before all of them
+	other := mem.New("other", 1<<20)
+	mod.repos.Set("other", other)
+	otherID := storeBytes(t, other, []byte("other"))
+	if err := mod.db.Create(otherID, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := mod.db.UpdateReadAt(map[astral.ObjectID]astral.Time{*otherID: astral.Time(time.Unix(999, 0).UTC())}); err != nil {
+		t.Fatal(err)
+	}
+
+	// the oldest object is held
+	mod.holders.Add(testHolder{id: ids[0]})
+
+	cfg := QuotaConfig{Size: 1000, HighWater: 80, LowWater: 50}
+
+	ctx, cancel := astral.NewContext(nil).WithCancel()
+	defer cancel()
+
+	usage, _ := mod.usage.Set("cache", &repoUsage{})
+	go mod.countUsage(ctx, "cache", usage)
+	for !usage.counted.Load() {
+		time.Sleep(time.Millisecond)
+	}
+
+	if err := mod.enforceQuota(ctx, "cache", cfg); err != nil {
+		t.Fatalf("enforce: %v", err)
+	}
+
+	left := map[string]bool{}
+	for _, id := range scanIDs(t, cache) {
+		left[id.String()] = true
+	}
+
+	if len(left) != 5 {
+		t.Fatalf("%d objects left, want 5", len(left))
+	}
+	if !left[ids[0].String()] {
+		t.Fatal("held object was purged")
+	}
+	for i := 1; i <= 5; i++ {
+		if left[ids[i].String()] {
+			t.Fatalf("object %d left while newer ones were purged", i)
+		}
+	}
+
+	if n := usage.bytes.Load(); n != 500 {
+		t.Fatalf("usage is %d bytes after the purge, want 500", n)
+	}
+	if has, _ := other.Contains(ctx, otherID); !has {
+		t.Fatal("object of another repository was purged")
+	}
+	if tracked, err := mod.db.Contains(otherID); err != nil || !tracked {
+		t.Fatalf("tracking row of an object of another repository was dropped: %v", err)
+	}
+
+	// below the high-water mark nothing happens
+	if err := mod.enforceQuota(ctx, "cache", cfg); err != nil {
+		t.Fatalf("enforce: %v", err)
+	}
+	if n := len(scanIDs(t, cache)); n != 5 {
+		t.Fatalf("%d objects left after a second run, want 5", n)
+	}
+}
@@ -0,0 +1,221 @@
+package objects
+
+import (
+	"errors"
+	"reflect"
+
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/mod/objects"
+)
+
+var objectIDType = reflect.TypeOf(astral.ObjectID{})
+
+var _ objects.ReferenceExtractor = &typedExtractor{}
+
+// References returns the IDs of objects referenced by an object. References are extracted once
+// and then served from the reference graph.
+func (mod *Module) References(ctx *astral.Context, objectID *astral.ObjectID) ([]*astral.ObjectID, error) {
+	refs, extracted, err := mod.db.FindReferences(objectID)
+	if err == nil && extracted {
+		return refs, nil
+	}
+
+	return mod.extractReferences(ctx, objectID)
+}
+
+// extractReferences runs all reference extractors on an object and stores the result in the
+// reference graph, unless an extractor couldn't tell the references yet.
+func (mod *Module) extractReferences(ctx *astral.Context, objectID *astral.ObjectID) ([]*astral.ObjectID, error) {
+	var refs []*astral.ObjectID
+	var seen = map[astral.ObjectID]bool{*objectID: true}
+	var complete = true
+
+	for _, extractor := range mod.extractors.Clone() {
+		found, err := extractor.ExtractReferences(ctx, objectID)
+		switch {
+		case errors.Is(err, objects.ErrReferencesUnknown):
+			complete = false
+		case err != nil:
+			return nil, err
+		}
+
+		for _, id := range found {
+			if id == nil || id.IsZero() || seen[*id] {
+				continue
+			}
+			seen[*id] = true
+			refs = append(refs, id)
+		}
+	}
+
+	if !complete {
+		return refs, nil
+	}
+
+	err := mod.db.SaveReferences(objectID, refs)
+	if err != nil {
+		mod.log.Errorv(1, "save references of %v: %v", objectID, err)
+	}
+
+	return refs, nil
+}
+
+// AddReferenceExtractor registers an extractor of references.
+func (mod *Module) AddReferenceExtractor(extractor objects.ReferenceExtractor) error {
+	return mod.extractors.Add(extractor)
+}
+
+// Closure returns the given objects followed by every object they reference, directly or
+// indirectly. Referenced objects that can't be read are skipped.
+func (mod *Module) Closure(ctx *astral.Context, ids []*astral.ObjectID) ([]*astral.ObjectID, error) {
+	return mod.closure(ctx, ids, mod.References)
+}
+
+func (mod *Module) closure(ctx *astral.Context, ids []*astral.ObjectID, references func(*astral.Context, *astral.ObjectID) ([]*astral.ObjectID, error)) ([]*astral.ObjectID, error) {
+	var (
+		closure []*astral.ObjectID
+		seen    = map[astral.ObjectID]bool{}
+		queue   = ids
+	)
+
+	for i := 0; i < len(queue); i++ {
+		id := queue[i]
+		if seen[*id] {
+			continue
+		}
+		seen[*id] = true
+		closure = append(closure, id)
+
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		refs, err := references(ctx, id)
+		if err != nil {
+			if i < len(ids) {
+				return nil, err
+			}
+			mod.log.Logv(2, "closure: skipping references of %v: %v", id, err)
+			continue
+		}
+
+		queue = append(queue, refs...)
+	}
+
+	return closure, nil
+}
+
+// typedExtractor finds references in astral objects: every ObjectID in the fields of the decoded
+// object, including objects decoded via runtime blueprints. Blobs and other untyped data
+// reference nothing.
+type typedExtractor struct {
+	mod *Module
+}
+
+func (e *typedExtractor) ExtractReferences(ctx *astral.Context, objectID *astral.ObjectID) ([]*astral.ObjectID, error) {
+	repo := e.mod.ReadDefault()
+
+	// don't load untyped data, it can be large and holds no references
+	probe, err := e.mod.Probe(ctx, repo, objectID)
+	if err != nil {
+		return nil, err
+	}
+	if len(probe.Type) == 0 {
+		return nil, nil
+	}
+
+	obj, err := e.mod.Load(ctx, repo, objectID)
+	if err != nil {
+		return nil, err
+	}
+
+	var refs []*astral.ObjectID
+	collectReferences(reflect.ValueOf(obj), func(id *astral.ObjectID) {
+		refs = append(refs, id)
+	})
+
+	return refs, nil
+}
+
+// collectReferences walks a value and calls fn for every ObjectID in it.
+func collectReferences(v reflect.Value, fn func(*astral.ObjectID)) {
+	if !v.IsValid() {
+		return
+	}
+
+	// runtime objects keep their values unexported
+	if v.CanInterface() {
+		switch o := v.Interface().(type) {
+		case *astral.RuntimeObject:
+			if o == nil {
+				return
+			}
+			if u := o.Underlying(); u != nil {
+				collectReferences(reflect.ValueOf(u), fn)
+			}
+			if bp := o.Blueprint(); bp != nil {
+				for _, f := range bp.Fields {
+					collectReferences(reflect.ValueOf(o.Get(f.Name.String())), fn)
+				}
+			}
+			return
+
+		case interface {
+			Each(func(int, astral.Object) error) error
+		}: // runtime slices and arrays
+			_ = o.Each(func(_ int, e astral.Object) error {
+				collectReferences(reflect.ValueOf(e), fn)
+				return nil
+			})
+			return
+
+		case interface {
+			Each(func(any, astral.Object) error) error
+		}: // runtime maps
+			_ = o.Each(func(_ any, e astral.Object) error {
+				collectReferences(reflect.ValueOf(e), fn)
+				return nil
+			})
+			return
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return
+		}
+		if v.Type() == reflect.PointerTo(objectIDType) {
+			fn(v.Interface().(*astral.ObjectID))
+			return
+		}
+		collectReferences(v.Elem(), fn)
+
+	case reflect.Struct:
+		if v.Type() == objectIDType {
+			id := v.Interface().(astral.ObjectID)
+			fn(&id)
+			return
+		}
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				collectReferences(v.Field(i), fn)
+			}
+		}
+
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return
+		}
+		for i := 0; i < v.Len(); i++ {
+			collectReferences(v.Index(i), fn)
+		}
+
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			collectReferences(iter.Key(), fn)
+			collectReferences(iter.Value(), fn)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package objects
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/mod/objects"
+	"github.com/cryptopunkscc/astrald/mod/objects/mem"
+)
+
+// A pin holds the whole closure of the pinned object until it's unpinned.
+func TestPin_Closure(t *testing.T) {
+	mod := newTestModule(t)
+	mod.extractors.Add(&typedExtractor{mod: mod})
+
+	ctx := astral.NewContext(nil)
+	local := mem.New("local", 1<<20)
+	mod.repos.Set("local", local)
+	mod.repos.Set("main", local)
+
+	a := storeBytes(t, local, bytes.Repeat([]byte{1}, 100))
+	b := storeBytes(t, local, bytes.Repeat([]byte{2}, 100))
+	other := storeBytes(t, local, bytes.Repeat([]byte{3}, 100))
+
+	// root -> inner -> b, root -> a
+	inner, err := mod.Store(ctx, local, &objects.BundleIndex{Objects: []*astral.ObjectID{b}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := mod.Store(ctx, local, &objects.BundleIndex{Objects: []*astral.ObjectID{inner, a}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	closure, err := mod.Pin(ctx, root)
+	if err != nil {
+		t.Fatalf("pin: %v", err)
+	}
+	if len(closure) != 4 {
+		t.Fatalf("closure of %d objects, want 4", len(closure))
+	}
+
+	holder := &pinHolder{mod: mod}
+	for _, id := range []*astral.ObjectID{root, inner, a, b} {
+		if !holder.HoldObject(id) {
+			t.Errorf("%v not held", id)
+		}
+	}
+	if holder.HoldObject(other) {
+		t.Error("unreferenced object held")
+	}
+
+	if err := mod.Unpin(root); err != nil {
+		t.Fatalf("unpin: %v", err)
+	}
+	if holder.HoldObject(b) {
+		t.Error("object held after unpin")
+	}
+	if err := mod.Unpin(root); err != ErrNotPinned {
+		t.Errorf("second unpin: %v, want ErrNotPinned", err)
+	}
+}
+
+// References are found in objects decoded via runtime blueprints.
+func TestCollectReferences_RuntimeObject(t *testing.T) {
+	bp := astral.NewBlueprint("test.objects.manifest",
+		astral.Field{Name: "parts", Spec: &astral.SliceSpec{Type: "object_id.sha256"}},
+	)
+
+	obj, err := astral.NewRuntimeObject(bp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parts, err := astral.NewRuntimeSlice("object_id.sha256")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var want []*astral.ObjectID
+	for i := 0; i < 3; i++ {
+		id, _ := astral.Resolve(bytes.NewReader([]byte{byte(i)}))
+		want = append(want, id)
+		if err := parts.Append(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := obj.Set("parts", parts); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []*astral.ObjectID
+	collectReferences(reflect.ValueOf(obj), func(id *astral.ObjectID) {
+		got = append(got, id)
+	})
+
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range got {
+		if !got[i].IsEqual(want[i]) {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
+
+// pendingExtractor doesn't know the references of an object until it's indexed.
+type pendingExtractor struct {
+	refs    []*astral.ObjectID
+	indexed bool
+}
+
+func (e *pendingExtractor) ExtractReferences(*astral.Context, *astral.ObjectID) ([]*astral.ObjectID, error) {
+	if !e.indexed {
+		return nil, objects.ErrReferencesUnknown
+	}
+	return e.refs, nil
+}
+
+// References that an extractor doesn't know yet are extracted again on the next request.
+func TestReferences_Unknown(t *testing.T) {
+	mod := newTestModule(t)
+
+	ctx := astral.NewContext(nil)
+	id := testObjectID(t, "archive")
+	entry := testObjectID(t, "entry")
+
+	extractor := &pendingExtractor{refs: []*astral.ObjectID{entry}}
+	mod.extractors.Add(extractor)
+
+	refs, err := mod.References(ctx, id)
+	if err != nil || len(refs) != 0 {
+		t.Fatalf("got %v, %v before indexing, want no references", refs, err)
+	}
+
+	extractor.indexed = true
+
+	refs, err = mod.References(ctx, id)
+	if err != nil || len(refs) != 1 || !refs[0].IsEqual(entry) {
+		t.Fatalf("got %v, %v after indexing, want %v", refs, err, entry)
+	}
+}
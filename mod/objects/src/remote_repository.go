@@ -0,0 +1,165 @@
+package objects
+
+import (
+	"errors"
+	"io"
+
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/lib/astrald"
+	"github.com/cryptopunkscc/astrald/mod/objects"
+	objectscli "github.com/cryptopunkscc/astrald/mod/objects/client"
+)
+
+// RemoteRepository proxies a named repository of another node through the objects client.
+// It is part of the network zone: every operation fails with astral.ErrZoneExcluded unless
+// the context includes it. Reads are checked against the object (see checkedRead), and whole
+// objects read from it are kept in the cache repository.
+type RemoteRepository struct {
+	mod    *Module
+	label  string
+	node   *astral.Identity
+	repo   string
+	client *objectscli.Client
+}
+
+var _ objects.Repository = &RemoteRepository{}
+
+func NewRemoteRepository(mod *Module, label string, node *astral.Identity, repo string) *RemoteRepository {
+	return &RemoteRepository{
+		mod:    mod,
+		label:  label,
+		node:   node,
+		repo:   repo,
+		client: objectscli.New(node, astrald.Default()),
+	}
+}
+
+func (repo *RemoteRepository) Label() string {
+	return repo.label
+}
+
+func (repo *RemoteRepository) Create(ctx *astral.Context, opts *objects.CreateOpts) (objects.Writer, error) {
+	if !ctx.Zone().Is(astral.ZoneNetwork) {
+		return nil, astral.ErrZoneExcluded
+	}
+
+	var alloc int
+	if opts != nil {
+		alloc = opts.Alloc
+	}
+
+	return repo.client.Create(ctx, repo.repo, alloc)
+}
+
+func (repo *RemoteRepository) Contains(ctx *astral.Context, objectID *astral.ObjectID) (bool, error) {
+	if !ctx.Zone().Is(astral.ZoneNetwork) {
+		return false, astral.ErrZoneExcluded
+	}
+
+	return repo.client.Contains(ctx, repo.repo, objectID)
+}
+
+func (repo *RemoteRepository) Scan(ctx *astral.Context, follow bool) (<-chan *astral.ObjectID, error) {
+	if !ctx.Zone().Is(astral.ZoneNetwork) {
+		return nil, astral.ErrZoneExcluded
+	}
+
+	ch, errPtr := repo.client.Scan(ctx, repo.repo, follow)
+	if ch == nil {
+		return nil, *errPtr
+	}
+
+	return ch, nil
+}
+
+func (repo *RemoteRepository) Delete(ctx *astral.Context, objectID *astral.ObjectID) error {
+	if !ctx.Zone().Is(astral.ZoneNetwork) {
+		return astral.ErrZoneExcluded
+	}
+
+	return repo.client.Delete(ctx, objectID, repo.repo)
+}
+
+func (repo *RemoteRepository) Read(ctx *astral.Context, objectID *astral.ObjectID, offset int64, limit int64) (objects.Reader, error) {
+	if !ctx.Zone().Is(astral.ZoneNetwork) {
+		return nil, astral.ErrZoneExcluded
+	}
+
+	check := checkedRead{objectID: objectID, offset: offset, limit: limit}
+	if check.whole() {
+		check.tree = repo.mod.cachedHashTree(objectID)
+	} else {
+		check.tree = repo.mod.trustedHashTree(ctx, repo.node, objectID)
+	}
+	start, length := check.window()
+
+	r, err := repo.client.ReadRepo(ctx, repo.repo, objectID, start, length)
+	if err != nil {
+		return nil, err
+	}
+
+	return repo.mod.readThrough(ctx, objectID, offset, limit, &remoteReader{ReadCloser: check.wrap(r), repo: repo}), nil
+}
+
+func (repo *RemoteRepository) Free(ctx *astral.Context) (int64, error) {
+	if !ctx.Zone().Is(astral.ZoneNetwork) {
+		return -1, astral.ErrZoneExcluded
+	}
+
+	return repo.client.Free(ctx, repo.repo)
+}
+
+func (repo *RemoteRepository) String() string {
+	return repo.repo + "@" + repo.node.String()
+}
+
+// AddRemoteRepository mounts a repository of another node in the network group. The label
+// defaults to the repository and node names.
+func (mod *Module) AddRemoteRepository(name string, node *astral.Identity, repo string, label string) error {
+	switch {
+	case len(name) == 0:
+		return errors.New("name is empty")
+	case node.IsZero():
+		return errors.New("node is required")
+	case node.IsEqual(mod.node.Identity()):
+		return errors.New("cannot mount a repository of this node")
+	}
+
+	if len(repo) == 0 {
+		repo = objects.RepoLocal
+	}
+	if len(label) == 0 {
+		label = "Remote (" + repo + "@" + mod.Dir.DisplayName(node) + ")"
+	}
+
+	err := mod.AddRepository(name, NewRemoteRepository(mod, label, node, repo))
+	if err != nil {
+		return err
+	}
+
+	return mod.AddGroup(objects.RepoNetwork, name)
+}
+
+// setupRemoteRepos mounts the configured remote repositories. It needs mod/dir to resolve
+// node aliases.
+func (mod *Module) setupRemoteRepos() {
+	for name, cfg := range mod.config.Remote {
+		node, err := mod.Dir.ResolveIdentity(cfg.Node)
+		if err != nil {
+			mod.log.Error("remote repo %v: invalid node %v: %v", name, cfg.Node, err)
+			continue
+		}
+
+		err = mod.AddRemoteRepository(name, node, cfg.Repo, cfg.Label)
+		if err != nil {
+			mod.log.Error("remote repo %v: %v", name, err)
+		}
+	}
+}
+
+type remoteReader struct {
+	io.ReadCloser
+	repo *RemoteRepository
+}
+
+func (r *remoteReader) Repo() objects.Repository { return r.repo }
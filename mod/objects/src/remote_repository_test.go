@@ -0,0 +1,49 @@
+package objects
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/astral/log"
+	"github.com/cryptopunkscc/astrald/mod/objects"
+)
+
+// A mounted remote repository joins the network group and stays out of reach outside the
+// network zone.
+func TestRemoteRepository_NetworkZone(t *testing.T) {
+	mod := &Module{log: log.New(nil), node: &testNode{identity: astral.GenerateIdentity()}}
+	mod.setupDefaultRepos()
+
+	err := mod.AddRemoteRepository("sibling", mod.node.Identity(), "", "Sibling")
+	if err == nil {
+		t.Fatal("mounted a repository of the node itself")
+	}
+
+	err = mod.AddRemoteRepository("sibling", astral.GenerateIdentity(), "", "Sibling")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	network := mod.GetRepository(objects.RepoNetwork).(*RepoGroup)
+	if !slices.Contains(network.List(), "sibling") {
+		t.Fatalf("network group %v does not list the remote repository", network.List())
+	}
+
+	ctx := astral.NewContext(nil).WithZone(astral.ZoneDevice | astral.ZoneVirtual)
+	repo := mod.GetRepository("sibling")
+
+	if _, err := repo.Read(ctx, testObjectID(t, "data"), 0, 0); !errors.Is(err, astral.ErrZoneExcluded) {
+		t.Fatalf("read got %v, want ErrZoneExcluded", err)
+	}
+	if has, err := repo.Contains(ctx, testObjectID(t, "data")); has || !errors.Is(err, astral.ErrZoneExcluded) {
+		t.Fatalf("contains got %v, %v, want ErrZoneExcluded", has, err)
+	}
+	if _, err := repo.Free(ctx); !errors.Is(err, astral.ErrZoneExcluded) {
+		t.Fatalf("free got %v, want ErrZoneExcluded", err)
+	}
+	if _, err := network.Free(ctx); err != nil {
+		t.Fatalf("network group free got %v outside the network zone", err)
+	}
+}
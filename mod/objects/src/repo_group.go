@@ -304,7 +304,10 @@ func (group *RepoGroup) Free(ctx *astral.Context) (int64, error) {
 			continue
 		}
 		size, err := repo.Free(ctx)
-		if err != nil {
+		switch {
+		case errors.Is(err, astral.ErrZoneExcluded):
+			continue
+		case err != nil:
 			return 0, err
 		}
 		if size > 0 { // size might be -1 for unknown
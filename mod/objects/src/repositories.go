@@ -15,6 +15,11 @@ func (mod *Module) AddRepository(name string, repo objects.Repository) error {
 	return nil
 }
 
+// Repositories returns all registered repositories by name
+func (mod *Module) Repositories() map[string]objects.Repository {
+	return mod.repos.Clone()
+}
+
 func (mod *Module) GetRepository(name string) (repo objects.Repository) {
 	repo, _ = mod.repos.Get(name)
 
@@ -0,0 +1,338 @@
+package objects
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/mod/objects"
+	"github.com/cryptopunkscc/astrald/mod/scheduler"
+)
+
+const (
+	scrubCheckInterval = time.Hour // how often scheduled scrubs are checked for being due
+	scrubSaveEvery     = 64        // objects verified between saves of the scrub state
+)
+
+var errScrubMismatch = errors.New("data does not match object id")
+
+var _ objects.Holder = &quarantineHolder{}
+
+type scrubOpts struct {
+	Quarantine bool  // remove corrupt objects from the repository
+	Repair     bool  // replace removed objects with copies from providers, implies Quarantine
+	Rate       int64 // maximum bytes per second, 0 for no limit
+}
+
+// Scrub re-reads every object in the named repository and checks its data against its ObjectID.
+// The state of the pass is saved as it goes, so an interrupted pass continues where it stopped
+// the next time it's started. The final report is stored in the default write repository.
+func (mod *Module) Scrub(ctx *astral.Context, name string, opts scrubOpts) (*objects.ScrubReport, error) {
+	repo := mod.GetRepository(name)
+	if repo == nil {
+		return nil, fmt.Errorf("repository %s not found", name)
+	}
+
+	if err := mod.scrubbing.Add(name); err != nil {
+		return nil, fmt.Errorf("repository %s is already being scrubbed", name)
+	}
+	defer mod.scrubbing.Remove(name)
+
+	// note: only repairs reach the network, and only if the caller included it
+	remote := ctx
+	ctx = ctx.ExcludeZone(astral.ZoneNetwork)
+
+	row, report := mod.loadScrub(name)
+
+	scan, err := repo.Scan(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+
+	// verify in text order of IDs, so a pass can be resumed after the last verified ID
+	var ids []string
+	for id := range scan {
+		if id != nil && id.String() > row.Cursor {
+			ids = append(ids, id.String())
+		}
+	}
+	slices.Sort(ids)
+
+	throttle := newThrottle(opts.Rate)
+
+	for i, s := range ids {
+		if ctx.Err() != nil {
+			mod.saveScrub(row, report)
+			return nil, ctx.Err()
+		}
+
+		id, _ := astral.ParseID(s)
+
+		n, err := mod.verifyObject(ctx, repo, id, throttle)
+		report.Bytes += astral.Uint64(n)
+
+		switch {
+		case err == nil:
+
+		case errors.Is(err, objects.ErrNotFound):
+			// deleted since the scan
+
+		case errors.Is(err, errScrubMismatch):
+			mod.log.Error("scrub %v: %v is corrupt", name, id)
+			report.Corrupt = append(report.Corrupt, id)
+
+			if !opts.Quarantine && !opts.Repair {
+				break
+			}
+
+			if err := mod.quarantine(ctx, name, repo, id); err != nil {
+				mod.log.Errorv(1, "scrub %v: quarantine %v: %v", name, id, err)
+				break
+			}
+			report.Quarantined = append(report.Quarantined, id)
+
+			if !opts.Repair {
+				break
+			}
+
+			if err := mod.Download(remote, id, repo); err != nil {
+				mod.log.Errorv(1, "scrub %v: repair %v: %v", name, id, err)
+				break
+			}
+			report.Repaired = append(report.Repaired, id)
+
+		default:
+			if ctx.Err() != nil {
+				continue
+			}
+			mod.log.Errorv(1, "scrub %v: read %v: %v", name, id, err)
+			report.Unreadable = append(report.Unreadable, id)
+		}
+
+		report.Checked++
+		row.Cursor = s
+
+		if i%scrubSaveEvery == scrubSaveEvery-1 {
+			mod.saveScrub(row, report)
+		}
+	}
+
+	report.FinishedAt = astral.Now()
+
+	reportID, err := mod.Store(ctx, mod.WriteDefault(), report)
+	if err != nil {
+		mod.log.Errorv(1, "scrub %v: store report: %v", name, err)
+	}
+
+	finishedAt := report.FinishedAt.Time()
+	row.FinishedAt = &finishedAt
+	row.ReportID = reportID
+	mod.saveScrub(row, report)
+
+	mod.log.Logv(1, "scrub %v: verified %v objects, %v corrupt, %v unreadable",
+		name, report.Checked, len(report.Corrupt), len(report.Unreadable))
+
+	return report, nil
+}
+
+// loadScrub returns the state of an unfinished pass over the repository, or starts a new one.
+func (mod *Module) loadScrub(name string) (*dbScrub, *objects.ScrubReport) {
+	row, err := mod.db.FindScrub(name)
+	if err == nil && row.FinishedAt == nil {
+		var report objects.ScrubReport
+		if json.Unmarshal(row.Report, &report) == nil {
+			return row, &report
+		}
+	}
+
+	report := &objects.ScrubReport{
+		Repo:      astral.String8(name),
+		StartedAt: astral.Now(),
+	}
+
+	return &dbScrub{Repo: name, StartedAt: report.StartedAt.Time()}, report
+}
+
+func (mod *Module) saveScrub(row *dbScrub, report *objects.ScrubReport) {
+	var err error
+
+	row.Report, err = json.Marshal(report)
+	if err == nil {
+		err = mod.db.SaveScrub(row)
+	}
+	if err != nil {
+		mod.log.Errorv(1, "scrub %v: save state: %v", row.Repo, err)
+	}
+}
+
+// verifyObject reads an object and checks that its data resolves to its ID.
+func (mod *Module) verifyObject(ctx *astral.Context, repo objects.Repository, id *astral.ObjectID, throttle *throttle) (int64, error) {
+	r, err := repo.Read(ctx, id, 0, 0)
+	if err != nil {
+		return 0, err
+	}
+	defer r.Close()
+
+	resolver := astral.NewWriteResolver(nil)
+
+	n, err := io.Copy(resolver, throttle.Reader(ctx, r))
+	if err != nil {
+		return n, err
+	}
+
+	if !resolver.Resolve().IsEqual(id) {
+		return n, errScrubMismatch
+	}
+
+	return n, nil
+}
+
+// quarantine removes a corrupt object from the repository. If a quarantine repository is
+// configured, the damaged data is kept there for inspection first.
+func (mod *Module) quarantine(ctx *astral.Context, name string, repo objects.Repository, id *astral.ObjectID) error {
+	var storedID *astral.ObjectID
+
+	if qname := mod.config.Scrub.QuarantineRepo; len(qname) > 0 {
+		qrepo := mod.GetRepository(qname)
+		if qrepo == nil {
+			return fmt.Errorf("quarantine repository %s not found", qname)
+		}
+
+		var err error
+		storedID, err = mod.copyObject(ctx, repo, qrepo, id)
+		if err != nil {
+			return fmt.Errorf("copy to quarantine: %w", err)
+		}
+	}
+
+	err := mod.db.CreateQuarantined(name, id, storedID)
+	if err != nil {
+		return err
+	}
+
+	return repo.Delete(ctx, id)
+}
+
+// copyObject copies the data of an object between repositories and returns the ID of the copy.
+func (mod *Module) copyObject(ctx *astral.Context, from, to objects.Repository, id *astral.ObjectID) (*astral.ObjectID, error) {
+	r, err := from.Read(ctx, id, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	w, err := to.Create(ctx, &objects.CreateOpts{Alloc: int(id.Size)})
+	if err != nil {
+		return nil, err
+	}
+	defer w.Discard()
+
+	_, err = io.Copy(w, r)
+	if err != nil {
+		return nil, err
+	}
+
+	return w.Commit()
+}
+
+// scrubTask scrubs the configured repositories when their pass is due, resumes interrupted
+// passes and schedules its next run.
+type scrubTask struct {
+	mod *Module
+}
+
+var _ scheduler.Task = &scrubTask{}
+
+func (mod *Module) newScrubTask() *scrubTask {
+	return &scrubTask{mod: mod}
+}
+
+func (task *scrubTask) String() string {
+	return "objects.scrub"
+}
+
+func (task *scrubTask) Run(ctx *astral.Context) error {
+	mod := task.mod
+	cfg := mod.config.Scrub
+
+	opts := scrubOpts{
+		Quarantine: cfg.Quarantine,
+		Repair:     cfg.Repair,
+		Rate:       cfg.Rate,
+	}
+
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaultConfig.Scrub.Interval
+	}
+
+	for _, name := range cfg.Repos {
+		row, err := mod.db.FindScrub(name)
+		if err == nil && row.FinishedAt != nil && time.Since(*row.FinishedAt) < cfg.Interval {
+			continue
+		}
+
+		_, err = mod.Scrub(ctx.IncludeZone(astral.ZoneNetwork), name, opts)
+		if err != nil && ctx.Err() == nil {
+			mod.log.Errorv(1, "scrub %v: %v", name, err)
+		}
+	}
+
+	delay, _ := mod.ctx.WithTimeout(min(cfg.Interval, scrubCheckInterval))
+	_, err := mod.Scheduler.Schedule(mod.newScrubTask(), delay)
+
+	return err
+}
+
+// quarantineHolder protects damaged data kept in the quarantine repository from being purged.
+type quarantineHolder struct {
+	mod *Module
+}
+
+func (h *quarantineHolder) HoldObject(objectID *astral.ObjectID) bool {
+	held, err := h.mod.db.IsQuarantined(objectID)
+	return err == nil && held
+}
+
+// throttle limits the average rate of reads made through its readers.
+type throttle struct {
+	rate  int64
+	start time.Time
+	n     int64
+}
+
+func newThrottle(rate int64) *throttle {
+	return &throttle{rate: rate, start: time.Now()}
+}
+
+func (t *throttle) Reader(ctx *astral.Context, r io.Reader) io.Reader {
+	if t.rate <= 0 {
+		return r
+	}
+	return &throttledReader{ctx: ctx, t: t, r: r}
+}
+
+type throttledReader struct {
+	ctx *astral.Context
+	t   *throttle
+	r   io.Reader
+}
+
+func (r *throttledReader) Read(p []byte) (n int, err error) {
+	n, err = r.r.Read(p)
+	r.t.n += int64(n)
+
+	due := r.t.start.Add(time.Duration(float64(r.t.n) / float64(r.t.rate) * float64(time.Second)))
+	if wait := time.Until(due); wait > 0 {
+		select {
+		case <-r.ctx.Done():
+			return n, r.ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+
+	return
+}
@@ -0,0 +1,81 @@
+package objects
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/mod/objects"
+	"github.com/cryptopunkscc/astrald/mod/objects/mem"
+)
+
+// rottenRepository serves the data of an object from another repository in place of one of its objects.
+type rottenRepository struct {
+	*mem.Repository
+	rotten *astral.ObjectID
+	from   objects.Repository
+	with   *astral.ObjectID
+}
+
+func (repo *rottenRepository) Read(ctx *astral.Context, objectID *astral.ObjectID, offset int64, limit int64) (objects.Reader, error) {
+	if objectID.IsEqual(repo.rotten) {
+		return repo.from.Read(ctx, repo.with, offset, limit)
+	}
+	return repo.Repository.Read(ctx, objectID, offset, limit)
+}
+
+// A scrub finds the object whose data no longer matches its ID, keeps the damaged data in
+// the quarantine repository and removes the object from the scrubbed one.
+func TestScrub_Quarantine(t *testing.T) {
+	mod := newTestModule(t)
+	mod.config.Scrub.QuarantineRepo = "quarantine"
+
+	store := mem.New("store", 1<<20)
+	quarantine := mem.New("quarantine", 1<<20)
+	mod.repos.Set("local", mem.New("local", 1<<20))
+	mod.repos.Set("quarantine", quarantine)
+
+	var ids []*astral.ObjectID
+	for i := 0; i < 5; i++ {
+		ids = append(ids, storeBytes(t, store, bytes.Repeat([]byte{byte(i)}, 100)))
+	}
+
+	// the third object rots
+	other := mem.New("other", 1<<20)
+	rotID := storeBytes(t, other, bytes.Repeat([]byte{0xff}, 100))
+	mod.repos.Set("store", &rottenRepository{Repository: store, rotten: ids[2], from: other, with: rotID})
+
+	report, err := mod.Scrub(astral.NewContext(nil), "store", scrubOpts{Quarantine: true})
+	if err != nil {
+		t.Fatalf("scrub: %v", err)
+	}
+
+	if len(report.Corrupt) != 1 || !report.Corrupt[0].IsEqual(ids[2]) {
+		t.Fatalf("corrupt: %v, want %v", report.Corrupt, ids[2])
+	}
+	if len(report.Quarantined) != 1 || report.Checked != 5 {
+		t.Fatalf("quarantined %d, checked %d", len(report.Quarantined), report.Checked)
+	}
+	if has, _ := store.Contains(nil, ids[2]); has {
+		t.Fatal("corrupt object still in the repository")
+	}
+
+	held := false
+	for _, id := range scanIDs(t, quarantine) {
+		if id.IsEqual(rotID) && (&quarantineHolder{mod: mod}).HoldObject(id) {
+			held = true
+		}
+	}
+	if !held {
+		t.Fatal("damaged data not kept in the quarantine repository")
+	}
+
+	// the next pass starts over and finds nothing
+	report, err = mod.Scrub(astral.NewContext(nil), "store", scrubOpts{Quarantine: true})
+	if err != nil {
+		t.Fatalf("scrub: %v", err)
+	}
+	if len(report.Corrupt) != 0 || report.Checked != 4 {
+		t.Fatalf("second pass: %d corrupt of %d checked", len(report.Corrupt), report.Checked)
+	}
+}
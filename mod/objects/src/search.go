@@ -13,6 +13,33 @@ import (
 // Search runs all local searchers, and network searchers when the context zone permits, merging their results into one channel.
 // The channel closes once every searcher finishes; the returned error reports only setup failures.
 func (mod *Module) Search(ctx *astral.Context, query objects.SearchQuery) (<-chan *objects.SearchResult, error) {
+	ranked, err := mod.searchRanked(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	var results = make(chan *objects.SearchResult)
+
+	go func() {
+		defer close(results)
+
+		for {
+			result, ok, err := sig.RecvOk(ctx, ranked)
+			if err != nil || !ok {
+				return
+			}
+
+			if err := sig.Send(ctx, results, result.SearchResult()); err != nil {
+				return
+			}
+		}
+	}()
+
+	return results, nil
+}
+
+// searchRanked runs a search like Search, keeping the scores of ranked searchers.
+func (mod *Module) searchRanked(ctx *astral.Context, query objects.SearchQuery) (<-chan *objects.ScoredResult, error) {
 	search := &objects.Search{
 		CallerID: ctx.Identity(),
 		Query:    query,
@@ -22,7 +49,7 @@ func (mod *Module) Search(ctx *astral.Context, query objects.SearchQuery) (<-cha
 		pre.PreprocessSearch(search)
 	}
 
-	var results = make(chan *objects.SearchResult)
+	var results = make(chan *objects.ScoredResult)
 	var wg sync.WaitGroup
 
 	// run local searchers
@@ -31,27 +58,7 @@ func (mod *Module) Search(ctx *astral.Context, query objects.SearchQuery) (<-cha
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-
-			_res, _err := searcher.SearchObject(ctx, query)
-			if _err != nil {
-				return
-			}
-
-			for {
-				result, ok, err := sig.RecvOk(ctx, _res)
-				if err != nil || !ok {
-					return
-				}
-
-				if result == nil || result.ObjectID == nil || result.ObjectID.IsZero() {
-					mod.log.Errorv(1, "searcher %T returned invalid result", searcher)
-					continue
-				}
-
-				if err := sig.Send(ctx, results, result); err != nil {
-					return
-				}
-			}
+			mod.runSearcher(ctx, searcher, query, results)
 		}()
 	}
 
@@ -63,38 +70,7 @@ func (mod *Module) Search(ctx *astral.Context, query objects.SearchQuery) (<-cha
 			wg.Add(1)
 			go func() {
 				defer wg.Done()
-
-				// execute search
-				_results, errPtr := objectscli.New(nodeID, astrald.Default()).Search(ctx, query)
-				if _results == nil {
-					if errPtr != nil && *errPtr != nil {
-						mod.log.Errorv(1, "search %v: %v", nodeID, *errPtr)
-					}
-					return
-				}
-
-				// copy results
-				for {
-					result, ok, err := sig.RecvOk(ctx, _results)
-					if err != nil {
-						return
-					}
-					if !ok {
-						if errPtr != nil && *errPtr != nil {
-							mod.log.Errorv(1, "search %v: %v", nodeID, *errPtr)
-						}
-						return
-					}
-
-					if result == nil || result.ObjectID == nil || result.ObjectID.IsZero() {
-						mod.log.Errorv(1, "network search %v returned invalid result", nodeID)
-						continue
-					}
-
-					if err := sig.Send(ctx, results, result); err != nil {
-						return
-					}
-				}
+				mod.runNetworkSearch(ctx, nodeID, query, results)
 			}()
 		}
 	}
@@ -107,6 +83,103 @@ func (mod *Module) Search(ctx *astral.Context, query objects.SearchQuery) (<-cha
 	return results, nil
 }
 
+// runSearcher sends the results of a local searcher to results until it is done.
+func (mod *Module) runSearcher(ctx *astral.Context, searcher objects.Searcher, query objects.SearchQuery, results chan<- *objects.ScoredResult) {
+	var found <-chan *objects.ScoredResult
+	var err error
+
+	if ranked, ok := searcher.(objects.RankedSearcher); ok {
+		found, err = ranked.SearchRanked(ctx, query)
+	} else {
+		var unranked <-chan *objects.SearchResult
+		unranked, err = searcher.SearchObject(ctx, query)
+		if err == nil {
+			found = unscored(ctx, unranked)
+		}
+	}
+	if err != nil {
+		return
+	}
+
+	for {
+		result, ok, err := sig.RecvOk(ctx, found)
+		if err != nil || !ok {
+			return
+		}
+
+		if result == nil || result.ObjectID == nil || result.ObjectID.IsZero() {
+			mod.log.Errorv(1, "searcher %T returned invalid result", searcher)
+			continue
+		}
+
+		if err := sig.Send(ctx, results, result); err != nil {
+			return
+		}
+	}
+}
+
+// runNetworkSearch sends the results of a search on a remote node to results until it is done.
+func (mod *Module) runNetworkSearch(ctx *astral.Context, nodeID *astral.Identity, query objects.SearchQuery, results chan<- *objects.ScoredResult) {
+	// execute search
+	_results, errPtr := objectscli.New(nodeID, astrald.Default()).Search(ctx, query)
+	if _results == nil {
+		if errPtr != nil && *errPtr != nil {
+			mod.log.Errorv(1, "search %v: %v", nodeID, *errPtr)
+		}
+		return
+	}
+
+	// copy results
+	for {
+		result, ok, err := sig.RecvOk(ctx, _results)
+		if err != nil {
+			return
+		}
+		if !ok {
+			if errPtr != nil && *errPtr != nil {
+				mod.log.Errorv(1, "search %v: %v", nodeID, *errPtr)
+			}
+			return
+		}
+
+		if result == nil || result.ObjectID == nil || result.ObjectID.IsZero() {
+			mod.log.Errorv(1, "network search %v returned invalid result", nodeID)
+			continue
+		}
+
+		if err := sig.Send(ctx, results, &objects.ScoredResult{SourceID: result.SourceID, ObjectID: result.ObjectID}); err != nil {
+			return
+		}
+	}
+}
+
+// unscored relays the results of a searcher that doesn't rank them with a score of 0.
+func unscored(ctx *astral.Context, in <-chan *objects.SearchResult) <-chan *objects.ScoredResult {
+	var out = make(chan *objects.ScoredResult)
+
+	go func() {
+		defer close(out)
+
+		for {
+			result, ok, err := sig.RecvOk(ctx, in)
+			if err != nil || !ok {
+				return
+			}
+
+			var scored *objects.ScoredResult
+			if result != nil {
+				scored = &objects.ScoredResult{SourceID: result.SourceID, ObjectID: result.ObjectID}
+			}
+
+			if err := sig.Send(ctx, out, scored); err != nil {
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
 // AddSearcher registers a searcher, deduplicating by source identity so each source is added at most once.
 func (mod *Module) AddSearcher(searcher objects.Searcher) error {
 	source, ok, err := objects.SourceIdentity(searcher)
@@ -0,0 +1,386 @@
+package objects
+
+import (
+	"cmp"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/mod/objects"
+	"github.com/cryptopunkscc/astrald/sig"
+)
+
+const (
+	searchSessionTTL  = 5 * time.Minute // how long the results of a paged search are kept for next pages
+	maxSearchSessions = 64              // maximum number of kept paged searches
+	maxSearchResults  = 100_000         // maximum number of results collected by a paged search
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// searchSession collects the results of a paged search in the background, so that pages are
+// returned as soon as they are filled and next pages don't run the search again. Results that
+// were returned in a page keep their positions; results found later are sorted after them.
+type searchSession struct {
+	key       string // the query, sort order and repository of the search
+	compare   func(a, b *objects.ScoredResult) int
+	after     *objects.ScoredResult // results up to this one were returned by an earlier search
+	createdAt time.Time
+	cancel    context.CancelFunc
+
+	mu       sync.Mutex
+	cond     *sync.Cond
+	results  []*objects.ScoredResult // returned results in page order, then the rest
+	index    map[string]int          // position of every result by ObjectID
+	skipped  map[string]struct{}     // objects found up to after, returned by an earlier search
+	served   int                     // number of results returned in pages
+	sorted   bool                    // results after the returned ones are sorted
+	done     bool                    // the search has ended
+	complete bool                    // the search has ended without being cut short
+}
+
+// searchCursor is the decoded form of a SearchCursor. It points past the last result of a page,
+// by the session that holds the results and the offset of the next page, and by the sort key of
+// the result, in case the session expired and the search has to run again.
+type searchCursor struct {
+	Session string  `json:"s"`
+	Offset  int     `json:"o,omitempty"`
+	Score   float64 `json:"sc,omitempty"`
+	Size    uint64  `json:"sz,omitempty"`
+	ID      string  `json:"id"`
+}
+
+func (c *searchCursor) encode() astral.String16 {
+	data, _ := json.Marshal(c)
+	return astral.String16(base64.RawURLEncoding.EncodeToString(data))
+}
+
+// last returns the sort key of the result the cursor points past.
+func (c *searchCursor) last() *objects.ScoredResult {
+	last := &objects.ScoredResult{
+		ObjectID: &astral.ObjectID{Size: c.Size},
+		Score:    astral.Float64(c.Score),
+	}
+	if id, err := astral.ParseID(c.ID); err == nil {
+		last.ObjectID = id
+	}
+	return last
+}
+
+func decodeSearchCursor(s string) (*searchCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var c searchCursor
+	if json.Unmarshal(data, &c) != nil || len(c.ID) == 0 {
+		return nil, ErrInvalidCursor
+	}
+
+	return &c, nil
+}
+
+// SearchPage returns a page of merged, deduplicated and sorted results of a search, and the cursor
+// of the next page (nil after the last page). If repo is not nil, only objects it contains are returned.
+//
+// The search keeps running in the background after a page is filled, so a page is sorted among the
+// results found so far, and better results found later are returned in later pages.
+func (mod *Module) SearchPage(ctx *astral.Context, query objects.SearchQuery, opts objects.SearchPageOpts, repo objects.Repository) ([]*objects.ScoredResult, *objects.SearchCursor, error) {
+	sort := opts.Sort
+	if len(sort) == 0 {
+		sort = objects.SortScore
+	}
+
+	compare, ok := searchOrders[sort]
+	if !ok {
+		return nil, nil, errors.New("unsupported sort order " + sort)
+	}
+
+	key := query.String() + "\n" + sort + "\n" + mod.getRepoName(repo)
+
+	var cursor *searchCursor
+	if len(opts.Cursor) > 0 {
+		var err error
+		cursor, err = decodeSearchCursor(opts.Cursor)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// continue a kept search or run it
+	var sessionID string
+	var session *searchSession
+	var start int
+
+	if cursor != nil {
+		if s, found := mod.searchSessions.Get(cursor.Session); found && s.key == key && time.Since(s.createdAt) < searchSessionTTL {
+			if s.resumes(cursor) {
+				sessionID, session, start = cursor.Session, s, cursor.Offset
+			}
+		}
+	}
+
+	if session == nil {
+		var after *objects.ScoredResult
+		if cursor != nil {
+			after = cursor.last()
+		}
+
+		var err error
+		session, err = mod.startSearch(ctx, query, repo, key, compare, after)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		sessionID = strconv.FormatUint(uint64(astral.NewNonce()), 16)
+		mod.keepSearchSession(sessionID, session)
+	}
+
+	page, more := session.page(ctx, start, opts.Limit)
+	if !more || len(page) == 0 {
+		return page, nil, nil
+	}
+
+	last := page[len(page)-1]
+	next := &searchCursor{
+		Session: sessionID,
+		Offset:  start + len(page),
+		Score:   float64(last.Score),
+		Size:    last.ObjectID.Size,
+		ID:      last.ObjectID.String(),
+	}
+
+	return page, &objects.SearchCursor{Cursor: next.encode()}, nil
+}
+
+// startSearch runs a search in the background for a new session. The search is detached from ctx,
+// since it outlives the first page, and is cut short when the session expires.
+func (mod *Module) startSearch(ctx *astral.Context, query objects.SearchQuery, repo objects.Repository, key string, compare func(a, b *objects.ScoredResult) int, after *objects.ScoredResult) (*searchSession, error) {
+	sctx, cancel := ctx.Detach().WithTimeout(searchSessionTTL)
+
+	matches, err := mod.searchRanked(sctx, query)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	var filter func(*objects.ScoredResult) bool
+	if repo != nil {
+		filter = func(result *objects.ScoredResult) bool {
+			contains, err := repo.Contains(sctx, result.ObjectID)
+			return err == nil && contains
+		}
+	}
+
+	session := &searchSession{
+		key:       key,
+		compare:   compare,
+		after:     after,
+		createdAt: time.Now(),
+		cancel:    cancel,
+		index:     map[string]int{},
+		skipped:   map[string]struct{}{},
+	}
+	session.cond = sync.NewCond(&session.mu)
+
+	go func() {
+		defer cancel()
+		session.collect(sctx, matches, filter)
+	}()
+
+	return session, nil
+}
+
+// collect adds the results of a search to the session until the search ends or ctx is done.
+// Objects found by more than one searcher keep their highest score, unless already returned.
+func (s *searchSession) collect(ctx *astral.Context, matches <-chan *objects.ScoredResult, filter func(*objects.ScoredResult) bool) {
+	var complete bool
+	defer func() {
+		s.mu.Lock()
+		s.done, s.complete = true, complete
+		s.cond.Broadcast()
+		s.mu.Unlock()
+	}()
+
+	for {
+		match, ok, err := sig.RecvOk(ctx, matches)
+		if err != nil {
+			return
+		}
+		if !ok {
+			complete = true
+			return
+		}
+
+		if s.after != nil && s.compare(match, s.after) <= 0 {
+			s.skip(match)
+			continue
+		}
+		if s.update(match) {
+			continue
+		}
+		if filter != nil && !filter(match) {
+			continue
+		}
+
+		s.add(match)
+	}
+}
+
+// update replaces a known result with a higher scored one, if it wasn't returned yet. Returns
+// false if the object is not known.
+func (s *searchSession) update(match *objects.ScoredResult) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i, found := s.index[match.ObjectID.String()]
+	if !found {
+		return false
+	}
+
+	if i >= s.served && match.Score > s.results[i].Score {
+		s.results[i] = match
+		s.sorted = false
+	}
+
+	return true
+}
+
+// skip drops an object from the results, since an earlier search returned it.
+func (s *searchSession) skip(match *objects.ScoredResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.skipped[match.ObjectID.String()] = struct{}{}
+	s.sorted = false
+}
+
+func (s *searchSession) add(match *objects.ScoredResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.results) >= maxSearchResults {
+		return
+	}
+
+	s.index[match.ObjectID.String()] = len(s.results)
+	s.results = append(s.results, match)
+	s.sorted = false
+	s.cond.Broadcast()
+}
+
+// page waits until the page at start is filled, the search ends or ctx is done, and returns the
+// page. More is false if the search has ended and the page holds its last result. A search run
+// again after an expired session waits for all results, so that it continues in sort order from
+// the cursor.
+func (s *searchSession) page(ctx *astral.Context, start, limit int) (page []*objects.ScoredResult, more bool) {
+	stop := context.AfterFunc(ctx, func() {
+		s.mu.Lock()
+		s.cond.Broadcast()
+		s.mu.Unlock()
+	})
+	defer stop()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for !s.done && ctx.Err() == nil && (limit <= 0 || s.after != nil || len(s.results) < start+limit) {
+		s.cond.Wait()
+	}
+
+	if !s.sorted {
+		tail := slices.DeleteFunc(s.results[s.served:], func(result *objects.ScoredResult) bool {
+			key := result.ObjectID.String()
+			if _, found := s.skipped[key]; found {
+				delete(s.index, key)
+				return true
+			}
+			return false
+		})
+		s.results = s.results[:s.served+len(tail)]
+
+		slices.SortFunc(tail, s.compare)
+		for i, result := range tail {
+			s.index[result.ObjectID.String()] = s.served + i
+		}
+		s.sorted = true
+	}
+
+	start = min(start, len(s.results))
+	end := len(s.results)
+	if limit > 0 {
+		end = min(start+limit, end)
+	}
+
+	page = slices.Clone(s.results[start:end])
+	s.served = max(s.served, end)
+
+	return page, end < len(s.results) || !s.complete
+}
+
+// resumes checks if the next page of a cursor can be returned from the session. A session cut
+// short cannot return results past the ones it has, so the search has to run again.
+func (s *searchSession) resumes(cursor *searchCursor) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case cursor.Offset <= 0 || cursor.Offset > s.served:
+		return false
+	case s.results[cursor.Offset-1].ObjectID.String() != cursor.ID:
+		return false
+	case s.done && !s.complete && cursor.Offset >= len(s.results):
+		return false
+	}
+
+	return true
+}
+
+// keepSearchSession keeps the results of a paged search, dropping expired sessions and, if there
+// are too many, the oldest one. The searches of dropped sessions are stopped.
+func (mod *Module) keepSearchSession(id string, session *searchSession) {
+	var oldestID string
+	var oldest time.Time
+
+	for sid, s := range mod.searchSessions.Clone() {
+		if time.Since(s.createdAt) > searchSessionTTL {
+			mod.searchSessions.Delete(sid)
+			s.cancel()
+			continue
+		}
+		if len(oldestID) == 0 || s.createdAt.Before(oldest) {
+			oldestID, oldest = sid, s.createdAt
+		}
+	}
+
+	if mod.searchSessions.Len() >= maxSearchSessions {
+		if s, ok := mod.searchSessions.Delete(oldestID); ok {
+			s.cancel()
+		}
+	}
+
+	mod.searchSessions.Set(id, session)
+}
+
+// searchOrders compare results by the supported sort orders. Ties are ordered by ObjectID
+// text, so every order is total and a cursor always points at a single position.
+var searchOrders = map[string]func(a, b *objects.ScoredResult) int{
+	objects.SortScore: func(a, b *objects.ScoredResult) int {
+		return cmp.Or(cmp.Compare(b.Score, a.Score), compareIDs(a, b))
+	},
+	objects.SortID: compareIDs,
+	objects.SortSize: func(a, b *objects.ScoredResult) int {
+		return cmp.Or(cmp.Compare(b.ObjectID.Size, a.ObjectID.Size), compareIDs(a, b))
+	},
+}
+
+func compareIDs(a, b *objects.ScoredResult) int {
+	return cmp.Compare(a.ObjectID.String(), b.ObjectID.String())
+}
@@ -0,0 +1,231 @@
+package objects
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/astral/log"
+	"github.com/cryptopunkscc/astrald/mod/objects"
+)
+
+// staticSearcher returns the same results for every query.
+type staticSearcher struct {
+	results []*objects.ScoredResult
+}
+
+func (s *staticSearcher) SearchObject(ctx *astral.Context, query objects.SearchQuery) (<-chan *objects.SearchResult, error) {
+	return nil, errors.New("unranked search")
+}
+
+func (s *staticSearcher) SearchRanked(ctx *astral.Context, query objects.SearchQuery) (<-chan *objects.ScoredResult, error) {
+	var out = make(chan *objects.ScoredResult, len(s.results))
+	defer close(out)
+
+	for _, r := range s.results {
+		out <- r
+	}
+	return out, nil
+}
+
+func testResultID(i int) *astral.ObjectID {
+	id, _ := astral.Resolve(strings.NewReader(fmt.Sprint(i)))
+	return id
+}
+
+// waitSearches waits until the searches of all kept sessions have ended.
+func waitSearches(mod *Module) {
+	for _, s := range mod.searchSessions.Clone() {
+		s.mu.Lock()
+		for !s.done {
+			s.cond.Wait()
+		}
+		s.mu.Unlock()
+	}
+}
+
+// A kept session returns every result once. After the session expires, the search runs again and
+// continues in order after the last returned result.
+func TestSearchPage_Cursor(t *testing.T) {
+	ctx := astral.NewContext(nil)
+	mod := &Module{log: log.New(nil)}
+
+	// two searchers with overlapping results and tied scores
+	a, b := &staticSearcher{}, &staticSearcher{}
+	for i := 0; i < 50; i++ {
+		a.results = append(a.results, &objects.ScoredResult{ObjectID: testResultID(i), Score: astral.Float64(i % 7)})
+	}
+	for i := 40; i < 60; i++ {
+		b.results = append(b.results, &objects.ScoredResult{ObjectID: testResultID(i), Score: 10})
+	}
+	mod.searchers.Add(a)
+	mod.searchers.Add(b)
+
+	// every object with its highest score, in order
+	var sorted []*objects.ScoredResult
+	sorted = append(sorted, a.results[:40]...)
+	sorted = append(sorted, b.results...)
+	slices.SortFunc(sorted, searchOrders[objects.SortScore])
+
+	for _, expire := range []bool{false, true} {
+		opts := objects.SearchPageOpts{Limit: 7}
+
+		var first, all []*objects.ScoredResult
+		for pages := 0; ; pages++ {
+			if pages > 20 {
+				t.Fatal("too many pages")
+			}
+
+			page, next, err := mod.SearchPage(ctx, objects.SearchQuery{}, opts, nil)
+			if err != nil {
+				t.Fatalf("search page: %v", err)
+			}
+			if len(page) > 7 {
+				t.Fatalf("page of %d results", len(page))
+			}
+			if pages == 0 {
+				first = page
+			}
+			all = append(all, page...)
+
+			if next == nil {
+				break
+			}
+			opts.Cursor = string(next.Cursor)
+
+			if expire {
+				waitSearches(mod)
+				for id := range mod.searchSessions.Clone() {
+					mod.searchSessions.Delete(id)
+				}
+			}
+		}
+
+		seen := map[string]bool{}
+		for _, r := range all {
+			if seen[r.ObjectID.String()] {
+				t.Fatalf("duplicate result %v", r.ObjectID)
+			}
+			seen[r.ObjectID.String()] = true
+		}
+
+		want := sorted
+		if expire {
+			// the first page may be returned before all results are found
+			last := first[len(first)-1]
+			want = slices.Clone(first)
+			for _, r := range sorted {
+				if searchOrders[objects.SortScore](r, last) > 0 && !slices.ContainsFunc(first, func(f *objects.ScoredResult) bool {
+					return f.ObjectID.IsEqual(r.ObjectID)
+				}) {
+					want = append(want, r)
+				}
+			}
+		}
+
+		if len(all) != len(want) {
+			t.Fatalf("got %d results, want %d", len(all), len(want))
+		}
+		for i := len(first); expire && i < len(all); i++ {
+			if !all[i].ObjectID.IsEqual(want[i].ObjectID) || all[i].Score != want[i].Score {
+				t.Fatalf("result %d: got %v (%v), want %v (%v)", i, all[i].ObjectID, all[i].Score, want[i].ObjectID, want[i].Score)
+			}
+		}
+	}
+}
+
+// blockingSearcher returns its first results at once and the rest after release is closed.
+type blockingSearcher struct {
+	first, rest []*objects.ScoredResult
+	release     chan struct{}
+}
+
+func (s *blockingSearcher) SearchObject(ctx *astral.Context, query objects.SearchQuery) (<-chan *objects.SearchResult, error) {
+	return nil, errors.New("unranked search")
+}
+
+func (s *blockingSearcher) SearchRanked(ctx *astral.Context, query objects.SearchQuery) (<-chan *objects.ScoredResult, error) {
+	var out = make(chan *objects.ScoredResult)
+
+	go func() {
+		defer close(out)
+
+		for i, r := range append(slices.Clone(s.first), s.rest...) {
+			if i == len(s.first) {
+				select {
+				case <-s.release:
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			select {
+			case out <- r:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// The first page is returned as soon as it is filled, while the search goes on in the background.
+// A search cut short keeps returning cursors instead of ending with its last result.
+func TestSearchPage_FirstPage(t *testing.T) {
+	ctx := astral.NewContext(nil)
+	mod := &Module{log: log.New(nil)}
+
+	searcher := &blockingSearcher{release: make(chan struct{})}
+	for i := 0; i < 10; i++ {
+		r := &objects.ScoredResult{ObjectID: testResultID(i), Score: astral.Float64(i)}
+		if i < 5 {
+			searcher.first = append(searcher.first, r)
+		} else {
+			searcher.rest = append(searcher.rest, r)
+		}
+	}
+	mod.searchers.Add(searcher)
+
+	pageCtx, cancel := ctx.WithTimeout(5 * time.Second)
+	defer cancel()
+
+	page, next, err := mod.SearchPage(pageCtx, objects.SearchQuery{}, objects.SearchPageOpts{Limit: 5}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pageCtx.Err() != nil || len(page) != 5 || next == nil {
+		t.Fatalf("got %d results and cursor %v before the search ended", len(page), next)
+	}
+
+	close(searcher.release)
+
+	var count = len(page)
+	for next != nil {
+		page, next, err = mod.SearchPage(ctx, objects.SearchQuery{}, objects.SearchPageOpts{Limit: 5, Cursor: string(next.Cursor)}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		count += len(page)
+	}
+	if count != 10 {
+		t.Fatalf("got %d results, want 10", count)
+	}
+
+	// a search that timed out is not final
+	cut := &searchSession{compare: compareIDs, index: map[string]int{}}
+	cut.cond = sync.NewCond(&cut.mu)
+
+	cutCtx, cutCancel := ctx.WithCancel()
+	cutCancel()
+	cut.collect(cutCtx, make(chan *objects.ScoredResult), nil)
+
+	if _, more := cut.page(ctx, 0, 5); !more {
+		t.Fatal("search cut short returned as complete")
+	}
+}
@@ -0,0 +1,459 @@
+package objects
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/astral/channel"
+	"github.com/cryptopunkscc/astrald/lib/astrald"
+	"github.com/cryptopunkscc/astrald/lib/query"
+	"github.com/cryptopunkscc/astrald/mod/objects"
+	objectscli "github.com/cryptopunkscc/astrald/mod/objects/client"
+)
+
+const (
+	syncListThreshold = 32 // ranges with at most this many objects are exchanged as lists
+	syncBranches      = 16 // number of subranges a differing range is split into
+)
+
+// syncSet is a snapshot of a repository sorted by object key, the order in which ranges
+// are reconciled.
+type syncSet struct {
+	keys [][]byte
+	ids  []*astral.ObjectID
+}
+
+func newSyncSet(ids []*astral.ObjectID) *syncSet {
+	set := &syncSet{ids: slices.Clone(ids)}
+
+	sort.Slice(set.ids, func(i, j int) bool {
+		return bytes.Compare(syncKey(set.ids[i]), syncKey(set.ids[j])) < 0
+	})
+	set.ids = slices.CompactFunc(set.ids, func(a, b *astral.ObjectID) bool { return a.IsEqual(b) })
+
+	for _, id := range set.ids {
+		set.keys = append(set.keys, syncKey(id))
+	}
+
+	return set
+}
+
+// syncKey orders objects by hash, then size.
+func syncKey(id *astral.ObjectID) []byte {
+	return binary.BigEndian.AppendUint64(slices.Clone(id.Hash[:]), id.Size)
+}
+
+// bounds returns the index range of the objects with lower <= key < upper. An empty upper
+// bound is unbounded.
+func (set *syncSet) bounds(lower, upper []byte) (i, j int) {
+	i = sort.Search(len(set.keys), func(k int) bool { return bytes.Compare(set.keys[k], lower) >= 0 })
+	j = len(set.keys)
+	if len(upper) > 0 {
+		j = sort.Search(len(set.keys), func(k int) bool { return bytes.Compare(set.keys[k], upper) >= 0 })
+	}
+	return i, max(i, j)
+}
+
+func (set *syncSet) has(id *astral.ObjectID) bool {
+	_, found := slices.BinarySearchFunc(set.keys, syncKey(id), bytes.Compare)
+	return found
+}
+
+// fingerprint summarizes the objects in the index range i:j.
+func (set *syncSet) fingerprint(i, j int) []byte {
+	var acc [sha256.Size]byte
+	for _, id := range set.ids[i:j] {
+		for k := range acc {
+			acc[k] ^= id.Hash[k]
+		}
+	}
+
+	sum := sha256.Sum256(binary.BigEndian.AppendUint64(acc[:], uint64(j-i)))
+	return sum[:16]
+}
+
+func (set *syncSet) fingerprintRange(lower, upper []byte, i, j int) *objects.SyncRange {
+	return &objects.SyncRange{
+		Lower:       lower,
+		Upper:       upper,
+		Mode:        objects.SyncFingerprint,
+		Fingerprint: set.fingerprint(i, j),
+		Count:       astral.Uint32(j - i),
+	}
+}
+
+// reconcile runs the initiating side of the set reconciliation. Returns the objects that only
+// the other side holds (need) and those that only the local set holds (have).
+func reconcile(ch *channel.Channel, local *syncSet) (need, have []*astral.ObjectID, err error) {
+	pending := []*objects.SyncRange{local.fingerprintRange(nil, nil, 0, len(local.ids))}
+
+	for len(pending) > 0 {
+		for _, r := range pending {
+			if err = ch.Send(r); err != nil {
+				return
+			}
+		}
+		if err = ch.Send(&astral.EOS{}); err != nil {
+			return
+		}
+
+		var replies []*objects.SyncRange
+		err = ch.Switch(channel.Collect(&replies), channel.BreakOnEOS, channel.PassErrors)
+		if err != nil {
+			return
+		}
+
+		pending = nil
+		for _, r := range replies {
+			i, j := local.bounds(r.Lower, r.Upper)
+
+			switch r.Mode {
+			case objects.SyncList:
+				remote := newSyncSet(r.IDs)
+				for _, id := range remote.ids {
+					if !local.has(id) {
+						need = append(need, id)
+					}
+				}
+				for _, id := range local.ids[i:j] {
+					if !remote.has(id) {
+						have = append(have, id)
+					}
+				}
+
+			case objects.SyncFingerprint:
+				if int(r.Count) == j-i && bytes.Equal(r.Fingerprint, local.fingerprint(i, j)) {
+					continue
+				}
+				pending = append(pending, local.fingerprintRange(r.Lower, r.Upper, i, j))
+			}
+		}
+	}
+
+	// an empty round ends the reconciliation
+	err = ch.Send(&astral.EOS{})
+	return
+}
+
+// respondReconcile runs the responding side of the set reconciliation until the initiator
+// sends an empty round. Differing ranges are answered with a list of objects if they are
+// small, otherwise they are split into subranges.
+func respondReconcile(ch *channel.Channel, set *syncSet) error {
+	for {
+		var ranges []*objects.SyncRange
+		err := ch.Switch(channel.Collect(&ranges), channel.BreakOnEOS, channel.PassErrors)
+		if err != nil {
+			return err
+		}
+		if len(ranges) == 0 {
+			return nil
+		}
+
+		for _, r := range ranges {
+			if r.Mode != objects.SyncFingerprint {
+				return fmt.Errorf("unexpected sync range mode %d", r.Mode)
+			}
+
+			for _, reply := range set.answer(r) {
+				if err = ch.Send(reply); err != nil {
+					return err
+				}
+			}
+		}
+
+		if err = ch.Send(&astral.EOS{}); err != nil {
+			return err
+		}
+	}
+}
+
+// answer returns the replies to a fingerprint range received from the initiator.
+func (set *syncSet) answer(r *objects.SyncRange) []*objects.SyncRange {
+	i, j := set.bounds(r.Lower, r.Upper)
+	n := j - i
+
+	switch {
+	case int(r.Count) == n && bytes.Equal(r.Fingerprint, set.fingerprint(i, j)):
+		return []*objects.SyncRange{{Lower: r.Lower, Upper: r.Upper, Mode: objects.SyncSkip}}
+
+	case n <= syncListThreshold:
+		return []*objects.SyncRange{{
+			Lower: r.Lower,
+			Upper: r.Upper,
+			Mode:  objects.SyncList,
+			Count: astral.Uint32(n),
+			IDs:   slices.Clone(set.ids[i:j]),
+		}}
+	}
+
+	var replies []*objects.SyncRange
+	step := (n + syncBranches - 1) / syncBranches
+	for b := 0; b < n; b += step {
+		lower, upper := []byte(r.Lower), []byte(r.Upper)
+		if b > 0 {
+			lower = set.keys[i+b]
+		}
+		if b+step < n {
+			upper = set.keys[i+b+step]
+		}
+		replies = append(replies, set.fingerprintRange(lower, upper, i+b, min(i+b+step, j)))
+	}
+	return replies
+}
+
+// scanSyncSet takes a snapshot of a repository.
+func scanSyncSet(ctx *astral.Context, repo objects.Repository) (*syncSet, error) {
+	scan, err := repo.Scan(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []*astral.ObjectID
+	for id := range scan {
+		ids = append(ids, id)
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	return newSyncSet(ids), nil
+}
+
+// syncTask mirrors a local repository and a repository of another node.
+type syncTask struct {
+	mod        *Module
+	repo       objects.Repository
+	node       *astral.Identity
+	remoteRepo string
+	pull       bool
+	push       bool
+	client     *objectscli.Client
+
+	mu       sync.Mutex
+	report   objects.SyncReport
+	onReport func(*objects.SyncReport)
+}
+
+func (mod *Module) newSyncTask(repo objects.Repository, node *astral.Identity, remoteRepo string, direction string) (*syncTask, error) {
+	task := &syncTask{
+		mod:        mod,
+		repo:       repo,
+		node:       node,
+		remoteRepo: remoteRepo,
+		client:     objectscli.New(node, astrald.Default()),
+	}
+
+	switch direction {
+	case objects.SyncPull, "":
+		task.pull = true
+	case objects.SyncPush:
+		task.push = true
+	case objects.SyncBoth:
+		task.pull, task.push = true, true
+	default:
+		return nil, fmt.Errorf("invalid direction %q", direction)
+	}
+
+	return task, nil
+}
+
+// reconcile compares the repositories and transfers the difference.
+func (task *syncTask) reconcile(ctx *astral.Context) error {
+	local, err := scanSyncSet(ctx, task.repo)
+	if err != nil {
+		return err
+	}
+
+	q := query.New(task.mod.node.Identity(), task.node, objects.MethodReconcile, query.Args{
+		"repo": task.remoteRepo,
+	})
+
+	ch, err := query.Route(ctx, task.mod.node, q)
+	if err != nil {
+		return err
+	}
+	defer ch.Close()
+
+	need, have, err := reconcile(ch, local)
+	if err != nil {
+		return err
+	}
+
+	task.mod.log.Logv(1, "sync %v with %v: %v to pull, %v to push", task.remoteRepo, task.node, len(need), len(have))
+
+	if task.pull {
+		for _, id := range need {
+			task.done(ctx, id, task.pullObject(ctx, id), false)
+		}
+	}
+	if task.push {
+		for _, id := range have {
+			task.done(ctx, id, task.pushObject(ctx, id), true)
+		}
+	}
+
+	return nil
+}
+
+// follow transfers objects added to either repository until ctx is cancelled.
+func (task *syncTask) follow(ctx *astral.Context) error {
+	var wg sync.WaitGroup
+	errc := make(chan error, 2)
+
+	if task.pull {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			scan, errPtr := task.client.Scan(ctx, task.remoteRepo, true)
+			if scan == nil {
+				errc <- *errPtr
+				return
+			}
+
+			task.followScan(ctx, scan, func(id *astral.ObjectID) {
+				if has, _ := task.repo.Contains(ctx, id); !has {
+					task.done(ctx, id, task.pullObject(ctx, id), false)
+				}
+			})
+			errc <- *errPtr
+		}()
+	}
+
+	if task.push {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			scan, err := task.repo.Scan(ctx, true)
+			if err != nil {
+				errc <- err
+				return
+			}
+
+			task.followScan(ctx, scan, func(id *astral.ObjectID) {
+				if has, _ := task.client.Contains(ctx, task.remoteRepo, id); !has {
+					task.done(ctx, id, task.pushObject(ctx, id), true)
+				}
+			})
+			errc <- nil
+		}()
+	}
+
+	wg.Wait()
+	close(errc)
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	var errs []error
+	for err := range errc {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// followScan calls fn for every object emitted by a follow scan after its snapshot.
+func (task *syncTask) followScan(ctx *astral.Context, scan <-chan *astral.ObjectID, fn func(*astral.ObjectID)) {
+	var live bool
+	for id := range scan {
+		switch {
+		case id == nil:
+			live = true
+		case live:
+			fn(id)
+		}
+	}
+}
+
+func (task *syncTask) pullObject(ctx *astral.Context, id *astral.ObjectID) error {
+	r, err := task.client.ReadRepo(ctx, task.remoteRepo, id, 0, 0)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	w, err := task.repo.Create(ctx, &objects.CreateOpts{Alloc: int(id.Size)})
+	if err != nil {
+		return err
+	}
+	defer w.Discard()
+
+	err = copyObject(w, r, id)
+	if err != nil {
+		return err
+	}
+
+	return task.mod.db.Create(id, "")
+}
+
+func (task *syncTask) pushObject(ctx *astral.Context, id *astral.ObjectID) error {
+	r, err := task.repo.Read(ctx, id, 0, 0)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	w, err := task.client.Create(ctx, task.remoteRepo, int(id.Size))
+	if err != nil {
+		return err
+	}
+	defer w.Discard()
+
+	return copyObject(w, r, id)
+}
+
+// copyObject copies the data of an object and commits it if it resolves to id.
+func copyObject(w objects.Writer, r io.Reader, id *astral.ObjectID) error {
+	resolver := astral.NewWriteResolver(w)
+
+	_, err := io.Copy(resolver, io.LimitReader(r, int64(id.Size)+1))
+	if err != nil {
+		return err
+	}
+
+	if resolved := resolver.Resolve(); !resolved.IsEqual(id) {
+		return fmt.Errorf("data resolves to %v", resolved)
+	}
+
+	_, err = w.Commit()
+	return err
+}
+
+// done records the result of a transfer and reports the progress.
+func (task *syncTask) done(ctx *astral.Context, id *astral.ObjectID, err error, pushed bool) {
+	task.mu.Lock()
+	defer task.mu.Unlock()
+
+	switch {
+	case err != nil:
+		task.mod.log.Errorv(1, "sync %v with %v: %v: %v", task.remoteRepo, task.node, id, err)
+		task.report.Failed++
+	case pushed:
+		task.report.Pushed++
+	default:
+		task.report.Pulled++
+	}
+
+	if task.onReport != nil && ctx.Err() == nil {
+		report := task.report
+		task.onReport(&report)
+	}
+}
+
+func (task *syncTask) Report() *objects.SyncReport {
+	task.mu.Lock()
+	defer task.mu.Unlock()
+
+	report := task.report
+	return &report
+}
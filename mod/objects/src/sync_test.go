@@ -0,0 +1,97 @@
+package objects
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/astral/channel"
+)
+
+func testSyncIDs(t *testing.T, prefix string, n int) (ids []*astral.ObjectID) {
+	t.Helper()
+
+	for i := 0; i < n; i++ {
+		id, err := astral.Resolve(strings.NewReader(fmt.Sprintf("%s-%d", prefix, i)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+	return
+}
+
+// pipeWriter drops empty writes, which would block on an io.Pipe until the other side reads.
+type pipeWriter struct{ *io.PipeWriter }
+
+func (w pipeWriter) Write(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	return w.PipeWriter.Write(p)
+}
+
+// runReconcile reconciles two sets over an in-memory connection.
+func runReconcile(t *testing.T, local, remote []*astral.ObjectID) (need, have []*astral.ObjectID) {
+	t.Helper()
+
+	ar, bw := io.Pipe()
+	br, aw := io.Pipe()
+
+	done := make(chan error, 1)
+	go func() {
+		ch := channel.New(channel.Join(br, pipeWriter{bw}))
+		done <- respondReconcile(ch, newSyncSet(remote))
+		bw.Close()
+	}()
+
+	need, have, err := reconcile(channel.New(channel.Join(ar, pipeWriter{aw})), newSyncSet(local))
+	if err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("respond: %v", err)
+	}
+	return
+}
+
+func sameIDs(a, b []*astral.ObjectID) bool {
+	set := newSyncSet(a)
+	if len(set.ids) != len(newSyncSet(b).ids) {
+		return false
+	}
+	for _, id := range b {
+		if !set.has(id) {
+			return false
+		}
+	}
+	return true
+}
+
+// Reconciliation finds exactly the objects held by only one side.
+func TestReconcile(t *testing.T) {
+	common := testSyncIDs(t, "common", 3000)
+	onlyLocal := testSyncIDs(t, "local", 40)
+	onlyRemote := testSyncIDs(t, "remote", 70)
+
+	need, have := runReconcile(t, slices.Concat(common, onlyLocal), slices.Concat(common, onlyRemote))
+	if !sameIDs(need, onlyRemote) {
+		t.Errorf("need %d objects, want %d", len(need), len(onlyRemote))
+	}
+	if !sameIDs(have, onlyLocal) {
+		t.Errorf("have %d objects, want %d", len(have), len(onlyLocal))
+	}
+
+	need, have = runReconcile(t, common, common)
+	if len(need) != 0 || len(have) != 0 {
+		t.Errorf("identical sets differ by %d/%d objects", len(need), len(have))
+	}
+
+	need, have = runReconcile(t, nil, common)
+	if !sameIDs(need, common) || len(have) != 0 {
+		t.Errorf("empty local set: need %d, have %d", len(need), len(have))
+	}
+}
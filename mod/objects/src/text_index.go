@@ -0,0 +1,231 @@
+package objects
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/mod/objects"
+)
+
+const textDescribeTimeout = 10 * time.Second // how long describers get to describe an indexed object
+
+// runTextIndex keeps the text index in sync with the configured repositories.
+func (mod *Module) runTextIndex(ctx *astral.Context) {
+	ctx = ctx.WithZone(astral.ZoneDevice | astral.ZoneVirtual)
+
+	for _, name := range mod.config.TextIndex.Repos {
+		go func() {
+			err := mod.followTextIndex(ctx, name)
+			if err != nil && ctx.Err() == nil {
+				mod.log.Errorv(1, "text index %v: %v", name, err)
+			}
+		}()
+	}
+}
+
+// followTextIndex indexes the objects of a repository, removes objects that are no longer in it
+// from the index, and then indexes new objects as they appear until ctx is canceled.
+func (mod *Module) followTextIndex(ctx *astral.Context, name string) error {
+	repo := mod.GetRepository(name)
+	if repo == nil {
+		return fmt.Errorf("repository %s not found", name)
+	}
+
+	scan, err := repo.Scan(ctx, true)
+	if err != nil {
+		return err
+	}
+
+	// take a snapshot
+	var snapshot []*astral.ObjectID
+	for id := range scan {
+		if id == nil {
+			break
+		}
+		snapshot = append(snapshot, id)
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	// drop objects that left the repository
+	present := make(map[astral.ObjectID]bool, len(snapshot))
+	for _, id := range snapshot {
+		present[*id] = true
+	}
+
+	indexed, err := mod.db.FindTextRepoObjects(name)
+	if err != nil {
+		return err
+	}
+	for _, id := range indexed {
+		if present[*id] {
+			continue
+		}
+		if err := mod.db.RemoveTextRepo(name, id); err != nil {
+			mod.log.Errorv(1, "text index %v: remove %v: %v", name, id, err)
+		}
+	}
+
+	for _, id := range snapshot {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		mod.indexText(ctx, name, repo, id)
+	}
+
+	mod.log.Logv(1, "text index synced with %v (%v objects)", name, len(snapshot))
+
+	// follow new objects
+	for id := range scan {
+		if id != nil {
+			mod.indexText(ctx, name, repo, id)
+		}
+	}
+
+	return ctx.Err()
+}
+
+// indexText adds an object found in a repository to the text index. Objects are indexed once;
+// after that only the repository is recorded.
+func (mod *Module) indexText(ctx *astral.Context, name string, repo objects.Repository, id *astral.ObjectID) {
+	indexed, err := mod.db.IsTextIndexed(id)
+	if err == nil && !indexed {
+		err = mod.createTextEntry(ctx, repo, id)
+	}
+	if err == nil {
+		err = mod.db.AddTextRepo(name, id)
+	}
+	if err != nil && ctx.Err() == nil {
+		mod.log.Errorv(2, "text index %v: %v: %v", name, id, err)
+	}
+}
+
+func (mod *Module) createTextEntry(ctx *astral.Context, repo objects.Repository, id *astral.ObjectID) error {
+	probe, err := mod.Probe(ctx, repo, id)
+	if err != nil {
+		return err
+	}
+
+	mime, _, _ := strings.Cut(string(probe.Mime), ";")
+
+	var tags []dbTextTag
+	addTag := func(name, value string) {
+		value = strings.ToLower(strings.TrimSpace(value))
+		if len(value) > 0 {
+			tags = append(tags, dbTextTag{ObjectID: id, Name: strings.ToLower(name), Value: value})
+		}
+	}
+
+	addTag("type", string(probe.Type))
+	addTag("mime", mime)
+
+	// index the text content
+	var content string
+	if strings.HasPrefix(mime, "text/") {
+		content, err = mod.readText(ctx, repo, id)
+		if err != nil {
+			return err
+		}
+	}
+
+	// index the fields of descriptors
+	dctx, cancel := ctx.WithTimeout(textDescribeTimeout)
+	defer cancel()
+
+	descs, err := mod.Describe(dctx, id)
+	if err == nil {
+		for desc := range descs {
+			if desc.Data != nil {
+				descriptorTags(desc.Data, addTag)
+			}
+		}
+	}
+
+	return mod.db.CreateTextEntry(id, content, tags)
+}
+
+// readText reads the text content of an object, up to the configured size limit.
+func (mod *Module) readText(ctx *astral.Context, repo objects.Repository, id *astral.ObjectID) (string, error) {
+	r, err := repo.Read(ctx, id, 0, mod.config.TextIndex.MaxText)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+
+	if !utf8.Valid(data) {
+		return strings.ToValidUTF8(string(data), " "), nil
+	}
+	return string(data), nil
+}
+
+// descriptorTags passes the exported fields of a descriptor that have a text form as tags named
+// after the fields.
+func descriptorTags(data astral.Object, addTag func(name, value string)) {
+	v := reflect.Indirect(reflect.ValueOf(data))
+	if v.Kind() != reflect.Struct {
+		return
+	}
+
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		if value, ok := textValue(v.Field(i)); ok {
+			addTag(field.Name, value)
+		}
+	}
+}
+
+// textValue returns the text form of a string, number, bool or fmt.Stringer value.
+func textValue(v reflect.Value) (string, bool) {
+	if v.Kind() == reflect.Pointer && v.IsNil() {
+		return "", false
+	}
+
+	if s, ok := v.Interface().(fmt.Stringer); ok {
+		return s.String(), true
+	}
+
+	switch reflect.Indirect(v).Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return fmt.Sprint(reflect.Indirect(v).Interface()), true
+	}
+
+	return "", false
+}
+
+// textIndexHolds returns true if an indexed object is still in one of the repositories it was found in.
+func (mod *Module) textIndexHolds(ctx *astral.Context, id *astral.ObjectID) bool {
+	repos, err := mod.db.FindTextObjectRepos(id)
+	if err != nil {
+		return false
+	}
+
+	for _, name := range repos {
+		repo := mod.GetRepository(name)
+		if repo == nil {
+			continue
+		}
+		if has, _ := repo.Contains(ctx, id); has {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,101 @@
+package objects
+
+import (
+	"testing"
+
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/mod/objects"
+	"github.com/cryptopunkscc/astrald/mod/objects/mem"
+)
+
+// staticDescriber describes objects with fixed descriptor data.
+type staticDescriber struct {
+	data map[astral.ObjectID]astral.Object
+}
+
+func (d *staticDescriber) DescribeObject(ctx *astral.Context, id *astral.ObjectID) (<-chan *objects.Descriptor, error) {
+	var results = make(chan *objects.Descriptor, 1)
+	defer close(results)
+
+	if data, ok := d.data[*id]; ok {
+		results <- &objects.Descriptor{ObjectID: id, Data: data}
+	}
+	return results, nil
+}
+
+func TestTextIndex_Search(t *testing.T) {
+	mod := newTestModule(t)
+	mod.config = defaultConfig
+	if err := mod.db.MigrateTextIndex(); err != nil {
+		t.Fatalf("migrate text index: %v", err)
+	}
+
+	ctx := astral.NewContext(nil)
+	local := mem.New("local", 1<<20)
+	mod.repos.Set("local", local)
+
+	notes := storeBytes(t, local, []byte("meeting notes: the budget for the garden project"))
+	recipe := storeBytes(t, local, []byte("garden salad recipe with garden herbs"))
+	report := storeBytes(t, local, []byte("quarterly budget report"))
+
+	mod.describers.Add(&staticDescriber{data: map[astral.ObjectID]astral.Object{
+		*report: &objects.ScrubReport{Repo: "archive"},
+	}})
+
+	for _, id := range []*astral.ObjectID{notes, recipe, report} {
+		mod.indexText(ctx, "local", local, id)
+	}
+
+	tests := []struct {
+		query string
+		want  []*astral.ObjectID
+	}{
+		{"garden", []*astral.ObjectID{recipe, notes}}, // more matches rank first
+		{"budget", []*astral.ObjectID{notes, report}},
+		{"budg", []*astral.ObjectID{notes, report}}, // word prefixes match
+		{"budget garden", []*astral.ObjectID{notes}},
+		{"budget -repo:archive", []*astral.ObjectID{notes}},
+		{"repo:archive", []*astral.ObjectID{report}},
+		{"budget ?repo:archive", []*astral.ObjectID{report, notes}},
+		{"archive", []*astral.ObjectID{report}}, // tag values are searchable text
+		{"missing", nil},
+	}
+
+	for _, test := range tests {
+		var q objects.SearchQuery
+		q.UnmarshalText([]byte(test.query))
+
+		matches, err := mod.db.SearchText(q, textSearchLimit)
+		if err != nil {
+			t.Fatalf("search %q: %v", test.query, err)
+		}
+
+		var got []*astral.ObjectID
+		for _, match := range matches {
+			got = append(got, match.ObjectID)
+		}
+
+		if len(got) != len(test.want) {
+			t.Errorf("search %q: got %v, want %v", test.query, got, test.want)
+			continue
+		}
+		for i := range got {
+			if !got[i].IsEqual(test.want[i]) {
+				t.Errorf("search %q: got %v, want %v", test.query, got, test.want)
+				break
+			}
+		}
+	}
+
+	// deleted objects are dropped on the next sync and skipped until then
+	local.Delete(ctx, recipe)
+	if mod.textIndexHolds(ctx, recipe) {
+		t.Error("deleted object still held")
+	}
+	if err := mod.db.RemoveTextRepo("local", recipe); err != nil {
+		t.Fatal(err)
+	}
+	if indexed, _ := mod.db.IsTextIndexed(recipe); indexed {
+		t.Error("removed object still indexed")
+	}
+}
@@ -0,0 +1,87 @@
+package objects
+
+import (
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/mod/objects"
+)
+
+// textSearchLimit is the maximum number of results of a single text search
+const textSearchLimit = 1000
+
+var _ objects.RankedSearcher = &textSearcher{}
+
+// textSearcher searches the full-text index of the module. Supported tags are the names of
+// tags found in the index; results are sent best matches first.
+type textSearcher struct {
+	mod *Module
+}
+
+func (s *textSearcher) SearchObject(ctx *astral.Context, query objects.SearchQuery) (<-chan *objects.SearchResult, error) {
+	ranked, err := s.SearchRanked(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	var results = make(chan *objects.SearchResult)
+
+	go func() {
+		defer close(results)
+
+		for result := range ranked {
+			select {
+			case results <- result.SearchResult():
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return results, nil
+}
+
+// SearchRanked runs the search like SearchObject, scoring results by the relevance of the match.
+func (s *textSearcher) SearchRanked(ctx *astral.Context, query objects.SearchQuery) (<-chan *objects.ScoredResult, error) {
+	if !ctx.Zone().Is(astral.ZoneDevice) {
+		return nil, astral.ErrZoneExcluded
+	}
+
+	names, err := s.mod.db.TextTagNames()
+	if err != nil {
+		return nil, err
+	}
+
+	err = query.RequiredTagsIn(names...)
+	if err != nil {
+		return nil, err
+	}
+
+	matches, err := s.mod.db.SearchText(query, textSearchLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	var results = make(chan *objects.ScoredResult)
+
+	go func() {
+		defer close(results)
+
+		for _, match := range matches {
+			// deletions are only noticed by the index on the next scan, so skip objects that are gone
+			if !s.mod.textIndexHolds(ctx, match.ObjectID) {
+				continue
+			}
+
+			select {
+			case results <- &objects.ScoredResult{
+				SourceID: s.mod.node.Identity(),
+				ObjectID: match.ObjectID,
+				Score:    astral.Float64(match.Score),
+			}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return results, nil
+}
@@ -0,0 +1,84 @@
+package objects
+
+import (
+	"io"
+
+	"github.com/cryptopunkscc/astrald/astral"
+)
+
+// SyncRange modes
+const (
+	SyncSkip        = 0 // the range is in sync
+	SyncFingerprint = 1 // Fingerprint and Count describe the sender's objects in the range
+	SyncList        = 2 // IDs lists all the sender's objects in the range
+)
+
+// Sync directions
+const (
+	SyncPull = "pull" // copy objects missing locally from the remote repository
+	SyncPush = "push" // copy objects missing remotely to the remote repository
+	SyncBoth = "both"
+)
+
+// SyncRange is a message of the range-based set reconciliation used by objects.sync. A range
+// covers the object keys from Lower (inclusive) to Upper (exclusive, empty for no bound).
+type SyncRange struct {
+	Lower       astral.Bytes8
+	Upper       astral.Bytes8
+	Mode        astral.Uint8
+	Fingerprint astral.Bytes8
+	Count       astral.Uint32
+	IDs         []*astral.ObjectID
+}
+
+var _ astral.Object = &SyncRange{}
+
+func (SyncRange) ObjectType() string { return "mod.objects.sync_range" }
+
+func (r SyncRange) WriteTo(w io.Writer) (n int64, err error) {
+	return astral.Objectify(&r).WriteTo(w)
+}
+
+func (r *SyncRange) ReadFrom(src io.Reader) (n int64, err error) {
+	return astral.Objectify(r).ReadFrom(src)
+}
+
+func (r SyncRange) MarshalJSON() ([]byte, error) {
+	return astral.Objectify(&r).MarshalJSON()
+}
+
+func (r *SyncRange) UnmarshalJSON(bytes []byte) error {
+	return astral.Objectify(r).UnmarshalJSON(bytes)
+}
+
+// SyncReport sums up the objects transferred by objects.sync so far.
+type SyncReport struct {
+	Pulled astral.Uint32
+	Pushed astral.Uint32
+	Failed astral.Uint32
+}
+
+var _ astral.Object = &SyncReport{}
+
+func (SyncReport) ObjectType() string { return "mod.objects.sync_report" }
+
+func (r SyncReport) WriteTo(w io.Writer) (n int64, err error) {
+	return astral.Objectify(&r).WriteTo(w)
+}
+
+func (r *SyncReport) ReadFrom(src io.Reader) (n int64, err error) {
+	return astral.Objectify(r).ReadFrom(src)
+}
+
+func (r SyncReport) MarshalJSON() ([]byte, error) {
+	return astral.Objectify(&r).MarshalJSON()
+}
+
+func (r *SyncReport) UnmarshalJSON(bytes []byte) error {
+	return astral.Objectify(r).UnmarshalJSON(bytes)
+}
+
+func init() {
+	_ = astral.Add(&SyncRange{})
+	_ = astral.Add(&SyncReport{})
+}
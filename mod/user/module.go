@@ -23,6 +23,10 @@ const (
 	OpSyncWith          = "user.sync_with"
 	OpExpel             = "user.expel"
 	OpListExpelled      = "user.list_expelled"
+
+	OpSetReplicationRule    = "user.set_replication_rule"
+	OpRemoveReplicationRule = "user.remove_replication_rule"
+	OpReplicationRules      = "user.replication_rules"
 )
 
 type Module interface {
@@ -41,4 +45,9 @@ type Module interface {
 	// Expel permanently bans nodeID from the swarm. Only the active contract's
 	// issuer may expel and the ban is irreversible.
 	Expel(ctx *astral.Context, nodeID *astral.Identity) (*SignedExpulsion, error)
+	// SetReplicationRule stores a rule and shares it with the local swarm, whose
+	// members enforce it in the background.
+	SetReplicationRule(ctx *astral.Context, rule *ReplicationRule) error
+	// RemoveReplicationRule removes a rule from every swarm member.
+	RemoveReplicationRule(ctx *astral.Context, name string) error
 }
@@ -0,0 +1,60 @@
+package user
+
+import (
+	"io"
+
+	"github.com/cryptopunkscc/astrald/astral"
+)
+
+// ReplicationRule declares how the objects of a repository are replicated across the swarm.
+// Every object in Repo must exist on at least Copies swarm members, and on every member
+// tagged with Tag. Rules are shared by all swarm members; the newest version of a rule wins.
+type ReplicationRule struct {
+	Name      astral.String8
+	Repo      astral.String8
+	Copies    astral.Uint8   // minimum number of swarm members holding each object, 0 to disable
+	Tag       astral.String8 // members with this tag hold every object, empty to disable
+	UpdatedAt astral.Time
+	Removed   astral.Bool
+}
+
+var _ astral.Object = &ReplicationRule{}
+
+func (ReplicationRule) ObjectType() string { return "mod.user.replication_rule" }
+
+func (r ReplicationRule) WriteTo(w io.Writer) (int64, error) {
+	return astral.Objectify(&r).WriteTo(w)
+}
+
+func (r *ReplicationRule) ReadFrom(src io.Reader) (int64, error) {
+	return astral.Objectify(r).ReadFrom(src)
+}
+
+func (r ReplicationRule) MarshalJSON() ([]byte, error)  { return astral.Objectify(&r).MarshalJSON() }
+func (r *ReplicationRule) UnmarshalJSON(b []byte) error { return astral.Objectify(r).UnmarshalJSON(b) }
+
+// ReplicaRequest asks a swarm member to keep a copy of an object on behalf of a rule.
+type ReplicaRequest struct {
+	ObjectID *astral.ObjectID
+	Rule     astral.String8
+}
+
+var _ astral.Object = &ReplicaRequest{}
+
+func (ReplicaRequest) ObjectType() string { return "mod.user.replica_request" }
+
+func (r ReplicaRequest) WriteTo(w io.Writer) (int64, error) {
+	return astral.Objectify(&r).WriteTo(w)
+}
+
+func (r *ReplicaRequest) ReadFrom(src io.Reader) (int64, error) {
+	return astral.Objectify(r).ReadFrom(src)
+}
+
+func (r ReplicaRequest) MarshalJSON() ([]byte, error)  { return astral.Objectify(&r).MarshalJSON() }
+func (r *ReplicaRequest) UnmarshalJSON(b []byte) error { return astral.Objectify(r).UnmarshalJSON(b) }
+
+func init() {
+	_ = astral.Add(&ReplicationRule{})
+	_ = astral.Add(&ReplicaRequest{})
+}
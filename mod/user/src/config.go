@@ -14,4 +14,7 @@ const (
 
 type Config struct {
 	ActiveContract tree.Value[*auth.SignedContract]
+
+	// Tags of this node matched against the Tag of replication rules
+	Tags []string
 }
@@ -0,0 +1,114 @@
+package user
+
+import (
+	"time"
+
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/mod/user"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// dbReplicationRule stores the newest known version of a replication rule. Removed rules are
+// kept as tombstones so that an older version received later doesn't bring them back.
+type dbReplicationRule struct {
+	Name      string `gorm:"primaryKey"`
+	Repo      string
+	Copies    uint8
+	Tag       string
+	UpdatedAt time.Time `gorm:"autoUpdateTime:false"`
+	Removed   bool
+}
+
+func (dbReplicationRule) TableName() string { return user.DBPrefix + "replication_rules" }
+
+// dbReplica marks an object this node keeps on behalf of a replication rule.
+type dbReplica struct {
+	ObjectID *astral.ObjectID `gorm:"primaryKey"`
+	Rule     string           `gorm:"primaryKey"`
+}
+
+func (dbReplica) TableName() string { return user.DBPrefix + "replicas" }
+
+// SaveReplicationRule stores a rule unless the same or a newer version is stored. Returns true
+// if the rule was saved. Saving a removed rule drops its replicas.
+func (db *DB) SaveReplicationRule(rule *user.ReplicationRule) (saved bool, err error) {
+	err = db.Transaction(func(tx *gorm.DB) error {
+		var row dbReplicationRule
+		err := tx.Where("name = ?", string(rule.Name)).Limit(1).Find(&row).Error
+		if err != nil {
+			return err
+		}
+		if row.Name != "" && !rule.UpdatedAt.Time().After(row.UpdatedAt) {
+			return nil
+		}
+
+		saved = true
+		err = tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(&dbReplicationRule{
+			Name:      string(rule.Name),
+			Repo:      string(rule.Repo),
+			Copies:    uint8(rule.Copies),
+			Tag:       string(rule.Tag),
+			UpdatedAt: rule.UpdatedAt.Time(),
+			Removed:   bool(rule.Removed),
+		}).Error
+		if err != nil || !bool(rule.Removed) {
+			return err
+		}
+
+		return tx.Where("rule = ?", string(rule.Name)).Delete(&dbReplica{}).Error
+	})
+	return
+}
+
+// ReplicationRules returns all stored rules, including removed ones.
+func (db *DB) ReplicationRules() (rules []*user.ReplicationRule, err error) {
+	var rows []dbReplicationRule
+	err = db.Order("name").Find(&rows).Error
+	if err != nil {
+		return
+	}
+
+	for _, row := range rows {
+		rules = append(rules, row.rule())
+	}
+	return
+}
+
+func (db *DB) AddReplica(objectID *astral.ObjectID, rule string) error {
+	return db.Clauses(clause.OnConflict{DoNothing: true}).Create(&dbReplica{
+		ObjectID: objectID,
+		Rule:     rule,
+	}).Error
+}
+
+func (db *DB) isReplica(objectID *astral.ObjectID) (exists bool) {
+	db.Model(&dbReplica{}).
+		Select("1").
+		Where("object_id = ?", objectID).
+		Limit(1).
+		Scan(&exists)
+	return
+}
+
+// FindReplicationRule returns the stored version of a rule or nil if none is stored.
+func (db *DB) FindReplicationRule(name string) (*user.ReplicationRule, error) {
+	var rows []dbReplicationRule
+	err := db.Where("name = ?", name).Limit(1).Find(&rows).Error
+	if err != nil || len(rows) == 0 {
+		return nil, err
+	}
+
+	return rows[0].rule(), nil
+}
+
+func (row *dbReplicationRule) rule() *user.ReplicationRule {
+	return &user.ReplicationRule{
+		Name:      astral.String8(row.Name),
+		Repo:      astral.String8(row.Repo),
+		Copies:    astral.Uint8(row.Copies),
+		Tag:       astral.String8(row.Tag),
+		UpdatedAt: astral.Time(row.UpdatedAt),
+		Removed:   astral.Bool(row.Removed),
+	}
+}
@@ -0,0 +1,58 @@
+package user
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/mod/user"
+)
+
+// A stale version of a rule never replaces a newer one, and removing a rule releases its replicas.
+func TestSaveReplicationRule_NewestWins(t *testing.T) {
+	db := testDB(t)
+	if err := db.AutoMigrate(&dbReplicationRule{}, &dbReplica{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	at := time.Unix(1700000000, 0).UTC()
+	rule := &user.ReplicationRule{Name: "photos", Repo: "local", Copies: 2, UpdatedAt: astral.Time(at)}
+
+	if saved, err := db.SaveReplicationRule(rule); err != nil || !saved {
+		t.Fatalf("rule not saved (err %v)", err)
+	}
+
+	stale := *rule
+	stale.Copies = 5
+	stale.UpdatedAt = astral.Time(at.Add(-time.Minute))
+	if saved, err := db.SaveReplicationRule(&stale); err != nil || saved {
+		t.Fatalf("stale rule saved (err %v)", err)
+	}
+
+	objectID, err := astral.Resolve(strings.NewReader("replica"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AddReplica(objectID, "photos"); err != nil {
+		t.Fatal(err)
+	}
+	if !db.isReplica(objectID) {
+		t.Fatal("replica not held")
+	}
+
+	removed := *rule
+	removed.Removed = true
+	removed.UpdatedAt = astral.Time(at.Add(time.Minute))
+	if saved, err := db.SaveReplicationRule(&removed); err != nil || !saved {
+		t.Fatalf("removal not saved (err %v)", err)
+	}
+
+	found, err := db.FindReplicationRule("photos")
+	if err != nil || found == nil || !found.Removed || found.Copies != 2 {
+		t.Fatalf("got %+v (err %v), want removed rule with 2 copies", found, err)
+	}
+	if db.isReplica(objectID) {
+		t.Fatal("replica still held after the rule was removed")
+	}
+}
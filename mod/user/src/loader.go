@@ -17,6 +17,9 @@ func (Loader) Load(node astral.Node, assets assets.Assets, log *log.Logger) (cor
 		log:    log,
 		assets: assets,
 		ready:  make(chan struct{}),
+
+		replicate: make(chan struct{}, 1),
+		replicas:  make(chan *user.ReplicaRequest, replicaQueueSize),
 	}
 
 	_ = assets.LoadYAML(user.ModuleName, &mod.config)
@@ -27,7 +30,7 @@ func (Loader) Load(node astral.Node, assets assets.Assets, log *log.Logger) (cor
 
 	mod.db = &DB{DB: assets.Database(), mod: mod}
 
-	err = mod.db.AutoMigrate(&dbAsset{}, &dbExpulsion{})
+	err = mod.db.AutoMigrate(&dbAsset{}, &dbExpulsion{}, &dbReplicationRule{}, &dbReplica{})
 	if err != nil {
 		return nil, err
 	}
@@ -26,6 +26,9 @@ type Module struct {
 	ready          chan struct{}
 
 	sibs sig.Map[string, Sibling]
+
+	replicate chan struct{}
+	replicas  chan *user.ReplicaRequest // replica requests waiting for a worker
 }
 
 func (mod *Module) Run(ctx *astral.Context) error {
@@ -42,6 +45,10 @@ func (mod *Module) Run(ctx *astral.Context) error {
 	}()
 
 	mod.runSiblingLinker()
+	go mod.runReplication(mod.ctx)
+	for range replicaWorkers {
+		go mod.runReplicaWorker(mod.ctx)
+	}
 	<-ctx.Done()
 
 	return nil
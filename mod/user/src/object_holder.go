@@ -8,5 +8,5 @@ import (
 var _ objects.Holder = &Module{}
 
 func (mod *Module) HoldObject(objectID *astral.ObjectID) (hold bool) {
-	return mod.db.assetExists(objectID)
+	return mod.db.assetExists(objectID) || mod.db.isReplica(objectID)
 }
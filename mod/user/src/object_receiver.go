@@ -27,6 +27,16 @@ func (mod *Module) ReceiveObject(drop objects.Drop) (err error) {
 		if err == nil {
 			drop.Accept(true)
 		}
+	case *user.ReplicationRule:
+		err = mod.receiveReplicationRule(drop.SenderID(), o)
+		if err == nil {
+			drop.Accept(false)
+		}
+	case *user.ReplicaRequest:
+		err = mod.receiveReplicaRequest(drop.SenderID(), o)
+		if err == nil {
+			drop.Accept(false)
+		}
 	case *events.Event:
 		switch e := o.Data.(type) {
 		case *nodes.LinkCreatedEvent:
@@ -0,0 +1,35 @@
+package user
+
+import (
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/astral/channel"
+	"github.com/cryptopunkscc/astrald/lib/routing"
+)
+
+type opRemoveReplicationRuleArgs struct {
+	Name string
+	Out  string `query:"optional"`
+}
+
+// OpRemoveReplicationRule removes a replication rule from the swarm.
+// Requires an active contract; caller must be the contract issuer or the node (code 3 otherwise).
+func (mod *Module) OpRemoveReplicationRule(ctx *astral.Context, q *routing.IncomingQuery, args opRemoveReplicationRuleArgs) (err error) {
+	ac := mod.ActiveContract()
+	if ac == nil {
+		return q.RejectWithCode(2)
+	}
+
+	if !q.Caller().IsEqual(ac.Issuer) && !q.Caller().IsEqual(mod.node.Identity()) {
+		return q.RejectWithCode(3)
+	}
+
+	ch := channel.New(q.AcceptRaw(), channel.WithOutputFormat(args.Out))
+	defer ch.Close()
+
+	err = mod.RemoveReplicationRule(ctx, args.Name)
+	if err != nil {
+		return ch.Send(astral.Err(err))
+	}
+
+	return ch.Send(&astral.Ack{})
+}
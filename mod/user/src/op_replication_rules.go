@@ -0,0 +1,36 @@
+package user
+
+import (
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/astral/channel"
+	"github.com/cryptopunkscc/astrald/lib/routing"
+)
+
+type opReplicationRulesArgs struct {
+	Out string `query:"optional"`
+}
+
+// OpReplicationRules streams the replication rules in effect, terminated by EOS.
+func (mod *Module) OpReplicationRules(ctx *astral.Context, q *routing.IncomingQuery, args opReplicationRulesArgs) (err error) {
+	ac := mod.ActiveContract()
+	if ac == nil {
+		return q.RejectWithCode(2)
+	}
+
+	ch := channel.New(q.AcceptRaw(), channel.WithOutputFormat(args.Out))
+	defer ch.Close()
+
+	rules, err := mod.ReplicationRules()
+	if err != nil {
+		return ch.Send(astral.Err(err))
+	}
+
+	for _, rule := range rules {
+		err = ch.Send(rule)
+		if err != nil {
+			return
+		}
+	}
+
+	return ch.Send(&astral.EOS{})
+}
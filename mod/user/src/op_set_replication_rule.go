@@ -0,0 +1,50 @@
+package user
+
+import (
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/astral/channel"
+	"github.com/cryptopunkscc/astrald/lib/routing"
+	"github.com/cryptopunkscc/astrald/mod/user"
+)
+
+type opSetReplicationRuleArgs struct {
+	Name   string
+	Repo   string `query:"optional"`
+	Copies int    `query:"optional"`
+	Tag    string `query:"optional"`
+	Out    string `query:"optional"`
+}
+
+// OpSetReplicationRule creates or replaces a replication rule and returns it.
+// Requires an active contract; caller must be the contract issuer or the node (code 3 otherwise).
+func (mod *Module) OpSetReplicationRule(ctx *astral.Context, q *routing.IncomingQuery, args opSetReplicationRuleArgs) (err error) {
+	ac := mod.ActiveContract()
+	if ac == nil {
+		return q.RejectWithCode(2)
+	}
+
+	if !q.Caller().IsEqual(ac.Issuer) && !q.Caller().IsEqual(mod.node.Identity()) {
+		return q.RejectWithCode(3)
+	}
+
+	ch := channel.New(q.AcceptRaw(), channel.WithOutputFormat(args.Out))
+	defer ch.Close()
+
+	if args.Copies < 0 || args.Copies > 255 {
+		return ch.Send(astral.NewError("copies out of range"))
+	}
+
+	rule := &user.ReplicationRule{
+		Name:   astral.String8(args.Name),
+		Repo:   astral.String8(args.Repo),
+		Copies: astral.Uint8(args.Copies),
+		Tag:    astral.String8(args.Tag),
+	}
+
+	err = mod.SetReplicationRule(ctx, rule)
+	if err != nil {
+		return ch.Send(astral.Err(err))
+	}
+
+	return ch.Send(rule)
+}
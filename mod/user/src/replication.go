@@ -0,0 +1,323 @@
+package user
+
+import (
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/cryptopunkscc/astrald/astral"
+	"github.com/cryptopunkscc/astrald/lib/astrald"
+	"github.com/cryptopunkscc/astrald/mod/objects"
+	objectscli "github.com/cryptopunkscc/astrald/mod/objects/client"
+	"github.com/cryptopunkscc/astrald/mod/user"
+)
+
+const (
+	replicationInterval = 15 * time.Minute
+	siblingScanTimeout  = 5 * time.Minute
+	replicaWorkers      = 4   // replica requests downloaded at once
+	replicaQueueSize    = 256 // replica requests waiting for a worker
+)
+
+// SetReplicationRule stores a rule, shares it with the local swarm and schedules a replication pass.
+// The rule replaces every earlier rule with the same name.
+func (mod *Module) SetReplicationRule(ctx *astral.Context, rule *user.ReplicationRule) error {
+	switch {
+	case len(rule.Name) == 0:
+		return errors.New("rule name is required")
+	case rule.Copies == 0 && len(rule.Tag) == 0:
+		return errors.New("rule needs a number of copies or a tag")
+	}
+
+	if len(rule.Repo) == 0 {
+		rule.Repo = objects.RepoLocal
+	}
+	rule.UpdatedAt = astral.Now()
+	rule.Removed = false
+
+	return mod.saveReplicationRule(ctx, rule)
+}
+
+// RemoveReplicationRule removes a rule from every swarm member. Objects replicated for the rule
+// are no longer held and may be purged.
+func (mod *Module) RemoveReplicationRule(ctx *astral.Context, name string) error {
+	rule, err := mod.db.FindReplicationRule(name)
+	switch {
+	case err != nil:
+		return err
+	case rule == nil || bool(rule.Removed):
+		return errors.New("rule not found")
+	}
+
+	rule.UpdatedAt = astral.Now()
+	rule.Removed = true
+
+	return mod.saveReplicationRule(ctx, rule)
+}
+
+func (mod *Module) saveReplicationRule(ctx *astral.Context, rule *user.ReplicationRule) error {
+	saved, err := mod.db.SaveReplicationRule(rule)
+	switch {
+	case err != nil:
+		return err
+	case !saved:
+		return errors.New("a newer version of the rule exists")
+	}
+
+	go mod.PushToLocalSwarm(ctx, rule)
+	mod.triggerReplication()
+
+	return nil
+}
+
+// ReplicationRules returns the rules in effect.
+func (mod *Module) ReplicationRules() (list []*user.ReplicationRule, err error) {
+	rules, err := mod.db.ReplicationRules()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, rule := range rules {
+		if !rule.Removed {
+			list = append(list, rule)
+		}
+	}
+	return
+}
+
+func (mod *Module) triggerReplication() {
+	select {
+	case mod.replicate <- struct{}{}:
+	default:
+	}
+}
+
+// runReplication enforces the replication rules periodically and whenever the rules change.
+func (mod *Module) runReplication(ctx *astral.Context) {
+	ticker := time.NewTicker(replicationInterval)
+	defer ticker.Stop()
+
+	for {
+		mod.replicateAll(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-mod.replicate:
+		}
+	}
+}
+
+func (mod *Module) replicateAll(ctx *astral.Context) {
+	if mod.ActiveContract() == nil {
+		return
+	}
+
+	rules, err := mod.db.ReplicationRules()
+	if err != nil {
+		mod.log.Error("replication: error getting rules: %v", err)
+		return
+	}
+
+	siblings := mod.getSiblings()
+
+	// why: members that were offline when a rule changed learn about it here; removed
+	// rules are shared as well so that a stale member can't bring them back
+	for _, sib := range siblings {
+		for _, rule := range rules {
+			mod.Objects.Push(ctx, sib, rule)
+		}
+	}
+
+	for _, rule := range rules {
+		if rule.Removed {
+			continue
+		}
+
+		if rule.Copies > 0 {
+			mod.replicateCopies(ctx, rule, siblings)
+		}
+
+		if len(rule.Tag) > 0 && slices.Contains(mod.config.Tags, string(rule.Tag)) {
+			mod.replicateTagged(ctx, rule, siblings)
+		}
+	}
+}
+
+// replicateCopies makes sure that every object in the rule's local repository is held by at least
+// rule.Copies swarm members, this node included. Missing copies are requested from linked siblings.
+// Every sibling is scanned once; siblings that can't be scanned are left out of the pass.
+func (mod *Module) replicateCopies(ctx *astral.Context, rule *user.ReplicationRule, siblings []*astral.Identity) {
+	repo := mod.Objects.GetRepository(string(rule.Repo))
+	if repo == nil {
+		return
+	}
+
+	var reachable []*astral.Identity
+	var held []map[string]struct{}
+	for _, sib := range siblings {
+		ids, err := mod.siblingObjects(ctx, sib)
+		if err != nil {
+			mod.log.Logv(1, "replication %v: scan %v: %v", rule.Name, sib, err)
+			continue
+		}
+		reachable = append(reachable, sib)
+		held = append(held, ids)
+	}
+
+	scan, err := repo.Scan(ctx.WithZone(astral.ZoneDevice|astral.ZoneVirtual), false)
+	if err != nil {
+		mod.log.Error("replication %v: scan: %v", rule.Name, err)
+		return
+	}
+
+	for objectID := range scan {
+		holders := 1
+		var missing []*astral.Identity
+
+		for i, sib := range reachable {
+			if _, found := held[i][objectID.String()]; found {
+				holders++
+			} else {
+				missing = append(missing, sib)
+			}
+		}
+
+		for _, sib := range missing {
+			if holders >= int(rule.Copies) {
+				break
+			}
+
+			err = mod.Objects.Push(ctx, sib, &user.ReplicaRequest{ObjectID: objectID, Rule: rule.Name})
+			if err != nil {
+				mod.log.Logv(1, "replication %v: request %v from %v: %v", rule.Name, objectID, sib, err)
+				continue
+			}
+			holders++
+		}
+
+		if holders < int(rule.Copies) {
+			mod.log.Logv(1, "replication %v: %v has %v of %v copies", rule.Name, objectID, holders, rule.Copies)
+		}
+	}
+}
+
+// replicateTagged downloads every object from the rule's repository on the linked siblings that
+// this node doesn't hold yet.
+func (mod *Module) replicateTagged(ctx *astral.Context, rule *user.ReplicationRule, siblings []*astral.Identity) {
+	local := ctx.WithZone(astral.ZoneDevice | astral.ZoneVirtual)
+
+	for _, sib := range siblings {
+		scan, errPtr := objectscli.New(sib, astrald.Default()).Scan(ctx, string(rule.Repo), false)
+		if scan == nil {
+			mod.log.Logv(1, "replication %v: scan %v: %v", rule.Name, sib, *errPtr)
+			continue
+		}
+
+		for objectID := range scan {
+			if has, _ := mod.replicaRepo().Contains(local, objectID); has {
+				continue
+			}
+
+			err := mod.storeReplica(ctx, objectID, string(rule.Name))
+			if err != nil {
+				mod.log.Logv(1, "replication %v: download %v: %v", rule.Name, objectID, err)
+			}
+		}
+		if *errPtr != nil {
+			mod.log.Logv(1, "replication %v: scan %v: %v", rule.Name, sib, *errPtr)
+		}
+	}
+}
+
+// siblingObjects returns the IDs of the objects a sibling stores in its local repository.
+func (mod *Module) siblingObjects(ctx *astral.Context, sib *astral.Identity) (map[string]struct{}, error) {
+	ctx, cancel := ctx.WithTimeout(siblingScanTimeout)
+	defer cancel()
+
+	scan, errPtr := objectscli.New(sib, astrald.Default()).Scan(ctx, objects.RepoLocal, false)
+	if scan == nil {
+		return nil, *errPtr
+	}
+
+	var ids = map[string]struct{}{}
+	for objectID := range scan {
+		ids[objectID.String()] = struct{}{}
+	}
+
+	return ids, *errPtr
+}
+
+// replicaRepo returns the repository replicas are stored in.
+func (mod *Module) replicaRepo() objects.Repository {
+	if repo := mod.Objects.GetRepository(objects.RepoLocal); repo != nil {
+		return repo
+	}
+	return mod.Objects.WriteDefault()
+}
+
+// storeReplica downloads an object and holds it on behalf of a rule. The context must include
+// the network zone.
+func (mod *Module) storeReplica(ctx *astral.Context, objectID *astral.ObjectID, rule string) error {
+	err := mod.Objects.Download(ctx, objectID, mod.replicaRepo())
+	if err != nil {
+		return err
+	}
+
+	return mod.db.AddReplica(objectID, rule)
+}
+
+// receiveReplicationRule stores a rule shared by another swarm member.
+func (mod *Module) receiveReplicationRule(sender *astral.Identity, rule *user.ReplicationRule) error {
+	if !slices.ContainsFunc(mod.LocalSwarm(), sender.IsEqual) || len(rule.Name) == 0 {
+		return objects.ErrPushRejected
+	}
+
+	saved, err := mod.db.SaveReplicationRule(rule)
+	if err != nil {
+		return err
+	}
+
+	if saved {
+		mod.log.Logv(1, "replication rule %v updated by %v", rule.Name, sender)
+		mod.triggerReplication()
+	}
+
+	return nil
+}
+
+// receiveReplicaRequest queues the download of an object a swarm member asked this node to keep.
+func (mod *Module) receiveReplicaRequest(sender *astral.Identity, req *user.ReplicaRequest) error {
+	if !slices.ContainsFunc(mod.LocalSwarm(), sender.IsEqual) || req.ObjectID == nil {
+		return objects.ErrPushRejected
+	}
+
+	rule, err := mod.db.FindReplicationRule(string(req.Rule))
+	if err != nil || rule == nil || bool(rule.Removed) {
+		return objects.ErrPushRejected
+	}
+
+	// why: members ask again on their next pass, so a request that doesn't fit is dropped
+	select {
+	case mod.replicas <- req:
+		return nil
+	default:
+		return objects.ErrPushRejected
+	}
+}
+
+// runReplicaWorker downloads the objects of queued replica requests until ctx is done.
+func (mod *Module) runReplicaWorker(ctx *astral.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case req := <-mod.replicas:
+			err := mod.storeReplica(ctx, req.ObjectID, string(req.Rule))
+			if err != nil {
+				mod.log.Error("replication %v: download %v: %v", req.Rule, req.ObjectID, err)
+			}
+		}
+	}
+}